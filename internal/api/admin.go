@@ -41,6 +41,7 @@ type adminUserDeleteParams struct {
 type adminUserUpdateFactorParams struct {
 	FriendlyName string `json:"friendly_name"`
 	Phone        string `json:"phone"`
+	Email        string `json:"email"`
 }
 
 type AdminListUsersResponse struct {
@@ -630,6 +631,16 @@ func (a *API) adminUserUpdateFactor(w http.ResponseWriter, r *http.Request) erro
 			}
 		}
 
+		if params.Email != "" && factor.IsEmailFactor() {
+			email, err := a.validateEmail(params.Email)
+			if err != nil {
+				return err
+			}
+			if terr := factor.UpdateEmail(tx, email); terr != nil {
+				return terr
+			}
+		}
+
 		if terr := models.NewAuditLogEntry(config.AuditLog, r, tx, adminUser, models.UpdateFactorAction, "", map[string]interface{}{
 			"user_id":     user.ID,
 			"factor_id":   factor.ID,
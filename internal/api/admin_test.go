@@ -914,3 +914,104 @@ func (ts *AdminTestSuite) TestAdminUserCreateValidationErrors() {
 
 	}
 }
+
+func (ts *AdminTestSuite) TestAdminUserSessions() {
+	u, err := models.NewUser("", "test@example.com", "test", ts.Config.JWT.Aud, nil)
+	require.NoError(ts.T(), err, "Error making new user")
+	require.NoError(ts.T(), ts.API.db.Create(u), "Error creating user")
+
+	var sessions []*models.Session
+	for i := 0; i < 3; i++ {
+		s, err := models.NewSession(u.ID, nil)
+		require.NoError(ts.T(), err)
+		require.NoError(ts.T(), ts.API.db.Create(s))
+		sessions = append(sessions, s)
+	}
+
+	request := func(method, path string) *httptest.ResponseRecorder {
+		req := httptest.NewRequest(method, fmt.Sprintf("/admin/users/%s/sessions%s", u.ID, path), nil)
+		req.Header.Set("Authorization", fmt.Sprintf("Bearer %s", ts.token))
+		w := httptest.NewRecorder()
+		ts.API.handler.ServeHTTP(w, req)
+		return w
+	}
+
+	w := request(http.MethodGet, "")
+	require.Equal(ts.T(), http.StatusOK, w.Code)
+
+	var response SessionListResponse
+	require.NoError(ts.T(), json.NewDecoder(w.Body).Decode(&response))
+	require.Len(ts.T(), response.Sessions, 3)
+
+	w = request(http.MethodDelete, "/"+sessions[0].ID.String())
+	require.Equal(ts.T(), http.StatusNoContent, w.Code)
+
+	_, err = models.FindSessionByID(ts.API.db, sessions[0].ID, false)
+	require.True(ts.T(), models.IsNotFoundError(err))
+
+	w = request(http.MethodDelete, "/"+sessions[0].ID.String())
+	require.Equal(ts.T(), http.StatusNotFound, w.Code)
+
+	w = request(http.MethodDelete, "")
+	require.Equal(ts.T(), http.StatusNoContent, w.Code)
+
+	remaining, err := models.FindAllSessionsForUser(ts.API.db, u.ID, false)
+	require.NoError(ts.T(), err)
+	require.Empty(ts.T(), remaining)
+
+	entries := []models.AuditLogEntry{}
+	require.NoError(ts.T(), ts.API.db.Q().All(&entries))
+	revoked := 0
+	for _, entry := range entries {
+		if entry.Payload["action"] == string(models.TokenRevokedAction) {
+			assert.Equal(ts.T(), "supabase_admin", entry.Payload["actor_username"])
+			revoked++
+		}
+	}
+	assert.Equal(ts.T(), 2, revoked)
+}
+
+func (ts *AdminTestSuite) TestAdminUserTrustedDevices() {
+	u, err := models.NewUser("", "test@example.com", "test", ts.Config.JWT.Aud, nil)
+	require.NoError(ts.T(), err, "Error making new user")
+	require.NoError(ts.T(), ts.API.db.Create(u), "Error creating user")
+
+	f := models.NewTOTPFactor(u, "trusted")
+	f.Status = models.FactorStateVerified.String()
+	require.NoError(ts.T(), ts.API.db.Create(f), "Error creating factor")
+
+	var devices []*models.TrustedDevice
+	for i := 0; i < 3; i++ {
+		d, _ := models.NewTrustedDevice(u.ID, f.ID, "Mozilla/5.0", "127.0.0.1", time.Hour)
+		require.NoError(ts.T(), ts.API.db.Create(d))
+		devices = append(devices, d)
+	}
+
+	request := func(method, path string) *httptest.ResponseRecorder {
+		req := httptest.NewRequest(method, fmt.Sprintf("/admin/users/%s/trusted_devices%s", u.ID, path), nil)
+		req.Header.Set("Authorization", fmt.Sprintf("Bearer %s", ts.token))
+		w := httptest.NewRecorder()
+		ts.API.handler.ServeHTTP(w, req)
+		return w
+	}
+
+	w := request(http.MethodGet, "")
+	require.Equal(ts.T(), http.StatusOK, w.Code)
+
+	var response TrustedDeviceListResponse
+	require.NoError(ts.T(), json.NewDecoder(w.Body).Decode(&response))
+	require.Len(ts.T(), response.TrustedDevices, 3)
+
+	w = request(http.MethodDelete, "/"+devices[0].ID.String())
+	require.Equal(ts.T(), http.StatusNoContent, w.Code)
+
+	w = request(http.MethodDelete, "/"+devices[0].ID.String())
+	require.Equal(ts.T(), http.StatusNotFound, w.Code)
+
+	w = request(http.MethodDelete, "")
+	require.Equal(ts.T(), http.StatusNoContent, w.Code)
+
+	remaining, err := models.FindTrustedDevicesByUserID(ts.API.db, u.ID)
+	require.NoError(ts.T(), err)
+	require.Empty(ts.T(), remaining)
+}
@@ -11,6 +11,7 @@ import (
 	"github.com/supabase/auth/internal/api/apierrors"
 	"github.com/supabase/auth/internal/api/oauthserver"
 	"github.com/supabase/auth/internal/conf"
+	"github.com/supabase/auth/internal/geoip"
 	"github.com/supabase/auth/internal/hooks/hookshttp"
 	"github.com/supabase/auth/internal/hooks/hookspgfunc"
 	"github.com/supabase/auth/internal/hooks/v0hooks"
@@ -39,6 +40,7 @@ type API struct {
 	hooksMgr    *v0hooks.Manager
 	hibpClient  *hibp.PwnedClient
 	oauthServer *oauthserver.Server
+	geoip       *geoip.Database
 
 	// overrideTime can be used to override the clock used by handlers. Should only be used in tests!
 	overrideTime func() time.Time
@@ -120,6 +122,16 @@ func NewAPIWithVersion(globalConfig *conf.GlobalConfiguration, db *storage.Conne
 		}
 	}
 
+	if api.config.Sessions.GeoIPCountryDatabase != "" || api.config.Sessions.GeoIPASNDatabase != "" {
+		db, err := geoip.OpenDatabase(api.config.Sessions.GeoIPCountryDatabase, api.config.Sessions.GeoIPASNDatabase)
+		if err != nil {
+			// sessions are still recorded without location metadata
+			logrus.WithError(err).Error("Unable to open the GeoIP database")
+		} else {
+			api.geoip = db
+		}
+	}
+
 	api.deprecationNotices()
 
 	xffmw, _ := xff.Default()
@@ -145,8 +157,12 @@ func NewAPIWithVersion(globalConfig *conf.GlobalConfiguration, db *storage.Conne
 		r.UseBypass(api.databaseCleanup(cleanup))
 	}
 
+	r.UseBypass(api.sendSignInNotifications)
+
 	r.Get("/health", api.HealthCheck)
 	r.Get("/.well-known/jwks.json", api.Jwks)
+	r.Get("/.well-known/oauth-authorization-server", api.OAuthAuthorizationServerMetadata)
+	r.Get("/.well-known/openid-configuration", api.OpenIDConfiguration)
 
 	r.Route("/callback", func(r *router) {
 		r.Use(api.isValidExternalHost)
@@ -206,31 +222,66 @@ func NewAPIWithVersion(globalConfig *conf.GlobalConfiguration, db *storage.Conne
 		// rate limiting applied in handler
 		r.With(api.verifyCaptcha).With(api.oauthClientAuth).Post("/token", api.Token)
 
+		r.With(api.limitHandler(api.limiterOpts.Verify)).Route("/sessions/report", func(r *router) {
+			r.Get("/", api.ReportSignInConfirmation)
+			r.Post("/", api.ReportSignIn)
+		})
+
+		r.With(api.requirePasskeyEnabled).With(api.limitHandler(api.limiterOpts.Passkey)).
+			With(api.verifyCaptcha).Post("/passkeys/challenge", api.PasskeyChallenge)
+
 		r.With(api.limitHandler(api.limiterOpts.Verify)).Route("/verify", func(r *router) {
 			r.Get("/", api.Verify)
 			r.Post("/", api.Verify)
 		})
 
-		r.With(api.requireAuthentication).Post("/logout", api.Logout)
+		r.With(api.requireAuthenticationForMFAEnrollment).Post("/logout", api.Logout)
 
 		r.With(api.requireAuthentication).Route("/reauthenticate", func(r *router) {
 			r.Get("/", api.Reauthenticate)
 		})
 
-		r.With(api.requireAuthentication).Route("/user", func(r *router) {
-			r.Get("/", api.UserGet)
-			r.With(api.limitHandler(api.limiterOpts.User)).Put("/", api.UserUpdate)
+		r.Route("/user", func(r *router) {
+			r.With(api.requireAuthenticationForMFAEnrollment).Get("/", api.UserGet)
+			r.With(api.requireAuthentication).With(api.limitHandler(api.limiterOpts.User)).Put("/", api.UserUpdate)
 
-			r.Route("/identities", func(r *router) {
+			r.With(api.requireAuthentication).Route("/identities", func(r *router) {
 				r.Use(api.requireManualLinkingEnabled)
 				r.Get("/authorize", api.LinkIdentity)
 				r.Delete("/{identity_id}", api.DeleteIdentity)
 			})
+
+			r.With(api.requireAuthentication).Route("/sessions", func(r *router) {
+				r.Get("/", api.UserSessionList)
+				r.Delete("/{session_id}", api.UserSessionDelete)
+			})
+
+			r.With(api.requireAuthentication).Route("/passkeys", func(r *router) {
+				r.Use(api.requirePasskeyEnabled)
+				r.Use(api.requireNotAnonymous)
+				r.Get("/", api.UserPasskeyList)
+				r.Post("/", api.UserPasskeyCreate)
+				r.With(api.limitHandler(api.limiterOpts.Passkey)).Post("/challenge", api.UserPasskeyChallenge)
+				r.Put("/{passkey_id}", api.UserPasskeyUpdate)
+				r.Delete("/{passkey_id}", api.UserPasskeyDelete)
+			})
+
+			r.With(api.requireAuthentication).Route("/trusted_devices", func(r *router) {
+				r.Get("/", api.UserTrustedDeviceList)
+				r.Delete("/{trusted_device_id}", api.UserTrustedDeviceDelete)
+			})
+
+			r.With(api.requireAuthentication).Route("/oauth/grants", func(r *router) {
+				r.Get("/", api.oauthServer.UserOAuthGrantList)
+				r.Delete("/{client_id}", api.oauthServer.UserOAuthGrantRevoke)
+			})
 		})
 
-		r.With(api.requireAuthentication).Route("/factors", func(r *router) {
+		r.With(api.requireAuthenticationForMFAEnrollment).Route("/factors", func(r *router) {
 			r.Use(api.requireNotAnonymous)
 			r.Post("/", api.EnrollFactor)
+			r.With(api.limitHandler(api.limiterOpts.FactorVerify)).
+				Post("/trusted_devices/verify", api.VerifyTrustedDevice)
 			r.Route("/{factor_id}", func(r *router) {
 				r.Use(api.loadFactor)
 
@@ -239,6 +290,7 @@ func NewAPIWithVersion(globalConfig *conf.GlobalConfiguration, db *storage.Conne
 				r.With(api.limitHandler(api.limiterOpts.FactorChallenge)).
 					Post("/challenge", api.ChallengeFactor)
 				r.Delete("/", api.UnenrollFactor)
+				r.Post("/recovery_codes", api.RegenerateRecoveryCodes)
 
 			})
 		})
@@ -278,6 +330,18 @@ func NewAPIWithVersion(globalConfig *conf.GlobalConfiguration, db *storage.Conne
 						})
 					})
 
+					r.Route("/sessions", func(r *router) {
+						r.Get("/", api.adminUserGetSessions)
+						r.Delete("/", api.adminUserDeleteSessions)
+						r.Delete("/{session_id}", api.adminUserDeleteSession)
+					})
+
+					r.Route("/trusted_devices", func(r *router) {
+						r.Get("/", api.adminUserGetTrustedDevices)
+						r.Delete("/", api.adminUserDeleteTrustedDevices)
+						r.Delete("/{trusted_device_id}", api.adminUserDeleteTrustedDevice)
+					})
+
 					r.Get("/", api.adminUserGet)
 					r.Put("/", api.adminUserUpdate)
 					r.Delete("/", api.adminUserDelete)
@@ -312,16 +376,73 @@ func NewAPIWithVersion(globalConfig *conf.GlobalConfiguration, db *storage.Conne
 					r.Route("/{client_id}", func(r *router) {
 						r.Use(api.oauthServer.LoadOAuthServerClient)
 						r.Get("/", api.oauthServer.OAuthServerClientGet)
+						r.Put("/", api.oauthServer.OAuthServerClientUpdate)
 						r.Delete("/", api.oauthServer.OAuthServerClientDelete)
+						r.Post("/rotate_secret", api.oauthServer.OAuthServerClientRotateSecret)
+					})
+				})
+
+				// RFC 8707 resources access tokens can be restricted to
+				r.Route("/resources", func(r *router) {
+					r.Get("/", api.oauthServer.AdminOAuthServerResourceList)
+					r.Post("/", api.oauthServer.AdminOAuthServerResourceCreate)
+
+					r.Route("/{resource_id}", func(r *router) {
+						r.Use(api.oauthServer.LoadOAuthServerResource)
+						r.Get("/", api.oauthServer.AdminOAuthServerResourceGet)
+						r.Put("/", api.oauthServer.AdminOAuthServerResourceUpdate)
+						r.Delete("/", api.oauthServer.AdminOAuthServerResourceDelete)
 					})
 				})
 			})
 		})
 
-		// OAuth Dynamic Client Registration endpoint (public, rate limited)
 		r.Route("/oauth", func(r *router) {
+			// OAuth Dynamic Client Registration endpoint (public, rate limited)
 			r.With(api.limitHandler(api.limiterOpts.OAuthClientRegister)).
 				Post("/clients/register", api.oauthServer.OAuthServerClientDynamicRegister)
+
+			// RFC 7592 client configuration endpoint, authenticated with the
+			// registration access token issued at dynamic registration
+			r.Route("/clients/{client_id}", func(r *router) {
+				r.Use(api.oauthServer.LoadSelfManagedOAuthServerClient)
+				r.Get("/", api.oauthServer.OAuthServerClientSelfGet)
+				r.Put("/", api.oauthServer.OAuthServerClientSelfUpdate)
+				r.Delete("/", api.oauthServer.OAuthServerClientDelete)
+			})
+
+			// OAuth 2.1 authorization endpoint, redirects the user to the consent UI
+			r.Get("/authorize", api.oauthServer.OAuthServerAuthorize)
+
+			// Used by the consent UI on behalf of the signed in user
+			r.With(api.requireAuthentication).Route("/authorizations/{authorization_id}", func(r *router) {
+				r.Use(api.requireNotAnonymous)
+				r.Get("/", api.oauthServer.OAuthServerGetAuthorization)
+				r.Post("/consent", api.oauthServer.OAuthServerConsent)
+			})
+
+			// RFC 9126 pushed authorization requests
+			r.With(api.oauthClientAuth).Post("/par", api.oauthServer.OAuthServerPushedAuthorizationRequest)
+
+			// RFC 8628 device authorization grant
+			r.Route("/device", func(r *router) {
+				r.With(api.oauthClientAuth).Post("/code", api.oauthServer.OAuthServerDeviceAuthorize)
+
+				// Used by the verification UI on behalf of the signed in user
+				r.With(api.limitHandler(api.limiterOpts.OAuthDeviceVerification)).With(api.requireAuthentication).Route("/authorizations/{user_code}", func(r *router) {
+					r.Use(api.requireNotAnonymous)
+					r.Get("/", api.oauthServer.OAuthServerGetDeviceAuthorization)
+					r.Post("/consent", api.oauthServer.OAuthServerDeviceConsent)
+				})
+			})
+
+			// RFC 7662 token introspection and RFC 7009 token revocation
+			r.With(api.oauthClientAuth).Post("/introspect", api.OAuthIntrospect)
+			r.With(api.oauthClientAuth).Post("/revoke", api.OAuthRevoke)
+
+			// OpenID Connect UserInfo endpoint
+			r.With(api.requireOAuthServerAuthentication).Get("/userinfo", api.OAuthUserInfo)
+			r.With(api.requireOAuthServerAuthentication).Post("/userinfo", api.OAuthUserInfo)
 		})
 	})
 
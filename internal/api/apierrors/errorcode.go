@@ -89,12 +89,29 @@ const (
 	ErrorCodeMFAWebAuthnEnrollDisabled         ErrorCode = "mfa_webauthn_enroll_not_enabled"
 	ErrorCodeMFAWebAuthnVerifyDisabled         ErrorCode = "mfa_webauthn_verify_not_enabled"
 	ErrorCodeMFAVerifiedFactorExists           ErrorCode = "mfa_verified_factor_exists"
+	ErrorCodeMFARecoveryCodesEnrollDisabled    ErrorCode = "mfa_recovery_codes_enroll_not_enabled"
+	ErrorCodeMFARecoveryCodesVerifyDisabled    ErrorCode = "mfa_recovery_codes_verify_not_enabled"
+	ErrorCodeMFAEmailEnrollDisabled            ErrorCode = "mfa_email_enroll_not_enabled"
+	ErrorCodeMFAEmailVerifyDisabled            ErrorCode = "mfa_email_verify_not_enabled"
+	ErrorCodeMFATrustedDevicesDisabled         ErrorCode = "mfa_trusted_devices_not_enabled"
+	ErrorCodeTrustedDeviceNotFound             ErrorCode = "trusted_device_not_found"
+	ErrorCodeMFAEnrollmentRequired             ErrorCode = "mfa_enrollment_required"
 	//#nosec G101 -- Not a secret value.
 	ErrorCodeInvalidCredentials                     ErrorCode = "invalid_credentials"
 	ErrorCodeEmailAddressNotAuthorized              ErrorCode = "email_address_not_authorized"
 	ErrorCodeEmailAddressInvalid                    ErrorCode = "email_address_invalid"
 	ErrorCodeWeb3ProviderDisabled                   ErrorCode = "web3_provider_disabled"
 	ErrorCodeWeb3UnsupportedChain                   ErrorCode = "web3_unsupported_chain"
+	ErrorCodePasskeyProviderDisabled                ErrorCode = "passkey_provider_disabled"
+	ErrorCodePasskeyNotFound                        ErrorCode = "passkey_not_found"
+	ErrorCodeTooManyPasskeys                        ErrorCode = "too_many_passkeys"
 	ErrorCodeOAuthDynamicClientRegistrationDisabled ErrorCode = "oauth_dynamic_client_registration_disabled"
 	ErrorCodeEmailAddressNotProvided                ErrorCode = "email_address_not_provided"
+	ErrorCodeOAuthClientNotFound                    ErrorCode = "oauth_client_not_found"
+	ErrorCodeOAuthAuthorizationNotFound             ErrorCode = "oauth_authorization_not_found"
+	ErrorCodeOAuthAuthorizationExpired              ErrorCode = "oauth_authorization_expired"
+	ErrorCodeOAuthConsentNotFound                   ErrorCode = "oauth_consent_not_found"
+	ErrorCodeOAuthInsufficientScope                 ErrorCode = "oauth_insufficient_scope"
+	ErrorCodeOAuthResourceNotFound                  ErrorCode = "oauth_resource_not_found"
+	ErrorCodeSessionLimitReached                    ErrorCode = "session_limit_reached"
 )
@@ -16,6 +16,56 @@ import (
 
 // requireAuthentication checks incoming requests for tokens presented using the Authorization header
 func (a *API) requireAuthentication(w http.ResponseWriter, r *http.Request) (context.Context, error) {
+	ctx, err := a.authenticate(r)
+	if err != nil {
+		return ctx, err
+	}
+
+	return requireMFAEnrollment(ctx)
+}
+
+// requireAuthenticationForMFAEnrollment is like requireAuthentication but also
+// accepts sessions restricted by the MFA enforcement policy, so only use it on
+// routes needed to enroll a factor.
+func (a *API) requireAuthenticationForMFAEnrollment(w http.ResponseWriter, r *http.Request) (context.Context, error) {
+	return a.authenticate(r)
+}
+
+// requireOAuthServerAuthentication is like requireAuthentication but also
+// accepts access tokens issued to OAuth server clients, so only use it on
+// routes that check the scopes granted to the client.
+func (a *API) requireOAuthServerAuthentication(w http.ResponseWriter, r *http.Request) (context.Context, error) {
+	ctx, err := a.authenticateAnyClient(r)
+	if err != nil {
+		return ctx, err
+	}
+
+	return requireMFAEnrollment(ctx)
+}
+
+func requireMFAEnrollment(ctx context.Context) (context.Context, error) {
+	if claims := getClaims(ctx); claims.MFARequired {
+		return nil, apierrors.NewForbiddenError(apierrors.ErrorCodeMFAEnrollmentRequired, "MFA enrollment is required to perform this action")
+	}
+	return ctx, nil
+}
+
+// authenticate only accepts access tokens issued to the user, as tokens
+// issued to OAuth server clients are limited to the scopes they were granted.
+func (a *API) authenticate(r *http.Request) (context.Context, error) {
+	ctx, err := a.authenticateAnyClient(r)
+	if err != nil {
+		return ctx, err
+	}
+
+	session := getSession(ctx)
+	if getClaims(ctx).ClientID != "" || (session != nil && session.OAuthClientID != nil) {
+		return nil, apierrors.NewForbiddenError(apierrors.ErrorCodeOAuthInsufficientScope, "Access tokens issued to OAuth clients can't be used for this endpoint")
+	}
+	return ctx, nil
+}
+
+func (a *API) authenticateAnyClient(r *http.Request) (context.Context, error) {
 	token, err := a.extractBearerToken(r)
 	if err != nil {
 		return nil, err
@@ -74,29 +124,7 @@ func (a *API) parseJWTClaims(bearer string, r *http.Request) (context.Context, e
 	config := a.config
 
 	p := jwt.NewParser(jwt.WithValidMethods(config.JWT.ValidMethods))
-	token, err := p.ParseWithClaims(bearer, &AccessTokenClaims{}, func(token *jwt.Token) (interface{}, error) {
-		if kid, ok := token.Header["kid"]; ok {
-			if kidStr, ok := kid.(string); ok {
-				key, err := conf.FindPublicKeyByKid(kidStr, &config.JWT)
-				if err != nil {
-					return nil, err
-				}
-				if key != nil {
-					return key, nil
-				}
-
-				// otherwise try to use fallback
-			}
-		}
-		if alg, ok := token.Header["alg"]; ok {
-			if alg == jwt.SigningMethodHS256.Name {
-				// preserve backward compatibility for cases where the kid is not set
-				return []byte(config.JWT.Secret), nil
-			}
-		}
-
-		return nil, fmt.Errorf("unrecognized JWT kid %v for algorithm %v", token.Header["kid"], token.Header["alg"])
-	})
+	token, err := p.ParseWithClaims(bearer, &AccessTokenClaims{}, a.jwtKeyFunc)
 	if err != nil {
 		return nil, apierrors.NewForbiddenError(apierrors.ErrorCodeBadJWT, "invalid JWT: unable to parse or verify signature, %v", err).WithInternalError(err)
 	}
@@ -104,6 +132,34 @@ func (a *API) parseJWTClaims(bearer string, r *http.Request) (context.Context, e
 	return withToken(ctx, token), nil
 }
 
+// jwtKeyFunc returns the key used to verify the signature of access tokens
+// issued by this server.
+func (a *API) jwtKeyFunc(token *jwt.Token) (interface{}, error) {
+	config := a.config
+
+	if kid, ok := token.Header["kid"]; ok {
+		if kidStr, ok := kid.(string); ok {
+			key, err := conf.FindPublicKeyByKid(kidStr, &config.JWT)
+			if err != nil {
+				return nil, err
+			}
+			if key != nil {
+				return key, nil
+			}
+
+			// otherwise try to use fallback
+		}
+	}
+	if alg, ok := token.Header["alg"]; ok {
+		if alg == jwt.SigningMethodHS256.Name {
+			// preserve backward compatibility for cases where the kid is not set
+			return []byte(config.JWT.Secret), nil
+		}
+	}
+
+	return nil, fmt.Errorf("unrecognized JWT kid %v for algorithm %v", token.Header["kid"], token.Header["alg"])
+}
+
 func (a *API) maybeLoadUserOrSession(ctx context.Context) (context.Context, error) {
 	db := a.db.WithContext(ctx)
 	claims := getClaims(ctx)
@@ -5,6 +5,7 @@ import (
 	"net/url"
 
 	jwt "github.com/golang-jwt/jwt/v5"
+	"github.com/supabase/auth/internal/api/shared"
 	"github.com/supabase/auth/internal/models"
 )
 
@@ -21,10 +22,8 @@ const (
 	tokenKey            = contextKey("jwt")
 	inviteTokenKey      = contextKey("invite_token")
 	signatureKey        = contextKey("signature")
-	userKey             = contextKey("user")
 	targetUserKey       = contextKey("target_user")
 	factorKey           = contextKey("factor")
-	sessionKey          = contextKey("session")
 	externalReferrerKey = contextKey("external_referrer")
 	functionHooksKey    = contextKey("function_hooks")
 	adminUserKey        = contextKey("admin_user")
@@ -33,6 +32,9 @@ const (
 	ssoProviderKey      = contextKey("sso_provider")
 	externalHostKey     = contextKey("external_host")
 	flowStateKey        = contextKey("flow_state_id")
+	dpopJKTKey          = contextKey("dpop_jkt")
+	oauthResourceKey    = contextKey("oauth_resource")
+	signInNoticesKey    = contextKey("sign_in_notifications")
 )
 
 // withToken adds the JWT token to the context.
@@ -60,7 +62,7 @@ func getClaims(ctx context.Context) *AccessTokenClaims {
 
 // withUser adds the user to the context.
 func withUser(ctx context.Context, u *models.User) context.Context {
-	return context.WithValue(ctx, userKey, u)
+	return shared.WithUser(ctx, u)
 }
 
 // withTargetUser adds the target user for linking to the context.
@@ -75,14 +77,7 @@ func withFactor(ctx context.Context, f *models.Factor) context.Context {
 
 // getUser reads the user from the context.
 func getUser(ctx context.Context) *models.User {
-	if ctx == nil {
-		return nil
-	}
-	obj := ctx.Value(userKey)
-	if obj == nil {
-		return nil
-	}
-	return obj.(*models.User)
+	return shared.GetUser(ctx)
 }
 
 // getTargetUser reads the user from the context.
@@ -108,19 +103,12 @@ func getFactor(ctx context.Context) *models.Factor {
 
 // withSession adds the session to the context.
 func withSession(ctx context.Context, s *models.Session) context.Context {
-	return context.WithValue(ctx, sessionKey, s)
+	return shared.WithSession(ctx, s)
 }
 
 // getSession reads the session from the context.
 func getSession(ctx context.Context) *models.Session {
-	if ctx == nil {
-		return nil
-	}
-	obj := ctx.Value(sessionKey)
-	if obj == nil {
-		return nil
-	}
-	return obj.(*models.Session)
+	return shared.GetSession(ctx)
 }
 
 // withSignature adds the provided request ID to the context.
@@ -251,3 +239,47 @@ func getExternalHost(ctx context.Context) *url.URL {
 	}
 	return obj.(*url.URL)
 }
+
+// withDPoPJKT adds the thumbprint of the key that signed the DPoP proof to
+// the context
+func withDPoPJKT(ctx context.Context, jkt string) context.Context {
+	return context.WithValue(ctx, dpopJKTKey, jkt)
+}
+
+// getDPoPJKT returns the thumbprint of the key that signed the request's
+// DPoP proof, or an empty string if the request didn't carry one.
+func getDPoPJKT(ctx context.Context) string {
+	obj := ctx.Value(dpopJKTKey)
+	if obj == nil {
+		return ""
+	}
+	return obj.(string)
+}
+
+// withOAuthResource adds the resource requested for the access token to the
+// context
+func withOAuthResource(ctx context.Context, resource *models.OAuthServerResource) context.Context {
+	return context.WithValue(ctx, oauthResourceKey, resource)
+}
+
+func getOAuthResource(ctx context.Context) *models.OAuthServerResource {
+	obj := ctx.Value(oauthResourceKey)
+	if obj == nil {
+		return nil
+	}
+	return obj.(*models.OAuthServerResource)
+}
+
+// withPendingSignInNotifications adds the list the sign-in notifications of
+// the request are queued on to the context
+func withPendingSignInNotifications(ctx context.Context, pending *[]*pendingSignInNotification) context.Context {
+	return context.WithValue(ctx, signInNoticesKey, pending)
+}
+
+func getPendingSignInNotifications(ctx context.Context) *[]*pendingSignInNotification {
+	obj := ctx.Value(signInNoticesKey)
+	if obj == nil {
+		return nil
+	}
+	return obj.(*[]*pendingSignInNotification)
+}
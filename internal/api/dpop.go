@@ -0,0 +1,165 @@
+package api
+
+import (
+	"context"
+	"crypto"
+	"encoding/base64"
+	"encoding/json"
+	"net/http"
+	"net/url"
+	"strings"
+	"time"
+
+	"github.com/golang-jwt/jwt/v5"
+	"github.com/lestrrat-go/jwx/v2/jwk"
+	"github.com/supabase/auth/internal/api/apierrors"
+	"github.com/supabase/auth/internal/api/oauthserver"
+	"github.com/supabase/auth/internal/models"
+)
+
+const (
+	dpopHeader    = "DPoP"
+	dpopProofType = "dpop+jwt"
+)
+
+// dpopSigningAlgorithms are the asymmetric algorithms accepted for DPoP proofs
+var dpopSigningAlgorithms = oauthserver.ClientAssertionSigningMethods
+
+// dpopProofClaims are the claims of an RFC 9449 DPoP proof
+type dpopProofClaims struct {
+	jwt.RegisteredClaims
+	HTM string `json:"htm"`
+	HTU string `json:"htu"`
+}
+
+func invalidDPoPProof(reason string) *apierrors.OAuthError {
+	return apierrors.NewOAuthError("invalid_dpop_proof", "Invalid DPoP proof: "+reason)
+}
+
+// dpopRequestURI returns the htu a DPoP proof for the request must carry
+func (a *API) dpopRequestURI(r *http.Request) string {
+	return strings.TrimSuffix(a.config.API.ExternalURL, "/") + r.URL.Path
+}
+
+// verifyDPoPProof validates the DPoP proof sent with the request and returns
+// the thumbprint of the key it was signed with. Proofs must be signed with an
+// asymmetric key embedded in their header, be issued for this request, and
+// can only be used once.
+func (a *API) verifyDPoPProof(r *http.Request) (string, error) {
+	config := a.config
+
+	proofs := r.Header.Values(dpopHeader)
+	if len(proofs) != 1 {
+		return "", invalidDPoPProof("exactly one DPoP header is required")
+	}
+
+	var key jwk.Key
+	claims := &dpopProofClaims{}
+	p := jwt.NewParser(jwt.WithValidMethods(dpopSigningAlgorithms))
+	if _, err := p.ParseWithClaims(proofs[0], claims, func(token *jwt.Token) (interface{}, error) {
+		if typ, _ := token.Header["typ"].(string); typ != dpopProofType {
+			return nil, jwt.ErrTokenMalformed
+		}
+
+		rawKey, ok := token.Header["jwk"]
+		if !ok {
+			return nil, jwt.ErrTokenUnverifiable
+		}
+
+		keyJSON, err := json.Marshal(rawKey)
+		if err != nil {
+			return nil, err
+		}
+
+		key, err = jwk.ParseKey(keyJSON)
+		if err != nil {
+			return nil, err
+		}
+
+		if isPrivate, err := jwk.IsPrivateKey(key); err != nil || isPrivate {
+			return nil, jwt.ErrTokenUnverifiable
+		}
+
+		var raw interface{}
+		if err := key.Raw(&raw); err != nil {
+			return nil, err
+		}
+		return raw, nil
+	}); err != nil {
+		return "", invalidDPoPProof(err.Error())
+	}
+
+	if claims.ID == "" {
+		return "", invalidDPoPProof("jti is required")
+	}
+
+	if claims.IssuedAt == nil {
+		return "", invalidDPoPProof("iat is required")
+	}
+
+	maxAge := config.Security.DPoPProofMaxAge
+	if skew := time.Since(claims.IssuedAt.Time); skew > maxAge || skew < -maxAge {
+		return "", invalidDPoPProof("iat is too far from the current time")
+	}
+
+	if claims.HTM != r.Method {
+		return "", invalidDPoPProof("htm does not match the request method")
+	}
+
+	htu, err := url.Parse(claims.HTU)
+	if err != nil {
+		return "", invalidDPoPProof("htu is not a valid URL")
+	}
+	htu.RawQuery = ""
+	htu.Fragment = ""
+	if htu.String() != a.dpopRequestURI(r) {
+		return "", invalidDPoPProof("htu does not match the request URL")
+	}
+
+	thumbprint, err := key.Thumbprint(crypto.SHA256)
+	if err != nil {
+		return "", invalidDPoPProof("unable to compute the JWK thumbprint")
+	}
+	jkt := base64.RawURLEncoding.EncodeToString(thumbprint)
+
+	recorded, err := models.RecordDPoPProof(a.db.WithContext(r.Context()), jkt, claims.ID, claims.IssuedAt.Add(maxAge))
+	if err != nil {
+		return "", apierrors.NewInternalServerError("Error validating DPoP proof").WithInternalError(err)
+	}
+
+	if !recorded {
+		return "", invalidDPoPProof("jti has already been used")
+	}
+
+	return jkt, nil
+}
+
+// requireDPoPBinding checks that a request using a refresh token of a session
+// bound to a DPoP key carried a proof signed by that key. Sessions that
+// aren't bound accept requests with or without a proof.
+func requireDPoPBinding(ctx context.Context, session *models.Session) error {
+	if session.DPoPJKT == nil || *session.DPoPJKT == "" {
+		return nil
+	}
+
+	jkt := getDPoPJKT(ctx)
+	if jkt == "" {
+		return invalidDPoPProof("the refresh token is bound to a DPoP key")
+	}
+
+	if jkt != *session.DPoPJKT {
+		return invalidDPoPProof("the proof was not signed by the key the refresh token is bound to")
+	}
+
+	return nil
+}
+
+// accessTokenType returns the token_type of the access tokens issued for a
+// session, which is DPoP when they are bound to a DPoP key (RFC 9449 section
+// 5).
+func accessTokenType(jkt *string) string {
+	if jkt != nil && *jkt != "" {
+		return "DPoP"
+	}
+	return "bearer"
+}
@@ -0,0 +1,159 @@
+package api
+
+import (
+	"bytes"
+	"crypto/ecdsa"
+	"crypto/elliptic"
+	"crypto/rand"
+	"encoding/json"
+	"net/http"
+	"net/http/httptest"
+	"strings"
+	"time"
+
+	"github.com/gofrs/uuid"
+	"github.com/golang-jwt/jwt/v5"
+	"github.com/lestrrat-go/jwx/v2/jwk"
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+)
+
+func (ts *TokenTestSuite) newDPoPKey() *ecdsa.PrivateKey {
+	key, err := ecdsa.GenerateKey(elliptic.P256(), rand.Reader)
+	require.NoError(ts.T(), err)
+	return key
+}
+
+func (ts *TokenTestSuite) signDPoPProof(key *ecdsa.PrivateKey, claims jwt.MapClaims) string {
+	publicKey, err := jwk.FromRaw(key.Public())
+	require.NoError(ts.T(), err)
+
+	token := jwt.NewWithClaims(jwt.SigningMethodES256, claims)
+	token.Header["typ"] = dpopProofType
+	token.Header["jwk"] = publicKey
+
+	signed, err := token.SignedString(key)
+	require.NoError(ts.T(), err)
+	return signed
+}
+
+func (ts *TokenTestSuite) dpopProof(key *ecdsa.PrivateKey) string {
+	return ts.signDPoPProof(key, jwt.MapClaims{
+		"jti": uuid.Must(uuid.NewV4()).String(),
+		"htm": http.MethodPost,
+		"htu": strings.TrimSuffix(ts.Config.API.ExternalURL, "/") + "/token",
+		"iat": time.Now().Unix(),
+	})
+}
+
+func (ts *TokenTestSuite) dpopTokenRequest(grantType string, body map[string]interface{}, proof string) *httptest.ResponseRecorder {
+	var buffer bytes.Buffer
+	require.NoError(ts.T(), json.NewEncoder(&buffer).Encode(body))
+
+	req := httptest.NewRequest(http.MethodPost, "http://localhost/token?grant_type="+grantType, &buffer)
+	req.Header.Set("Content-Type", "application/json")
+	if proof != "" {
+		req.Header.Set(dpopHeader, proof)
+	}
+
+	w := httptest.NewRecorder()
+	ts.API.handler.ServeHTTP(w, req)
+	return w
+}
+
+func (ts *TokenTestSuite) TestDPoPBoundRefreshToken() {
+	key := ts.newDPoPKey()
+
+	w := ts.dpopTokenRequest("password", map[string]interface{}{
+		"email":    "test@example.com",
+		"password": "password",
+	}, ts.dpopProof(key))
+	require.Equal(ts.T(), http.StatusOK, w.Code, w.Body.String())
+
+	var response AccessTokenResponse
+	require.NoError(ts.T(), json.NewDecoder(w.Body).Decode(&response))
+	assert.Equal(ts.T(), "DPoP", response.TokenType)
+
+	claims := &AccessTokenClaims{}
+	_, err := jwt.NewParser().ParseWithClaims(response.Token, claims, ts.API.jwtKeyFunc)
+	require.NoError(ts.T(), err)
+	require.NotNil(ts.T(), claims.Confirmation)
+	assert.NotEmpty(ts.T(), claims.Confirmation.JKT)
+
+	refresh := map[string]interface{}{
+		"refresh_token": response.RefreshToken,
+	}
+
+	// bound refresh tokens require a proof
+	w = ts.dpopTokenRequest("refresh_token", refresh, "")
+	assert.Equal(ts.T(), http.StatusBadRequest, w.Code)
+	assert.Contains(ts.T(), w.Body.String(), "invalid_dpop_proof")
+
+	// the proof must be signed by the bound key
+	w = ts.dpopTokenRequest("refresh_token", refresh, ts.dpopProof(ts.newDPoPKey()))
+	assert.Equal(ts.T(), http.StatusBadRequest, w.Code)
+
+	proof := ts.dpopProof(key)
+	w = ts.dpopTokenRequest("refresh_token", refresh, proof)
+	require.Equal(ts.T(), http.StatusOK, w.Code, w.Body.String())
+
+	var refreshed AccessTokenResponse
+	require.NoError(ts.T(), json.NewDecoder(w.Body).Decode(&refreshed))
+	assert.Equal(ts.T(), "DPoP", refreshed.TokenType)
+
+	refreshedClaims := &AccessTokenClaims{}
+	_, err = jwt.NewParser().ParseWithClaims(refreshed.Token, refreshedClaims, ts.API.jwtKeyFunc)
+	require.NoError(ts.T(), err)
+	require.NotNil(ts.T(), refreshedClaims.Confirmation)
+	assert.Equal(ts.T(), claims.Confirmation.JKT, refreshedClaims.Confirmation.JKT)
+
+	// proofs can't be replayed
+	w = ts.dpopTokenRequest("refresh_token", map[string]interface{}{
+		"refresh_token": refreshed.RefreshToken,
+	}, proof)
+	assert.Equal(ts.T(), http.StatusBadRequest, w.Code)
+	assert.Contains(ts.T(), w.Body.String(), "jti has already been used")
+}
+
+func (ts *TokenTestSuite) TestDPoPProofValidation() {
+	key := ts.newDPoPKey()
+	htu := strings.TrimSuffix(ts.Config.API.ExternalURL, "/") + "/token"
+
+	cases := []struct {
+		desc   string
+		claims jwt.MapClaims
+	}{
+		{
+			desc:   "missing jti",
+			claims: jwt.MapClaims{"htm": http.MethodPost, "htu": htu, "iat": time.Now().Unix()},
+		},
+		{
+			desc:   "stale iat",
+			claims: jwt.MapClaims{"jti": "stale", "htm": http.MethodPost, "htu": htu, "iat": time.Now().Add(-time.Hour).Unix()},
+		},
+		{
+			desc:   "wrong method",
+			claims: jwt.MapClaims{"jti": "method", "htm": http.MethodGet, "htu": htu, "iat": time.Now().Unix()},
+		},
+		{
+			desc:   "wrong url",
+			claims: jwt.MapClaims{"jti": "url", "htm": http.MethodPost, "htu": "https://example.com/token", "iat": time.Now().Unix()},
+		},
+	}
+
+	for _, c := range cases {
+		ts.Run(c.desc, func() {
+			w := ts.dpopTokenRequest("refresh_token", map[string]interface{}{
+				"refresh_token": ts.RefreshToken.Token,
+			}, ts.signDPoPProof(key, c.claims))
+			assert.Equal(ts.T(), http.StatusBadRequest, w.Code)
+			assert.Contains(ts.T(), w.Body.String(), "invalid_dpop_proof")
+		})
+	}
+
+	// sessions that aren't bound keep working without a proof
+	w := ts.dpopTokenRequest("refresh_token", map[string]interface{}{
+		"refresh_token": ts.RefreshToken.Token,
+	}, "")
+	assert.Equal(ts.T(), http.StatusOK, w.Code)
+}
@@ -244,6 +244,7 @@ func (a *API) internalExternalProviderCallback(w http.ResponseWriter, r *http.Re
 
 			terr = tx.Update(flowState)
 		} else {
+			grantParams.Provider = providerType
 			token, terr = a.issueRefreshToken(r, tx, user, models.OAuth, grantParams)
 		}
 
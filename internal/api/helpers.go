@@ -63,6 +63,10 @@ type RequestParams interface {
 		InviteParams |
 		OtpParams |
 		PKCEGrantParams |
+		PasskeyChallengeParams |
+		PasskeyGrantParams |
+		PasskeyRegistrationParams |
+		PasskeyUpdateParams |
 		PasswordGrantParams |
 		RecoverParams |
 		RefreshTokenGrantParams |
@@ -70,6 +74,7 @@ type RequestParams interface {
 		SignupParams |
 		SingleSignOnParams |
 		SmsParams |
+		TrustedDeviceVerifyParams |
 		Web3GrantParams |
 		UserUpdateParams |
 		VerifyFactorParams |
@@ -446,6 +446,54 @@ func (a *API) sendReauthenticationOtp(r *http.Request, tx *storage.Connection, u
 	return nil
 }
 
+// sendMFAEmailOtp sends the code of an email MFA factor challenge to the
+// factor's address through the send email hook or the mailer. Like all other
+// emails it counts against the email rate limit.
+func (a *API) sendMFAEmailOtp(r *http.Request, tx *storage.Connection, u *models.User, email, otp string) error {
+	ctx := r.Context()
+	config := a.config
+
+	if !a.checkEmailAddressAuthorization(email) {
+		return apierrors.NewBadRequestError(apierrors.ErrorCodeEmailAddressNotAuthorized, "Email address %q cannot be used as it is not authorized", email)
+	}
+
+	if config.RateLimitEmailSent.Events == 0 || !a.limiterOpts.Email.Allow() {
+		emailRateLimitCounter.Add(
+			ctx,
+			1,
+			metric.WithAttributeSet(attribute.NewSet(attribute.String("path", r.URL.Path))),
+		)
+		return apierrors.NewTooManyRequestsError(apierrors.ErrorCodeOverEmailSendRateLimit, EmailRateLimitExceeded.Error())
+	}
+
+	if config.Hook.SendEmail.Enabled {
+		input := v0hooks.SendEmailInput{
+			User: u,
+			EmailData: mail.EmailData{
+				Token:           otp,
+				EmailActionType: mail.MFAEmailVerification,
+				SiteURL:         getExternalHost(ctx).String(),
+				FactorEmail:     email,
+			},
+		}
+		output := v0hooks.SendEmailOutput{}
+		return a.hooksMgr.InvokeHook(tx, r, &input, &output)
+	}
+
+	err := a.Mailer().MFAEmailMail(r, u, email, otp)
+	switch {
+	case errors.Is(err, mail.ErrInvalidEmailAddress),
+		errors.Is(err, mail.ErrInvalidEmailFormat),
+		errors.Is(err, mail.ErrInvalidEmailDNS):
+		return apierrors.NewBadRequestError(
+			apierrors.ErrorCodeEmailAddressInvalid,
+			"Email address %q is invalid",
+			email)
+	default:
+		return err
+	}
+}
+
 func (a *API) sendMagicLink(r *http.Request, tx *storage.Connection, u *models.User, flowType models.FlowType) error {
 	var err error
 	config := a.config
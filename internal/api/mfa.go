@@ -36,6 +36,7 @@ type EnrollFactorParams struct {
 	FactorType   string `json:"factor_type"`
 	Issuer       string `json:"issuer"`
 	Phone        string `json:"phone"`
+	Email        string `json:"email"`
 }
 
 type TOTPObject struct {
@@ -50,6 +51,9 @@ type EnrollFactorResponse struct {
 	FriendlyName string      `json:"friendly_name"`
 	TOTP         *TOTPObject `json:"totp,omitempty"`
 	Phone        string      `json:"phone,omitempty"`
+	Email        string      `json:"email,omitempty"`
+	// RecoveryCodes are only returned once, when they are generated
+	RecoveryCodes []string `json:"recovery_codes,omitempty"`
 }
 
 type ChallengeFactorParams struct {
@@ -61,6 +65,8 @@ type VerifyFactorParams struct {
 	ChallengeID uuid.UUID       `json:"challenge_id"`
 	Code        string          `json:"code"`
 	WebAuthn    *WebAuthnParams `json:"web_authn,omitempty"`
+	// TrustDevice asks for a token that skips MFA on this device later
+	TrustDevice bool `json:"trust_device"`
 }
 
 type ChallengeFactorResponse struct {
@@ -228,6 +234,74 @@ func (a *API) enrollPhoneFactor(w http.ResponseWriter, r *http.Request, params *
 	})
 }
 
+func (a *API) enrollEmailFactor(w http.ResponseWriter, r *http.Request, params *EnrollFactorParams) error {
+	ctx := r.Context()
+	config := a.config
+	user := getUser(ctx)
+	session := getSession(ctx)
+	db := a.db.WithContext(ctx)
+
+	// the factor's address defaults to the user's own, but can be any
+	// address the user can receive codes at
+	email := params.Email
+	if email == "" {
+		email = user.GetEmail()
+	}
+	if email == "" {
+		return apierrors.NewBadRequestError(apierrors.ErrorCodeValidationFailed, "Email address required to enroll Email factor")
+	}
+
+	email, err := a.validateEmail(email)
+	if err != nil {
+		return err
+	}
+
+	var factorsToDelete []models.Factor
+	for _, factor := range user.Factors {
+		if factor.IsEmailFactor() && factor.Email.String() == email {
+			if factor.IsVerified() {
+				return apierrors.NewUnprocessableEntityError(
+					apierrors.ErrorCodeMFAVerifiedFactorExists,
+					"A verified email factor already exists, unenroll the existing factor to continue",
+				)
+			} else if factor.IsUnverified() {
+				factorsToDelete = append(factorsToDelete, factor)
+			}
+		}
+	}
+
+	if err := db.Destroy(&factorsToDelete); err != nil {
+		return apierrors.NewInternalServerError("Database error deleting unverified email factors").WithInternalError(err)
+	}
+
+	if err := validateFactors(db, user, params.FriendlyName, a.config, session); err != nil {
+		return err
+	}
+
+	factor := models.NewEmailFactor(user, email, params.FriendlyName)
+	err = db.Transaction(func(tx *storage.Connection) error {
+		if terr := tx.Create(factor); terr != nil {
+			return terr
+		}
+		if terr := models.NewAuditLogEntry(config.AuditLog, r, tx, user, models.EnrollFactorAction, r.RemoteAddr, map[string]interface{}{
+			"factor_id":   factor.ID,
+			"factor_type": factor.FactorType,
+		}); terr != nil {
+			return terr
+		}
+		return nil
+	})
+	if err != nil {
+		return err
+	}
+	return sendJSON(w, http.StatusOK, &EnrollFactorResponse{
+		ID:           factor.ID,
+		Type:         models.Email,
+		FriendlyName: factor.FriendlyName,
+		Email:        email,
+	})
+}
+
 func (a *API) enrollWebAuthnFactor(w http.ResponseWriter, r *http.Request, params *EnrollFactorParams) error {
 	ctx := r.Context()
 	user := getUser(ctx)
@@ -336,6 +410,121 @@ func (a *API) enrollTOTPFactor(w http.ResponseWriter, r *http.Request, params *E
 	})
 }
 
+func (a *API) enrollRecoveryCodesFactor(w http.ResponseWriter, r *http.Request, params *EnrollFactorParams) error {
+	ctx := r.Context()
+	user := getUser(ctx)
+	config := a.config
+	session := getSession(ctx)
+	db := a.db.WithContext(ctx)
+
+	if err := validateFactors(db, user, params.FriendlyName, config, session); err != nil {
+		return err
+	}
+
+	// recovery codes can only stand in for another factor, and since one
+	// is verified validateFactors already required AAL2
+	hasOtherFactor := false
+	for _, factor := range user.Factors {
+		if factor.FactorType == models.RecoveryCode {
+			return apierrors.NewUnprocessableEntityError(
+				apierrors.ErrorCodeMFAVerifiedFactorExists,
+				"Recovery codes already exist, regenerate them or unenroll the existing factor to continue",
+			)
+		}
+		if factor.IsVerified() {
+			hasOtherFactor = true
+		}
+	}
+	if !hasOtherFactor {
+		return apierrors.NewUnprocessableEntityError(apierrors.ErrorCodeInsufficientAAL, "A verified factor is required to enroll recovery codes")
+	}
+
+	factor := models.NewRecoveryCodeFactor(user, params.FriendlyName)
+	var codes []string
+	err := db.Transaction(func(tx *storage.Connection) error {
+		var terr error
+		if terr = tx.Create(factor); terr != nil {
+			return terr
+		}
+		if codes, terr = factor.GenerateRecoveryCodes(tx, config.MFA.RecoveryCodes.Count); terr != nil {
+			return terr
+		}
+		if terr = models.NewAuditLogEntry(config.AuditLog, r, tx, user, models.EnrollFactorAction, r.RemoteAddr, map[string]interface{}{
+			"factor_id":   factor.ID,
+			"factor_type": factor.FactorType,
+		}); terr != nil {
+			return terr
+		}
+		if terr = models.NewAuditLogEntry(config.AuditLog, r, tx, user, models.GenerateRecoveryCodesAction, r.RemoteAddr, map[string]interface{}{
+			"factor_id": factor.ID,
+		}); terr != nil {
+			return terr
+		}
+		return nil
+	})
+	if err != nil {
+		return err
+	}
+	return sendJSON(w, http.StatusOK, &EnrollFactorResponse{
+		ID:            factor.ID,
+		Type:          models.RecoveryCode,
+		FriendlyName:  factor.FriendlyName,
+		RecoveryCodes: codes,
+	})
+}
+
+// RegenerateRecoveryCodes replaces the codes of a recovery_code factor with a
+// new set, invalidating all of the previous ones.
+func (a *API) RegenerateRecoveryCodes(w http.ResponseWriter, r *http.Request) error {
+	ctx := r.Context()
+	config := a.config
+	user := getUser(ctx)
+	factor := getFactor(ctx)
+	session := getSession(ctx)
+	db := a.db.WithContext(ctx)
+
+	if factor == nil || session == nil || user == nil {
+		return apierrors.NewInternalServerError("A valid session and factor are required to regenerate recovery codes")
+	}
+
+	if factor.FactorType != models.RecoveryCode {
+		return apierrors.NewBadRequestError(apierrors.ErrorCodeValidationFailed, "Only recovery_code factors have recovery codes")
+	}
+
+	if !config.MFA.RecoveryCodes.EnrollEnabled {
+		return apierrors.NewUnprocessableEntityError(apierrors.ErrorCodeMFARecoveryCodesEnrollDisabled, "MFA enroll is disabled for recovery codes")
+	}
+
+	if !session.IsAAL2() {
+		return apierrors.NewForbiddenError(apierrors.ErrorCodeInsufficientAAL, "AAL2 required to regenerate recovery codes")
+	}
+
+	var codes []string
+	err := db.Transaction(func(tx *storage.Connection) error {
+		var terr error
+		if codes, terr = factor.GenerateRecoveryCodes(tx, config.MFA.RecoveryCodes.Count); terr != nil {
+			return terr
+		}
+		if terr = models.NewAuditLogEntry(config.AuditLog, r, tx, user, models.GenerateRecoveryCodesAction, r.RemoteAddr, map[string]interface{}{
+			"factor_id":  factor.ID,
+			"session_id": session.ID,
+		}); terr != nil {
+			return terr
+		}
+		return nil
+	})
+	if err != nil {
+		return err
+	}
+
+	return sendJSON(w, http.StatusOK, &EnrollFactorResponse{
+		ID:            factor.ID,
+		Type:          models.RecoveryCode,
+		FriendlyName:  factor.FriendlyName,
+		RecoveryCodes: codes,
+	})
+}
+
 func (a *API) EnrollFactor(w http.ResponseWriter, r *http.Request) error {
 	ctx := r.Context()
 	user := getUser(ctx)
@@ -356,6 +545,11 @@ func (a *API) EnrollFactor(w http.ResponseWriter, r *http.Request) error {
 			return apierrors.NewUnprocessableEntityError(apierrors.ErrorCodeMFAPhoneEnrollDisabled, "MFA enroll is disabled for Phone")
 		}
 		return a.enrollPhoneFactor(w, r, params)
+	case models.Email:
+		if !config.MFA.Email.EnrollEnabled {
+			return apierrors.NewUnprocessableEntityError(apierrors.ErrorCodeMFAEmailEnrollDisabled, "MFA enroll is disabled for Email")
+		}
+		return a.enrollEmailFactor(w, r, params)
 	case models.TOTP:
 		if !config.MFA.TOTP.EnrollEnabled {
 			return apierrors.NewUnprocessableEntityError(apierrors.ErrorCodeMFATOTPEnrollDisabled, "MFA enroll is disabled for TOTP")
@@ -366,8 +560,13 @@ func (a *API) EnrollFactor(w http.ResponseWriter, r *http.Request) error {
 			return apierrors.NewUnprocessableEntityError(apierrors.ErrorCodeMFAWebAuthnEnrollDisabled, "MFA enroll is disabled for WebAuthn")
 		}
 		return a.enrollWebAuthnFactor(w, r, params)
+	case models.RecoveryCode:
+		if !config.MFA.RecoveryCodes.EnrollEnabled {
+			return apierrors.NewUnprocessableEntityError(apierrors.ErrorCodeMFARecoveryCodesEnrollDisabled, "MFA enroll is disabled for recovery codes")
+		}
+		return a.enrollRecoveryCodesFactor(w, r, params)
 	default:
-		return apierrors.NewBadRequestError(apierrors.ErrorCodeValidationFailed, "factor_type needs to be totp, phone, or webauthn")
+		return apierrors.NewBadRequestError(apierrors.ErrorCodeValidationFailed, "factor_type needs to be totp, phone, email, webauthn, or recovery_code")
 	}
 
 }
@@ -454,6 +653,55 @@ func (a *API) challengePhoneFactor(w http.ResponseWriter, r *http.Request) error
 	})
 }
 
+func (a *API) challengeEmailFactor(w http.ResponseWriter, r *http.Request) error {
+	ctx := r.Context()
+	config := a.config
+	db := a.db.WithContext(ctx)
+	user := getUser(ctx)
+	factor := getFactor(ctx)
+	ipAddress := utilities.GetIPAddress(r)
+
+	if factor.LastChallengedAt != nil {
+		if !factor.LastChallengedAt.Add(config.MFA.Email.MaxFrequency).Before(time.Now()) {
+			return apierrors.NewTooManyRequestsError(apierrors.ErrorCodeOverEmailSendRateLimit, generateFrequencyLimitErrorMessage(factor.LastChallengedAt, config.MFA.Email.MaxFrequency))
+		}
+	}
+
+	otp := crypto.GenerateOtp(config.MFA.Email.OtpLength)
+
+	challenge, err := factor.CreateEmailChallenge(ipAddress, otp, config.Security.DBEncryption.Encrypt, config.Security.DBEncryption.EncryptionKeyID, config.Security.DBEncryption.EncryptionKey)
+	if err != nil {
+		return apierrors.NewInternalServerError("error creating Email Challenge")
+	}
+
+	if err := db.Transaction(func(tx *storage.Connection) error {
+		if terr := a.sendMFAEmailOtp(r, tx, user, factor.Email.String(), otp); terr != nil {
+			if _, ok := terr.(*HTTPError); ok {
+				return terr
+			}
+			return apierrors.NewInternalServerError("error sending email").WithInternalError(terr)
+		}
+		if terr := factor.WriteChallengeToDatabase(tx, challenge); terr != nil {
+			return terr
+		}
+
+		if terr := models.NewAuditLogEntry(config.AuditLog, r, tx, user, models.CreateChallengeAction, r.RemoteAddr, map[string]interface{}{
+			"factor_id":     factor.ID,
+			"factor_status": factor.Status,
+		}); terr != nil {
+			return terr
+		}
+		return nil
+	}); err != nil {
+		return err
+	}
+	return sendJSON(w, http.StatusOK, &ChallengeFactorResponse{
+		ID:        challenge.ID,
+		Type:      factor.FactorType,
+		ExpiresAt: challenge.GetExpiryTime(config.MFA.ChallengeExpiryDuration).Unix(),
+	})
+}
+
 func (a *API) challengeTOTPFactor(w http.ResponseWriter, r *http.Request) error {
 	ctx := r.Context()
 	config := a.config
@@ -589,6 +837,11 @@ func (a *API) ChallengeFactor(w http.ResponseWriter, r *http.Request) error {
 			return apierrors.NewUnprocessableEntityError(apierrors.ErrorCodeMFAPhoneVerifyDisabled, "MFA verification is disabled for Phone")
 		}
 		return a.challengePhoneFactor(w, r)
+	case models.Email:
+		if !config.MFA.Email.VerifyEnabled {
+			return apierrors.NewUnprocessableEntityError(apierrors.ErrorCodeMFAEmailVerifyDisabled, "MFA verification is disabled for Email")
+		}
+		return a.challengeEmailFactor(w, r)
 
 	case models.TOTP:
 		if !config.MFA.TOTP.VerifyEnabled {
@@ -600,8 +853,14 @@ func (a *API) ChallengeFactor(w http.ResponseWriter, r *http.Request) error {
 			return apierrors.NewUnprocessableEntityError(apierrors.ErrorCodeMFAWebAuthnVerifyDisabled, "MFA verification is disabled for WebAuthn")
 		}
 		return a.challengeWebAuthnFactor(w, r)
+	case models.RecoveryCode:
+		if !config.MFA.RecoveryCodes.VerifyEnabled {
+			return apierrors.NewUnprocessableEntityError(apierrors.ErrorCodeMFARecoveryCodesVerifyDisabled, "MFA verification is disabled for recovery codes")
+		}
+		// like TOTP, the challenge only binds the verification to this request
+		return a.challengeTOTPFactor(w, r)
 	default:
-		return apierrors.NewBadRequestError(apierrors.ErrorCodeValidationFailed, "factor_type needs to be totp, phone, or webauthn")
+		return apierrors.NewBadRequestError(apierrors.ErrorCodeValidationFailed, "factor_type needs to be totp, phone, email, webauthn, or recovery_code")
 	}
 
 }
@@ -711,6 +970,11 @@ func (a *API) verifyTOTPFactor(w http.ResponseWriter, r *http.Request, params *V
 		if terr != nil {
 			return terr
 		}
+		if params.TrustDevice {
+			if token.TrustedDeviceToken, terr = a.trustDevice(r, tx, user, factor); terr != nil {
+				return terr
+			}
+		}
 		if terr = models.InvalidateSessionsWithAALLessThan(tx, user.ID, models.AAL2.String()); terr != nil {
 			return apierrors.NewInternalServerError("Failed to update sessions. %s", terr)
 		}
@@ -842,6 +1106,11 @@ func (a *API) verifyPhoneFactor(w http.ResponseWriter, r *http.Request, params *
 		if terr != nil {
 			return terr
 		}
+		if params.TrustDevice {
+			if token.TrustedDeviceToken, terr = a.trustDevice(r, tx, user, factor); terr != nil {
+				return terr
+			}
+		}
 		if terr = models.InvalidateSessionsWithAALLessThan(tx, user.ID, models.AAL2.String()); terr != nil {
 			return apierrors.NewInternalServerError("Failed to update sessions. %s", terr)
 		}
@@ -861,6 +1130,117 @@ func (a *API) verifyPhoneFactor(w http.ResponseWriter, r *http.Request, params *
 	return sendJSON(w, http.StatusOK, token)
 }
 
+func (a *API) verifyEmailFactor(w http.ResponseWriter, r *http.Request, params *VerifyFactorParams) error {
+	ctx := r.Context()
+	config := a.config
+	user := getUser(ctx)
+	factor := getFactor(ctx)
+	db := a.db.WithContext(ctx)
+
+	challenge, err := a.validateChallenge(r, db, factor, params.ChallengeID)
+	if err != nil {
+		return err
+	}
+
+	otpCode, shouldReEncrypt, err := challenge.GetOtpCode(config.Security.DBEncryption.DecryptionKeys, config.Security.DBEncryption.Encrypt, config.Security.DBEncryption.EncryptionKeyID)
+	if err != nil {
+		return apierrors.NewInternalServerError("Database error verifying MFA Email code").WithInternalError(err)
+	}
+	valid := subtle.ConstantTimeCompare([]byte(otpCode), []byte(params.Code)) == 1
+
+	if config.Hook.MFAVerificationAttempt.Enabled {
+		input := v0hooks.MFAVerificationAttemptInput{
+			UserID:     user.ID,
+			FactorID:   factor.ID,
+			FactorType: factor.FactorType,
+			Valid:      valid,
+		}
+
+		output := v0hooks.MFAVerificationAttemptOutput{}
+		err := a.hooksMgr.InvokeHook(nil, r, &input, &output)
+		if err != nil {
+			return err
+		}
+
+		if output.Decision == v0hooks.HookRejection {
+			if err := models.Logout(db, user.ID); err != nil {
+				return err
+			}
+
+			if output.Message == "" {
+				output.Message = v0hooks.DefaultMFAHookRejectionMessage
+			}
+
+			return apierrors.NewForbiddenError(apierrors.ErrorCodeMFAVerificationRejected, output.Message)
+		}
+	}
+	if !valid {
+		if shouldReEncrypt && config.Security.DBEncryption.Encrypt {
+			if err := challenge.SetOtpCode(otpCode, true, config.Security.DBEncryption.EncryptionKeyID, config.Security.DBEncryption.EncryptionKey); err != nil {
+				return err
+			}
+
+			if err := db.UpdateOnly(challenge, "otp_code"); err != nil {
+				return err
+			}
+		}
+		return apierrors.NewUnprocessableEntityError(apierrors.ErrorCodeMFAVerificationFailed, "Invalid MFA Email code entered")
+	}
+
+	var token *AccessTokenResponse
+
+	err = db.Transaction(func(tx *storage.Connection) error {
+		var terr error
+		if terr = models.NewAuditLogEntry(config.AuditLog, r, tx, user, models.VerifyFactorAction, r.RemoteAddr, map[string]interface{}{
+			"factor_id":    factor.ID,
+			"challenge_id": challenge.ID,
+			"factor_type":  factor.FactorType,
+		}); terr != nil {
+			return terr
+		}
+		if terr = challenge.Verify(tx); terr != nil {
+			return terr
+		}
+		if !factor.IsVerified() {
+			if terr = factor.UpdateStatus(tx, models.FactorStateVerified); terr != nil {
+				return terr
+			}
+		}
+		user, terr = models.FindUserByID(tx, user.ID)
+		if terr != nil {
+			return terr
+		}
+
+		token, terr = a.updateMFASessionAndClaims(r, tx, user, models.MFAEmail, models.GrantParams{
+			FactorID: &factor.ID,
+		})
+		if terr != nil {
+			return terr
+		}
+		if params.TrustDevice {
+			if token.TrustedDeviceToken, terr = a.trustDevice(r, tx, user, factor); terr != nil {
+				return terr
+			}
+		}
+		if terr = models.InvalidateSessionsWithAALLessThan(tx, user.ID, models.AAL2.String()); terr != nil {
+			return apierrors.NewInternalServerError("Failed to update sessions. %s", terr)
+		}
+		if terr = models.DeleteUnverifiedFactors(tx, user, factor.FactorType); terr != nil {
+			return apierrors.NewInternalServerError("Error removing unverified factors. %s", terr)
+		}
+		return nil
+	})
+	if err != nil {
+		return err
+	}
+
+	metering.RecordLogin(metering.LoginTypeMFA, user.ID, &metering.LoginData{
+		Provider: metering.ProviderMFAEmail,
+	})
+
+	return sendJSON(w, http.StatusOK, token)
+}
+
 func (a *API) verifyWebAuthnFactor(w http.ResponseWriter, r *http.Request, params *VerifyFactorParams) error {
 	ctx := r.Context()
 	config := a.config
@@ -945,6 +1325,11 @@ func (a *API) verifyWebAuthnFactor(w http.ResponseWriter, r *http.Request, param
 		if terr != nil {
 			return terr
 		}
+		if params.TrustDevice {
+			if token.TrustedDeviceToken, terr = a.trustDevice(r, tx, user, factor); terr != nil {
+				return terr
+			}
+		}
 		if terr = models.InvalidateSessionsWithAALLessThan(tx, user.ID, models.AAL2.String()); terr != nil {
 			return apierrors.NewInternalServerError("Failed to update session").WithInternalError(terr)
 		}
@@ -964,6 +1349,106 @@ func (a *API) verifyWebAuthnFactor(w http.ResponseWriter, r *http.Request, param
 	return sendJSON(w, http.StatusOK, token)
 }
 
+func (a *API) verifyRecoveryCodeFactor(w http.ResponseWriter, r *http.Request, params *VerifyFactorParams) error {
+	ctx := r.Context()
+	user := getUser(ctx)
+	factor := getFactor(ctx)
+	config := a.config
+	db := a.db.WithContext(ctx)
+
+	challenge, err := a.validateChallenge(r, db, factor, params.ChallengeID)
+	if err != nil {
+		return err
+	}
+
+	recoveryCode, err := factor.FindUnusedRecoveryCode(db, params.Code)
+	if err != nil && !models.IsNotFoundError(err) {
+		return apierrors.NewInternalServerError("Database error finding recovery code").WithInternalError(err)
+	}
+	valid := recoveryCode != nil
+
+	if config.Hook.MFAVerificationAttempt.Enabled {
+		input := v0hooks.MFAVerificationAttemptInput{
+			UserID:     user.ID,
+			FactorID:   factor.ID,
+			FactorType: factor.FactorType,
+			Valid:      valid,
+		}
+
+		output := v0hooks.MFAVerificationAttemptOutput{}
+		err := a.hooksMgr.InvokeHook(nil, r, &input, &output)
+		if err != nil {
+			return err
+		}
+
+		if output.Decision == v0hooks.HookRejection {
+			if err := models.Logout(db, user.ID); err != nil {
+				return err
+			}
+
+			if output.Message == "" {
+				output.Message = v0hooks.DefaultMFAHookRejectionMessage
+			}
+
+			return apierrors.NewForbiddenError(apierrors.ErrorCodeMFAVerificationRejected, output.Message)
+		}
+	}
+	if !valid {
+		return apierrors.NewUnprocessableEntityError(apierrors.ErrorCodeMFAVerificationFailed, "Invalid recovery code entered")
+	}
+
+	var token *AccessTokenResponse
+
+	err = db.Transaction(func(tx *storage.Connection) error {
+		used, terr := recoveryCode.Use(tx)
+		if terr != nil {
+			return terr
+		}
+		if !used {
+			return apierrors.NewUnprocessableEntityError(apierrors.ErrorCodeMFAVerificationFailed, "Invalid recovery code entered")
+		}
+		remaining, terr := factor.CountUnusedRecoveryCodes(tx)
+		if terr != nil {
+			return terr
+		}
+		if terr = models.NewAuditLogEntry(config.AuditLog, r, tx, user, models.VerifyFactorAction, r.RemoteAddr, map[string]interface{}{
+			"factor_id":                factor.ID,
+			"challenge_id":             challenge.ID,
+			"factor_type":              factor.FactorType,
+			"remaining_recovery_codes": remaining,
+		}); terr != nil {
+			return terr
+		}
+		if terr = challenge.Verify(tx); terr != nil {
+			return terr
+		}
+		user, terr = models.FindUserByID(tx, user.ID)
+		if terr != nil {
+			return terr
+		}
+
+		token, terr = a.updateMFASessionAndClaims(r, tx, user, models.MFARecoveryCode, models.GrantParams{
+			FactorID: &factor.ID,
+		})
+		if terr != nil {
+			return terr
+		}
+		if terr = models.InvalidateSessionsWithAALLessThan(tx, user.ID, models.AAL2.String()); terr != nil {
+			return apierrors.NewInternalServerError("Failed to update sessions. %s", terr)
+		}
+		return nil
+	})
+	if err != nil {
+		return err
+	}
+
+	metering.RecordLogin(metering.LoginTypeMFA, user.ID, &metering.LoginData{
+		Provider: metering.ProviderMFARecoveryCode,
+	})
+
+	return sendJSON(w, http.StatusOK, token)
+}
+
 func (a *API) VerifyFactor(w http.ResponseWriter, r *http.Request) error {
 	ctx := r.Context()
 	factor := getFactor(ctx)
@@ -976,6 +1461,9 @@ func (a *API) VerifyFactor(w http.ResponseWriter, r *http.Request) error {
 	if params.Code == "" && factor.FactorType != models.WebAuthn {
 		return apierrors.NewBadRequestError(apierrors.ErrorCodeValidationFailed, "Code needs to be non-empty")
 	}
+	if params.TrustDevice && !config.MFA.TrustedDevices.Enabled {
+		return apierrors.NewUnprocessableEntityError(apierrors.ErrorCodeMFATrustedDevicesDisabled, "Trusted devices are disabled")
+	}
 
 	switch factor.FactorType {
 	case models.Phone:
@@ -984,6 +1472,11 @@ func (a *API) VerifyFactor(w http.ResponseWriter, r *http.Request) error {
 		}
 
 		return a.verifyPhoneFactor(w, r, params)
+	case models.Email:
+		if !config.MFA.Email.VerifyEnabled {
+			return apierrors.NewUnprocessableEntityError(apierrors.ErrorCodeMFAEmailVerifyDisabled, "MFA verification is disabled for Email")
+		}
+		return a.verifyEmailFactor(w, r, params)
 	case models.TOTP:
 		if !config.MFA.TOTP.VerifyEnabled {
 			return apierrors.NewUnprocessableEntityError(apierrors.ErrorCodeMFATOTPVerifyDisabled, "MFA verification is disabled for TOTP")
@@ -994,8 +1487,18 @@ func (a *API) VerifyFactor(w http.ResponseWriter, r *http.Request) error {
 			return apierrors.NewUnprocessableEntityError(apierrors.ErrorCodeMFAWebAuthnEnrollDisabled, "MFA verification is disabled for WebAuthn")
 		}
 		return a.verifyWebAuthnFactor(w, r, params)
+	case models.RecoveryCode:
+		if !config.MFA.RecoveryCodes.VerifyEnabled {
+			return apierrors.NewUnprocessableEntityError(apierrors.ErrorCodeMFARecoveryCodesVerifyDisabled, "MFA verification is disabled for recovery codes")
+		}
+		// recovery codes are for emergencies, and must not let a device
+		// skip MFA long after the code was used
+		if params.TrustDevice {
+			return apierrors.NewBadRequestError(apierrors.ErrorCodeValidationFailed, "Devices can't be trusted when verifying with a recovery code")
+		}
+		return a.verifyRecoveryCodeFactor(w, r, params)
 	default:
-		return apierrors.NewBadRequestError(apierrors.ErrorCodeValidationFailed, "factor_type needs to be totp, phone, or webauthn")
+		return apierrors.NewBadRequestError(apierrors.ErrorCodeValidationFailed, "factor_type needs to be totp, phone, email, webauthn, or recovery_code")
 	}
 
 }
@@ -1029,6 +1532,13 @@ func (a *API) UnenrollFactor(w http.ResponseWriter, r *http.Request) error {
 		}); terr != nil {
 			return terr
 		}
+		if factor.FactorType == models.RecoveryCode {
+			if terr = models.NewAuditLogEntry(config.AuditLog, r, tx, user, models.DeleteRecoveryCodesAction, r.RemoteAddr, map[string]interface{}{
+				"factor_id": factor.ID,
+			}); terr != nil {
+				return terr
+			}
+		}
 		if terr = factor.DowngradeSessionsToAAL1(tx); terr != nil {
 			return terr
 		}
@@ -0,0 +1,78 @@
+package api
+
+import (
+	"slices"
+	"strings"
+	"time"
+
+	"github.com/supabase/auth/internal/api/apierrors"
+	"github.com/supabase/auth/internal/models"
+	"github.com/supabase/auth/internal/storage"
+)
+
+// MFAEnforcementState reports how the MFA enforcement policy applies to a user
+type MFAEnforcementState struct {
+	// Required is true when the policy requires the user to have a verified factor
+	Required bool `json:"required"`
+	// Deadline is when the enrollment grace period of the user ends
+	Deadline *time.Time `json:"deadline,omitempty"`
+	// Restricted is true when the grace period has ended without the user
+	// verifying a factor, and sessions are limited to enrolling one
+	Restricted bool `json:"restricted"`
+}
+
+// MFAEnforcementSettings reports whether the MFA enforcement policy is in use.
+// The roles and email domains it matches are deliberately not exposed.
+type MFAEnforcementSettings struct {
+	Enabled bool `json:"enabled"`
+	// GracePeriod is in seconds
+	GracePeriod int64 `json:"grace_period"`
+}
+
+func (a *API) mfaEnforcementRequired(user *models.User) bool {
+	config := a.config.MFA.Enforcement
+	if !config.Enabled || user.IsAnonymous {
+		return false
+	}
+
+	if role, ok := user.AppMetaData["role"].(string); ok && slices.Contains(config.Roles, role) {
+		return true
+	}
+
+	email := user.GetEmail()
+	if at := strings.LastIndex(email, "@"); at >= 0 {
+		if slices.Contains(config.EmailDomains, strings.ToLower(email[at+1:])) {
+			return true
+		}
+	}
+
+	return false
+}
+
+func (a *API) mfaEnforcementState(tx *storage.Connection, user *models.User) (*MFAEnforcementState, error) {
+	config := a.config.MFA.Enforcement
+	state := &MFAEnforcementState{}
+	if !a.mfaEnforcementRequired(user) {
+		return state, nil
+	}
+	state.Required = true
+
+	start := user.CreatedAt
+	if config.StartsAt.After(start) {
+		start = config.StartsAt
+	}
+	deadline := start.Add(config.GracePeriod).UTC()
+	state.Deadline = &deadline
+
+	if time.Now().Before(deadline) {
+		return state, nil
+	}
+
+	// factors may have been verified since the user was loaded
+	if err := tx.Load(user, "Factors"); err != nil {
+		return nil, apierrors.NewInternalServerError("Error loading user factors").WithInternalError(err)
+	}
+	state.Restricted = !user.HasMFAEnabled()
+
+	return state, nil
+}
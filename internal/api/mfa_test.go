@@ -11,6 +11,7 @@ import (
 	"time"
 
 	"github.com/gofrs/uuid"
+	"github.com/golang-jwt/jwt/v5"
 
 	"github.com/pquerna/otp"
 	"github.com/supabase/auth/internal/api/apierrors"
@@ -735,6 +736,331 @@ func performChallengeWebAuthnFlow(ts *MFATestSuite, factorID uuid.UUID, token st
 	return w
 }
 
+func (ts *MFATestSuite) TestRecoveryCodes() {
+	ts.Config.MFA.RecoveryCodes.EnrollEnabled = true
+	ts.Config.MFA.RecoveryCodes.VerifyEnabled = true
+
+	signUpResp := signUp(ts, "recovery@example.com", "testpassword")
+
+	// recovery codes can't be the first factor
+	performEnrollFlow(ts, signUpResp.Token, "recovery", models.RecoveryCode, "", "", http.StatusUnprocessableEntity)
+
+	verifyResp := AccessTokenResponse{}
+	y := performEnrollAndVerify(ts, signUpResp.Token, true)
+	require.NoError(ts.T(), json.NewDecoder(y.Body).Decode(&verifyResp))
+	aal2Token := verifyResp.Token
+
+	w := performEnrollFlow(ts, aal2Token, "recovery", models.RecoveryCode, "", "", http.StatusOK)
+	enrollResp := EnrollFactorResponse{}
+	require.NoError(ts.T(), json.NewDecoder(w.Body).Decode(&enrollResp))
+	require.Equal(ts.T(), models.RecoveryCode, enrollResp.Type)
+	require.Len(ts.T(), enrollResp.RecoveryCodes, ts.Config.MFA.RecoveryCodes.Count)
+	factorID := enrollResp.ID
+
+	// only one set of recovery codes per user
+	performEnrollFlow(ts, aal2Token, "recovery2", models.RecoveryCode, "", "", http.StatusUnprocessableEntity)
+
+	var buffer bytes.Buffer
+	w = ServeAuthenticatedRequest(ts, http.MethodPost, fmt.Sprintf("/factors/%s/recovery_codes", factorID), aal2Token, buffer)
+	require.Equal(ts.T(), http.StatusOK, w.Code)
+	regenerateResp := EnrollFactorResponse{}
+	require.NoError(ts.T(), json.NewDecoder(w.Body).Decode(&regenerateResp))
+	require.Len(ts.T(), regenerateResp.RecoveryCodes, ts.Config.MFA.RecoveryCodes.Count)
+
+	// a new AAL1 session steps up with a recovery code
+	grant, err := models.GrantAuthenticatedUser(ts.API.db, signUpResp.User, models.GrantParams{})
+	require.NoError(ts.T(), err)
+	aal1Token := ts.generateAAL1Token(signUpResp.User, grant.SessionId)
+
+	w = ServeAuthenticatedRequest(ts, http.MethodPost, fmt.Sprintf("/factors/%s/recovery_codes", factorID), aal1Token, buffer)
+	require.Equal(ts.T(), http.StatusForbidden, w.Code)
+
+	verifyRecoveryCode := func(code string, trustDevice bool) *httptest.ResponseRecorder {
+		w := performChallengeFlow(ts, factorID, aal1Token)
+		challengeResp := ChallengeFactorResponse{}
+		require.NoError(ts.T(), json.NewDecoder(w.Body).Decode(&challengeResp))
+
+		var buffer bytes.Buffer
+		require.NoError(ts.T(), json.NewEncoder(&buffer).Encode(map[string]interface{}{
+			"challenge_id": challengeResp.ID,
+			"code":         code,
+			"trust_device": trustDevice,
+		}))
+		return ServeAuthenticatedRequest(ts, http.MethodPost, fmt.Sprintf("/factors/%s/verify", factorID), aal1Token, buffer)
+	}
+
+	// codes of the previous set were invalidated
+	require.Equal(ts.T(), http.StatusUnprocessableEntity, verifyRecoveryCode(enrollResp.RecoveryCodes[0], false).Code)
+
+	code := regenerateResp.RecoveryCodes[0]
+
+	// recovery codes can't be used to trust a device
+	ts.Config.MFA.TrustedDevices.Enabled = true
+	defer func() {
+		ts.Config.MFA.TrustedDevices.Enabled = false
+	}()
+	require.Equal(ts.T(), http.StatusBadRequest, verifyRecoveryCode(code, true).Code)
+
+	w = verifyRecoveryCode(strings.ToUpper(code), false)
+	require.Equal(ts.T(), http.StatusOK, w.Code)
+
+	session, err := models.FindSessionByID(ts.API.db, *grant.SessionId, false)
+	require.NoError(ts.T(), err)
+	require.True(ts.T(), session.IsAAL2())
+	require.NoError(ts.T(), ts.API.db.Load(session, "AMRClaims"))
+	methods := []string{}
+	for _, claim := range session.AMRClaims {
+		methods = append(methods, claim.GetAuthenticationMethod())
+	}
+	require.Contains(ts.T(), methods, models.MFARecoveryCode.String())
+
+	// each code can only be used once
+	require.Equal(ts.T(), http.StatusUnprocessableEntity, verifyRecoveryCode(code, false).Code)
+
+	factor, err := models.FindFactorByFactorID(ts.API.db, factorID)
+	require.NoError(ts.T(), err)
+	remaining, err := factor.CountUnusedRecoveryCodes(ts.API.db)
+	require.NoError(ts.T(), err)
+	require.Equal(ts.T(), ts.Config.MFA.RecoveryCodes.Count-1, remaining)
+}
+
+func (ts *MFATestSuite) TestEmailFactor() {
+	ts.Config.MFA.Email.EnrollEnabled = true
+	ts.Config.MFA.Email.VerifyEnabled = true
+	ts.Config.MFA.Email.MaxFrequency = time.Minute
+
+	ts.Config.Hook.SendEmail.Enabled = true
+	ts.Config.Hook.SendEmail.URI = "pg-functions://postgres/auth/send_email_mfa_mock"
+	defer func() {
+		ts.Config.Hook.SendEmail.Enabled = false
+	}()
+
+	require.NoError(ts.T(), ts.Config.Hook.SendEmail.PopulateExtensibilityPoint())
+	require.NoError(ts.T(), ts.API.db.RawQuery(`
+        create or replace function send_email_mfa_mock(input jsonb)
+        returns json as $$
+        begin
+            return '{}'::json;
+       end; $$ language plpgsql;`).Exec())
+
+	grant, err := models.GrantAuthenticatedUser(ts.API.db, ts.TestUser, models.GrantParams{})
+	require.NoError(ts.T(), err)
+	token := ts.generateAAL1Token(ts.TestUser, grant.SessionId)
+
+	// the factor's address can differ from the user's email
+	var buffer bytes.Buffer
+	require.NoError(ts.T(), json.NewEncoder(&buffer).Encode(EnrollFactorParams{
+		FriendlyName: "work email",
+		FactorType:   models.Email,
+		Email:        "Work@Example.org",
+	}))
+	w := ServeAuthenticatedRequest(ts, http.MethodPost, "http://localhost/factors/", token, buffer)
+	require.Equal(ts.T(), http.StatusOK, w.Code)
+	enrollResp := EnrollFactorResponse{}
+	require.NoError(ts.T(), json.NewDecoder(w.Body).Decode(&enrollResp))
+	require.Equal(ts.T(), models.Email, enrollResp.Type)
+	require.Equal(ts.T(), "work@example.org", enrollResp.Email)
+
+	w = performChallengeFlow(ts, enrollResp.ID, token)
+	challengeResp := ChallengeFactorResponse{}
+	require.NoError(ts.T(), json.NewDecoder(w.Body).Decode(&challengeResp))
+	require.Equal(ts.T(), models.Email, challengeResp.Type)
+
+	// codes can only be sent once per max frequency
+	w = ServeAuthenticatedRequest(ts, http.MethodPost, fmt.Sprintf("http://localhost/factors/%s/challenge", enrollResp.ID), token, bytes.Buffer{})
+	require.Equal(ts.T(), http.StatusTooManyRequests, w.Code)
+
+	factor, err := models.FindFactorByFactorID(ts.API.db, enrollResp.ID)
+	require.NoError(ts.T(), err)
+	challenge, err := factor.FindChallengeByID(ts.API.db, challengeResp.ID)
+	require.NoError(ts.T(), err)
+	code, _, err := challenge.GetOtpCode(ts.Config.Security.DBEncryption.DecryptionKeys, ts.Config.Security.DBEncryption.Encrypt, ts.Config.Security.DBEncryption.EncryptionKeyID)
+	require.NoError(ts.T(), err)
+	require.Len(ts.T(), code, ts.Config.MFA.Email.OtpLength)
+
+	verify := func(code string) *httptest.ResponseRecorder {
+		var buffer bytes.Buffer
+		require.NoError(ts.T(), json.NewEncoder(&buffer).Encode(map[string]interface{}{
+			"challenge_id": challengeResp.ID,
+			"code":         code,
+		}))
+		return ServeAuthenticatedRequest(ts, http.MethodPost, fmt.Sprintf("http://localhost/factors/%s/verify", enrollResp.ID), token, buffer)
+	}
+
+	require.Equal(ts.T(), http.StatusUnprocessableEntity, verify("000000x").Code)
+	require.Equal(ts.T(), http.StatusOK, verify(code).Code)
+
+	session, err := models.FindSessionByID(ts.API.db, *grant.SessionId, false)
+	require.NoError(ts.T(), err)
+	require.True(ts.T(), session.IsAAL2())
+	require.NoError(ts.T(), ts.API.db.Load(session, "AMRClaims"))
+	methods := []string{}
+	for _, claim := range session.AMRClaims {
+		methods = append(methods, claim.GetAuthenticationMethod())
+	}
+	require.Contains(ts.T(), methods, models.MFAEmail.String())
+
+	ts.Config.MFA.Email.VerifyEnabled = false
+	w = ServeAuthenticatedRequest(ts, http.MethodPost, fmt.Sprintf("http://localhost/factors/%s/challenge", enrollResp.ID), token, bytes.Buffer{})
+	require.Equal(ts.T(), http.StatusUnprocessableEntity, w.Code)
+}
+
+func (ts *MFATestSuite) TestTrustedDevices() {
+	ts.Config.MFA.TrustedDevices.Enabled = true
+	ts.Config.MFA.TrustedDevices.ExpiryDuration = time.Hour
+
+	signUpResp := signUp(ts, "trusted@example.com", "testpassword")
+
+	w := performEnrollFlow(ts, signUpResp.Token, "", models.TOTP, ts.TestDomain, "", http.StatusOK)
+	enrollResp := EnrollFactorResponse{}
+	require.NoError(ts.T(), json.NewDecoder(w.Body).Decode(&enrollResp))
+	factorID := enrollResp.ID
+
+	w = performChallengeFlow(ts, factorID, signUpResp.Token)
+	challengeResp := ChallengeFactorResponse{}
+	require.NoError(ts.T(), json.NewDecoder(w.Body).Decode(&challengeResp))
+
+	code, err := totp.GenerateCode(enrollResp.TOTP.Secret, time.Now().UTC())
+	require.NoError(ts.T(), err)
+
+	var buffer bytes.Buffer
+	require.NoError(ts.T(), json.NewEncoder(&buffer).Encode(map[string]interface{}{
+		"challenge_id": challengeResp.ID,
+		"code":         code,
+		"trust_device": true,
+	}))
+	w = ServeAuthenticatedRequest(ts, http.MethodPost, fmt.Sprintf("/factors/%s/verify", factorID), signUpResp.Token, buffer)
+	require.Equal(ts.T(), http.StatusOK, w.Code)
+	verifyResp := AccessTokenResponse{}
+	require.NoError(ts.T(), json.NewDecoder(w.Body).Decode(&verifyResp))
+	require.NotEmpty(ts.T(), verifyResp.TrustedDeviceToken)
+
+	w = ServeAuthenticatedRequest(ts, http.MethodGet, "/user/trusted_devices", verifyResp.Token, bytes.Buffer{})
+	require.Equal(ts.T(), http.StatusOK, w.Code)
+	listResp := TrustedDeviceListResponse{}
+	require.NoError(ts.T(), json.NewDecoder(w.Body).Decode(&listResp))
+	require.Len(ts.T(), listResp.TrustedDevices, 1)
+	require.Equal(ts.T(), factorID, listResp.TrustedDevices[0].FactorID)
+	deviceID := listResp.TrustedDevices[0].ID
+
+	verifyTrustedDevice := func(token, trustedDeviceToken string) *httptest.ResponseRecorder {
+		var buffer bytes.Buffer
+		require.NoError(ts.T(), json.NewEncoder(&buffer).Encode(map[string]interface{}{
+			"token": trustedDeviceToken,
+		}))
+		return ServeAuthenticatedRequest(ts, http.MethodPost, "/factors/trusted_devices/verify", token, buffer)
+	}
+
+	// a later AAL1 session skips the challenge on the trusted device
+	grant, err := models.GrantAuthenticatedUser(ts.API.db, signUpResp.User, models.GrantParams{})
+	require.NoError(ts.T(), err)
+	aal1Token := ts.generateAAL1Token(signUpResp.User, grant.SessionId)
+
+	other, err := models.GrantAuthenticatedUser(ts.API.db, signUpResp.User, models.GrantParams{})
+	require.NoError(ts.T(), err)
+
+	require.Equal(ts.T(), http.StatusUnprocessableEntity, verifyTrustedDevice(aal1Token, "not-a-trusted-device").Code)
+	require.Equal(ts.T(), http.StatusOK, verifyTrustedDevice(aal1Token, verifyResp.TrustedDeviceToken).Code)
+
+	session, err := models.FindSessionByID(ts.API.db, *grant.SessionId, false)
+	require.NoError(ts.T(), err)
+	require.True(ts.T(), session.IsAAL2())
+
+	// like other verifications, the user's AAL1 sessions are signed out
+	_, err = models.FindSessionByID(ts.API.db, *other.SessionId, false)
+	require.True(ts.T(), models.IsNotFoundError(err))
+	require.NoError(ts.T(), ts.API.db.Load(session, "AMRClaims"))
+	methods := []string{}
+	for _, claim := range session.AMRClaims {
+		methods = append(methods, claim.GetAuthenticationMethod())
+	}
+	require.Contains(ts.T(), methods, models.MFATrustedDevice.String())
+
+	// revoked devices go through MFA again
+	w = ServeAuthenticatedRequest(ts, http.MethodDelete, fmt.Sprintf("/user/trusted_devices/%s", deviceID), verifyResp.Token, bytes.Buffer{})
+	require.Equal(ts.T(), http.StatusNoContent, w.Code)
+
+	grant, err = models.GrantAuthenticatedUser(ts.API.db, signUpResp.User, models.GrantParams{})
+	require.NoError(ts.T(), err)
+	aal1Token = ts.generateAAL1Token(signUpResp.User, grant.SessionId)
+	require.Equal(ts.T(), http.StatusUnprocessableEntity, verifyTrustedDevice(aal1Token, verifyResp.TrustedDeviceToken).Code)
+
+	// deleting the factor revokes the devices trusted with it
+	device, token := models.NewTrustedDevice(signUpResp.User.ID, factorID, "", "", time.Hour)
+	require.NoError(ts.T(), ts.API.db.Create(device))
+
+	factor, err := models.FindFactorByFactorID(ts.API.db, factorID)
+	require.NoError(ts.T(), err)
+	require.NoError(ts.T(), ts.API.db.Destroy(factor))
+
+	_, err = models.FindTrustedDeviceByToken(ts.API.db, signUpResp.User.ID, token)
+	require.True(ts.T(), models.IsNotFoundError(err))
+}
+
+func (ts *MFATestSuite) TestMFAEnforcement() {
+	ts.Config.MFA.Enforcement = conf.MFAEnforcementConfiguration{
+		Enabled:      true,
+		Roles:        []string{"admin"},
+		EmailDomains: []string{"example.org"},
+	}
+	defer func() {
+		ts.Config.MFA.Enforcement = conf.MFAEnforcementConfiguration{}
+	}()
+
+	parseClaims := func(token string) *AccessTokenClaims {
+		claims := &AccessTokenClaims{}
+		_, err := jwt.NewParser().ParseWithClaims(token, claims, ts.API.jwtKeyFunc)
+		require.NoError(ts.T(), err)
+		return claims
+	}
+	fetchUser := func(token string) UserResponse {
+		w := ServeAuthenticatedRequest(ts, http.MethodGet, "/user", token, bytes.Buffer{})
+		require.Equal(ts.T(), http.StatusOK, w.Code)
+		resp := UserResponse{}
+		require.NoError(ts.T(), json.NewDecoder(w.Body).Decode(&resp))
+		require.NotNil(ts.T(), resp.MFAEnforcement)
+		return resp
+	}
+
+	// users outside the policy are unaffected
+	grant, err := models.GrantAuthenticatedUser(ts.API.db, ts.TestUser, models.GrantParams{})
+	require.NoError(ts.T(), err)
+	token := ts.generateAAL1Token(ts.TestUser, grant.SessionId)
+	require.False(ts.T(), parseClaims(token).MFARequired)
+	require.False(ts.T(), fetchUser(token).MFAEnforcement.Required)
+
+	// matching users get a grace period to enroll
+	ts.Config.MFA.Enforcement.GracePeriod = time.Hour
+	require.NoError(ts.T(), ts.TestUser.UpdateAppMetaData(ts.API.db, map[string]interface{}{"role": "admin"}))
+	token = ts.generateAAL1Token(ts.TestUser, grant.SessionId)
+	require.False(ts.T(), parseClaims(token).MFARequired)
+	state := fetchUser(token).MFAEnforcement
+	require.True(ts.T(), state.Required)
+	require.False(ts.T(), state.Restricted)
+	require.WithinDuration(ts.T(), ts.TestUser.CreatedAt.Add(time.Hour), *state.Deadline, time.Second)
+
+	// after which sessions can only be used to enroll a factor
+	ts.Config.MFA.Enforcement.GracePeriod = 0
+	signUpResp := signUp(ts, "enforced@example.org", "testpassword")
+	require.True(ts.T(), parseClaims(signUpResp.Token).MFARequired)
+	require.True(ts.T(), fetchUser(signUpResp.Token).MFAEnforcement.Restricted)
+
+	w := ServeAuthenticatedRequest(ts, http.MethodGet, "/user/sessions", signUpResp.Token, bytes.Buffer{})
+	require.Equal(ts.T(), http.StatusForbidden, w.Code)
+	data := &HTTPError{}
+	require.NoError(ts.T(), json.NewDecoder(w.Body).Decode(data))
+	require.Equal(ts.T(), apierrors.ErrorCodeMFAEnrollmentRequired, data.ErrorCode)
+
+	w = performEnrollAndVerify(ts, signUpResp.Token, true)
+	verifyResp := AccessTokenResponse{}
+	require.NoError(ts.T(), json.NewDecoder(w.Body).Decode(&verifyResp))
+	require.False(ts.T(), parseClaims(verifyResp.Token).MFARequired)
+	require.False(ts.T(), fetchUser(verifyResp.Token).MFAEnforcement.Restricted)
+
+	w = ServeAuthenticatedRequest(ts, http.MethodGet, "/user/sessions", verifyResp.Token, bytes.Buffer{})
+	require.Equal(ts.T(), http.StatusOK, w.Code)
+}
+
 func (ts *MFATestSuite) TestChallengeFactorNotOwnedByUser() {
 	var buffer bytes.Buffer
 	email := "nomfaenabled@test.com"
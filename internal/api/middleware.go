@@ -87,6 +87,23 @@ func (a *API) limitHandler(lmt *limiter.Limiter) middlewareHandler {
 func (a *API) oauthClientAuth(w http.ResponseWriter, r *http.Request) (context.Context, error) {
 	ctx := r.Context()
 
+	// Clients using private_key_jwt authenticate with a client assertion
+	assertionClientID, assertion, err := oauthserver.ExtractClientAssertion(r)
+	if err != nil {
+		return nil, apierrors.NewBadRequestError(apierrors.ErrorCodeInvalidCredentials, "Invalid client credentials: "+err.Error())
+	}
+
+	if assertion != "" {
+		baseURL := strings.TrimSuffix(a.config.API.ExternalURL, "/")
+		audiences := []string{a.oauthIssuer(), baseURL + "/token", baseURL + r.URL.Path}
+
+		client, err := a.oauthServer.AuthenticateClientAssertion(ctx, assertionClientID, assertion, audiences)
+		if err != nil {
+			return nil, err
+		}
+		return oauthserver.WithOAuthServerClient(ctx, client), nil
+	}
+
 	clientID, clientSecret, err := oauthserver.ExtractClientCredentials(r)
 	if err != nil {
 		return nil, apierrors.NewBadRequestError(apierrors.ErrorCodeInvalidCredentials, "Invalid client credentials: "+err.Error())
@@ -108,7 +125,7 @@ func (a *API) oauthClientAuth(w http.ResponseWriter, r *http.Request) (context.C
 	}
 
 	// Validate client secret
-	if !oauthserver.ValidateClientSecret(clientSecret, client.ClientSecretHash) {
+	if !oauthserver.ValidateOAuthServerClientSecret(client, clientSecret) {
 		return nil, apierrors.NewBadRequestError(apierrors.ErrorCodeInvalidCredentials, "Invalid client credentials")
 	}
 
@@ -194,6 +211,10 @@ func isIgnoreCaptchaRoute(req *http.Request) bool {
 
 	case "web3":
 		return false
+
+	case "webauthn":
+		// captcha was verified when the passkey challenge was issued
+		return true
 	}
 
 	return false
@@ -48,3 +48,12 @@ func ExtractClientCredentials(r *http.Request) (clientID, clientSecret string, e
 
 	return clientID, clientSecret, nil
 }
+
+// extractBearerToken extracts a bearer token from the Authorization header
+func extractBearerToken(r *http.Request) string {
+	authHeader := r.Header.Get("Authorization")
+	if len(authHeader) < 7 || !strings.EqualFold(authHeader[:7], "Bearer ") {
+		return ""
+	}
+	return strings.TrimSpace(authHeader[7:])
+}
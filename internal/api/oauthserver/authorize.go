@@ -0,0 +1,456 @@
+package oauthserver
+
+import (
+	"context"
+	"encoding/json"
+	"net/http"
+	"net/url"
+	"regexp"
+	"slices"
+	"strings"
+
+	"github.com/go-chi/chi/v5"
+	"github.com/supabase/auth/internal/api/apierrors"
+	"github.com/supabase/auth/internal/api/shared"
+	"github.com/supabase/auth/internal/models"
+	"github.com/supabase/auth/internal/observability"
+	"github.com/supabase/auth/internal/storage"
+)
+
+const (
+	minCodeChallengeLength = 43
+	maxCodeChallengeLength = 128
+)
+
+var codeChallengePattern = regexp.MustCompile("^[a-zA-Z._~0-9-]+$")
+
+// AuthorizeParams are the query parameters accepted by the authorization endpoint
+type AuthorizeParams struct {
+	ClientID            string
+	RedirectURI         string
+	ResponseType        string
+	Scope               string
+	State               string
+	Nonce               string
+	CodeChallenge       string
+	CodeChallengeMethod string
+}
+
+// ConsentParams are the parameters accepted when a user approves or denies an authorization request
+type ConsentParams struct {
+	Action string `json:"action"`
+}
+
+// AuthorizationDetailsResponse describes a pending authorization request to the consent UI
+type AuthorizationDetailsResponse struct {
+	AuthorizationID string                    `json:"authorization_id"`
+	RedirectURI     string                    `json:"redirect_uri"`
+	Scope           string                    `json:"scope"`
+	Client          AuthorizationClientDetail `json:"client"`
+	User            AuthorizationUserDetail   `json:"user"`
+}
+
+// AuthorizationClientDetail contains the public information about the client requesting authorization
+type AuthorizationClientDetail struct {
+	ClientID   string `json:"client_id"`
+	ClientName string `json:"client_name,omitempty"`
+	ClientURI  string `json:"client_uri,omitempty"`
+	LogoURI    string `json:"logo_uri,omitempty"`
+}
+
+// AuthorizationUserDetail contains the information about the user being asked for consent
+type AuthorizationUserDetail struct {
+	ID    string `json:"id"`
+	Email string `json:"email,omitempty"`
+}
+
+// ConsentResponse contains the URL the consent UI should send the user back to
+type ConsentResponse struct {
+	RedirectURL string `json:"redirect_url"`
+}
+
+func parseAuthorizeParams(r *http.Request) *AuthorizeParams {
+	return authorizeParamsFromValues(r.URL.Query())
+}
+
+func authorizeParamsFromValues(query url.Values) *AuthorizeParams {
+	return &AuthorizeParams{
+		ClientID:            query.Get("client_id"),
+		RedirectURI:         query.Get("redirect_uri"),
+		ResponseType:        query.Get("response_type"),
+		Scope:               query.Get("scope"),
+		State:               query.Get("state"),
+		Nonce:               query.Get("nonce"),
+		CodeChallenge:       query.Get("code_challenge"),
+		CodeChallengeMethod: query.Get("code_challenge_method"),
+	}
+}
+
+// resolveRedirectURI returns the redirect URI to use for the client. When the
+// client only has one registered redirect URI it may be omitted, otherwise it
+// must exactly match one of the registered redirect URIs.
+func resolveRedirectURI(client *models.OAuthServerClient, redirectURI string) (string, bool) {
+	registered := client.GetRedirectURIs()
+
+	if redirectURI == "" {
+		if len(registered) == 1 {
+			return registered[0], true
+		}
+		return "", false
+	}
+
+	for _, uri := range registered {
+		if uri == redirectURI {
+			return redirectURI, true
+		}
+	}
+
+	return "", false
+}
+
+// validateCodeChallenge validates the PKCE parameters of an authorization
+// request. PKCE is mandatory for all clients in OAuth 2.1, and only the S256
+// method is allowed.
+func validateCodeChallenge(codeChallenge, codeChallengeMethod string) (models.CodeChallengeMethod, *apierrors.OAuthError) {
+	if codeChallenge == "" {
+		return 0, apierrors.NewOAuthError("invalid_request", "code_challenge is required")
+	}
+
+	if len(codeChallenge) < minCodeChallengeLength || len(codeChallenge) > maxCodeChallengeLength {
+		return 0, apierrors.NewOAuthError("invalid_request", "code_challenge must be between 43 and 128 characters")
+	}
+
+	if !codeChallengePattern.MatchString(codeChallenge) {
+		return 0, apierrors.NewOAuthError("invalid_request", "code_challenge can only contain alphanumeric characters, hyphens, periods, underscores and tildes")
+	}
+
+	if codeChallengeMethod == "" {
+		return 0, apierrors.NewOAuthError("invalid_request", "code_challenge_method is required")
+	}
+
+	method, err := models.ParseCodeChallengeMethod(codeChallengeMethod)
+	if err != nil || method != models.SHA256 {
+		return 0, apierrors.NewOAuthError("invalid_request", "code_challenge_method must be 'S256'")
+	}
+
+	return method, nil
+}
+
+// resolveRequestedScope validates the scopes requested by the client against
+// the scopes it is allowed to request. Clients that don't request any scopes
+// are given all of the scopes they are allowed to request.
+func resolveRequestedScope(client *models.OAuthServerClient, scope string) (string, *apierrors.OAuthError) {
+	requested := models.ParseOAuthScopes(scope)
+	if len(requested) == 0 {
+		return strings.Join(client.AllowedScopes(), " "), nil
+	}
+
+	if !client.AllowsScopes(requested) {
+		return "", apierrors.NewOAuthError("invalid_scope", "The requested scope is not allowed for this client")
+	}
+
+	return strings.Join(requested, " "), nil
+}
+
+// redirectWithError sends the user back to the client with an OAuth error response
+func redirectWithError(w http.ResponseWriter, r *http.Request, redirectURI string, oauthErr *apierrors.OAuthError, state string) error {
+	u, err := url.Parse(redirectURI)
+	if err != nil {
+		return apierrors.NewInternalServerError("Error parsing redirect URI").WithInternalError(err)
+	}
+
+	q := u.Query()
+	q.Set("error", oauthErr.Err)
+	q.Set("error_description", oauthErr.Description)
+	if state != "" {
+		q.Set("state", state)
+	}
+	u.RawQuery = q.Encode()
+
+	http.Redirect(w, r, u.String(), http.StatusFound)
+	return nil
+}
+
+// buildAuthorizationURL returns the URL of the consent UI for the authorization request
+func (s *Server) buildAuthorizationURL(authorizationID string) (string, error) {
+	u, err := url.Parse(s.config.SiteURL)
+	if err != nil {
+		return "", err
+	}
+
+	u.Path = strings.TrimSuffix(u.Path, "/") + "/" + strings.TrimPrefix(s.config.OAuthServer.AuthorizationPath, "/")
+
+	q := u.Query()
+	q.Set("authorization_id", authorizationID)
+	u.RawQuery = q.Encode()
+
+	return u.String(), nil
+}
+
+// OAuthServerAuthorize handles GET /oauth/authorize (OAuth 2.1 authorization endpoint)
+func (s *Server) OAuthServerAuthorize(w http.ResponseWriter, r *http.Request) error {
+	ctx := r.Context()
+	db := s.db.WithContext(ctx)
+	params := parseAuthorizeParams(r)
+
+	if params.ClientID == "" {
+		return apierrors.NewBadRequestError(apierrors.ErrorCodeValidationFailed, "client_id is required")
+	}
+
+	observability.LogEntrySetField(r, "oauth_client_id", params.ClientID)
+
+	client, err := s.getOAuthServerClient(ctx, params.ClientID)
+	if err != nil {
+		if models.IsNotFoundError(err) {
+			return apierrors.NewBadRequestError(apierrors.ErrorCodeOAuthClientNotFound, "Invalid client_id")
+		}
+		return apierrors.NewInternalServerError("Error loading OAuth client").WithInternalError(err)
+	}
+
+	if requestURI := r.URL.Query().Get("request_uri"); requestURI != "" {
+		// the parameters pushed by the client take the place of the query
+		params, err = s.loadPushedAuthorizeParams(ctx, client, requestURI)
+		if err != nil {
+			return err
+		}
+	} else if client.RequirePushedAuthorizationRequests {
+		return apierrors.NewBadRequestError(apierrors.ErrorCodeValidationFailed, "This client must use pushed authorization requests")
+	}
+
+	// Errors with the client or redirect URI must not be redirected, as the
+	// redirect URI can't be trusted at this point.
+	redirectURI, ok := resolveRedirectURI(client, params.RedirectURI)
+	if !ok {
+		return apierrors.NewBadRequestError(apierrors.ErrorCodeValidationFailed, "redirect_uri is missing or not registered for this client")
+	}
+
+	if params.ResponseType != "code" {
+		return redirectWithError(w, r, redirectURI, apierrors.NewOAuthError("unsupported_response_type", "response_type must be 'code'"), params.State)
+	}
+
+	if !slices.Contains(client.GetGrantTypes(), "authorization_code") {
+		return redirectWithError(w, r, redirectURI, apierrors.NewOAuthError("unauthorized_client", "Client is not allowed to use the authorization_code grant"), params.State)
+	}
+
+	codeChallengeMethod, oauthErr := validateCodeChallenge(params.CodeChallenge, params.CodeChallengeMethod)
+	if oauthErr != nil {
+		return redirectWithError(w, r, redirectURI, oauthErr, params.State)
+	}
+
+	scope, oauthErr := resolveRequestedScope(client, params.Scope)
+	if oauthErr != nil {
+		return redirectWithError(w, r, redirectURI, oauthErr, params.State)
+	}
+
+	authorization := models.NewOAuthServerAuthorization(client, redirectURI, scope, params.State, params.CodeChallenge, codeChallengeMethod, s.config.OAuthServer.AuthorizationTTL)
+	authorization.Nonce = storage.NullString(params.Nonce)
+	authorization.RedirectURIProvided = params.RedirectURI != ""
+	if err := db.Create(authorization); err != nil {
+		return apierrors.NewInternalServerError("Error creating OAuth authorization").WithInternalError(err)
+	}
+
+	authorizationURL, err := s.buildAuthorizationURL(authorization.AuthorizationID)
+	if err != nil {
+		return apierrors.NewInternalServerError("Error building authorization URL").WithInternalError(err)
+	}
+
+	http.Redirect(w, r, authorizationURL, http.StatusFound)
+	return nil
+}
+
+// loadPendingAuthorization finds a pending authorization request and binds it
+// to the currently signed in user. An authorization request can only ever be
+// acted upon by the first user that loads it.
+func (s *Server) loadPendingAuthorization(tx *storage.Connection, r *http.Request, user *models.User) (*models.OAuthServerAuthorization, *models.OAuthServerClient, error) {
+	authorizationID := chi.URLParam(r, "authorization_id")
+	if authorizationID == "" {
+		return nil, nil, apierrors.NewBadRequestError(apierrors.ErrorCodeValidationFailed, "authorization_id is required")
+	}
+
+	authorization, err := models.FindOAuthServerAuthorizationByAuthorizationID(tx, authorizationID)
+	if err != nil {
+		if models.IsNotFoundError(err) {
+			return nil, nil, apierrors.NewNotFoundError(apierrors.ErrorCodeOAuthAuthorizationNotFound, "OAuth authorization not found")
+		}
+		return nil, nil, apierrors.NewInternalServerError("Error loading OAuth authorization").WithInternalError(err)
+	}
+
+	if authorization.UserID != nil && *authorization.UserID != user.ID {
+		return nil, nil, apierrors.NewNotFoundError(apierrors.ErrorCodeOAuthAuthorizationNotFound, "OAuth authorization not found")
+	}
+
+	if !authorization.IsPending() || authorization.IsExpired() {
+		return nil, nil, apierrors.NewBadRequestError(apierrors.ErrorCodeOAuthAuthorizationExpired, "OAuth authorization has expired or was already used")
+	}
+
+	client, err := models.FindOAuthServerClientByID(tx, authorization.ClientID)
+	if err != nil {
+		if models.IsNotFoundError(err) {
+			return nil, nil, apierrors.NewNotFoundError(apierrors.ErrorCodeOAuthClientNotFound, "OAuth client not found")
+		}
+		return nil, nil, apierrors.NewInternalServerError("Error loading OAuth client").WithInternalError(err)
+	}
+
+	if authorization.UserID == nil {
+		if err := authorization.SetUser(tx, user.ID); err != nil {
+			return nil, nil, apierrors.NewInternalServerError("Error updating OAuth authorization").WithInternalError(err)
+		}
+	}
+
+	return authorization, client, nil
+}
+
+// consentAuthentication returns how the user authenticated in the session
+// they are giving consent from, so that it can be reported in ID tokens.
+func consentAuthentication(ctx context.Context, user *models.User) (*models.OAuthServerAuthentication, error) {
+	session := shared.GetSession(ctx)
+	if session == nil {
+		return nil, apierrors.NewForbiddenError(apierrors.ErrorCodeSessionNotFound, "A user session is required to give consent")
+	}
+
+	authentication, err := models.NewOAuthServerAuthentication(session, user)
+	if err != nil {
+		return nil, apierrors.NewInternalServerError("Error calculating session AAL").WithInternalError(err)
+	}
+	return authentication, nil
+}
+
+// completeAuthorization approves or denies the authorization request and
+// returns the URL the user should be sent back to. Approving also records the
+// user's consent so that later requests for the same scopes skip the consent
+// step.
+func (s *Server) completeAuthorization(tx *storage.Connection, r *http.Request, user *models.User, authorization *models.OAuthServerAuthorization, client *models.OAuthServerClient, approve bool) (*url.URL, error) {
+	redirectURL, err := url.Parse(authorization.RedirectURI)
+	if err != nil {
+		return nil, apierrors.NewInternalServerError("Error parsing redirect URI").WithInternalError(err)
+	}
+	q := redirectURL.Query()
+
+	if approve {
+		authentication, err := consentAuthentication(r.Context(), user)
+		if err != nil {
+			return nil, err
+		}
+
+		if err := authorization.Approve(tx, authentication, s.config.OAuthServer.AuthorizationTTL); err != nil {
+			return nil, apierrors.NewInternalServerError("Error updating OAuth authorization").WithInternalError(err)
+		}
+
+		if _, err := models.GrantOAuthServerConsent(tx, user.ID, client.ID, models.ParseOAuthScopes(authorization.Scope)); err != nil {
+			return nil, apierrors.NewInternalServerError("Error saving OAuth consent").WithInternalError(err)
+		}
+
+		if err := models.NewAuditLogEntry(s.config.AuditLog, r, tx, user, models.OAuthConsentGrantedAction, "", map[string]interface{}{
+			"client_id": client.ClientID,
+			"scope":     authorization.Scope,
+		}); err != nil {
+			return nil, err
+		}
+
+		q.Set("code", authorization.AuthorizationCode.String())
+	} else {
+		if err := authorization.Deny(tx); err != nil {
+			return nil, apierrors.NewInternalServerError("Error updating OAuth authorization").WithInternalError(err)
+		}
+		q.Set("error", "access_denied")
+		q.Set("error_description", "The user denied the authorization request")
+	}
+
+	if state := authorization.State.String(); state != "" {
+		q.Set("state", state)
+	}
+	redirectURL.RawQuery = q.Encode()
+
+	return redirectURL, nil
+}
+
+// OAuthServerGetAuthorization handles GET /oauth/authorizations/{authorization_id}
+//
+// If the user has previously consented to all of the requested scopes, the
+// authorization is approved right away and only a redirect URL is returned.
+func (s *Server) OAuthServerGetAuthorization(w http.ResponseWriter, r *http.Request) error {
+	ctx := r.Context()
+	db := s.db.WithContext(ctx)
+	user := shared.GetUser(ctx)
+
+	var response interface{}
+	err := db.Transaction(func(tx *storage.Connection) error {
+		authorization, client, terr := s.loadPendingAuthorization(tx, r, user)
+		if terr != nil {
+			return terr
+		}
+
+		consent, terr := models.FindActiveOAuthServerConsent(tx, user.ID, client.ID)
+		if terr != nil && !models.IsNotFoundError(terr) {
+			return apierrors.NewInternalServerError("Error loading OAuth consent").WithInternalError(terr)
+		}
+
+		if consent != nil && consent.HasScopes(models.ParseOAuthScopes(authorization.Scope)) {
+			redirectURL, terr := s.completeAuthorization(tx, r, user, authorization, client, true)
+			if terr != nil {
+				return terr
+			}
+
+			response = &ConsentResponse{
+				RedirectURL: redirectURL.String(),
+			}
+			return nil
+		}
+
+		response = &AuthorizationDetailsResponse{
+			AuthorizationID: authorization.AuthorizationID,
+			RedirectURI:     authorization.RedirectURI,
+			Scope:           authorization.Scope,
+			Client: AuthorizationClientDetail{
+				ClientID:   client.ClientID,
+				ClientName: client.ClientName.String(),
+				ClientURI:  client.ClientURI.String(),
+				LogoURI:    client.LogoURI.String(),
+			},
+			User: AuthorizationUserDetail{
+				ID:    user.ID.String(),
+				Email: user.GetEmail(),
+			},
+		}
+		return nil
+	})
+	if err != nil {
+		return err
+	}
+
+	return shared.SendJSON(w, http.StatusOK, response)
+}
+
+// OAuthServerConsent handles POST /oauth/authorizations/{authorization_id}/consent
+func (s *Server) OAuthServerConsent(w http.ResponseWriter, r *http.Request) error {
+	ctx := r.Context()
+	db := s.db.WithContext(ctx)
+	user := shared.GetUser(ctx)
+
+	var params ConsentParams
+	if err := json.NewDecoder(r.Body).Decode(&params); err != nil {
+		return apierrors.NewBadRequestError(apierrors.ErrorCodeBadJSON, "Invalid JSON body")
+	}
+
+	if params.Action != "approve" && params.Action != "deny" {
+		return apierrors.NewBadRequestError(apierrors.ErrorCodeValidationFailed, "action must be 'approve' or 'deny'")
+	}
+
+	var redirectURL *url.URL
+	err := db.Transaction(func(tx *storage.Connection) error {
+		authorization, client, terr := s.loadPendingAuthorization(tx, r, user)
+		if terr != nil {
+			return terr
+		}
+
+		redirectURL, terr = s.completeAuthorization(tx, r, user, authorization, client, params.Action == "approve")
+		return terr
+	})
+	if err != nil {
+		return err
+	}
+
+	return shared.SendJSON(w, http.StatusOK, &ConsentResponse{
+		RedirectURL: redirectURL.String(),
+	})
+}
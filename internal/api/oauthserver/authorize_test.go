@@ -0,0 +1,227 @@
+package oauthserver
+
+import (
+	"bytes"
+	"context"
+	"encoding/json"
+	"net/http"
+	"net/http/httptest"
+	"net/url"
+	"testing"
+
+	"github.com/go-chi/chi/v5"
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+	"github.com/supabase/auth/internal/api/shared"
+	"github.com/supabase/auth/internal/models"
+)
+
+const testCodeChallenge = "E9Melhoa2OwvFrEMTJguCHaoeK1t8URWbuGJSstw-cM"
+
+func (ts *OAuthClientTestSuite) createTestUser(email string) *models.User {
+	user, err := models.NewUser("", email, "password", ts.Config.JWT.Aud, nil)
+	require.NoError(ts.T(), err)
+	require.NoError(ts.T(), ts.DB.Create(user))
+	return user
+}
+
+func (ts *OAuthClientTestSuite) authorize(client *models.OAuthServerClient, query url.Values) *httptest.ResponseRecorder {
+	query.Set("client_id", client.ClientID)
+
+	req := httptest.NewRequest(http.MethodGet, "/oauth/authorize?"+query.Encode(), nil)
+	w := httptest.NewRecorder()
+
+	require.NoError(ts.T(), ts.Server.OAuthServerAuthorize(w, req))
+	return w
+}
+
+func (ts *OAuthClientTestSuite) authorizationRequest(method, authorizationID string, user *models.User, body []byte) *http.Request {
+	req := httptest.NewRequest(method, "/oauth/authorizations/"+authorizationID, bytes.NewReader(body))
+
+	rctx := chi.NewRouteContext()
+	rctx.URLParams.Add("authorization_id", authorizationID)
+
+	// the user signed in with a password before giving consent
+	session, err := models.NewSession(user.ID, nil)
+	require.NoError(ts.T(), err)
+	require.NoError(ts.T(), ts.DB.Create(session))
+	require.NoError(ts.T(), models.AddClaimToSession(ts.DB, session.ID, models.PasswordGrant))
+	session, err = models.FindSessionByID(ts.DB, session.ID, false)
+	require.NoError(ts.T(), err)
+
+	ctx := context.WithValue(req.Context(), chi.RouteCtxKey, rctx)
+	ctx = shared.WithUser(ctx, user)
+	ctx = shared.WithSession(ctx, session)
+	return req.WithContext(ctx)
+}
+
+func (ts *OAuthClientTestSuite) TestOAuthServerAuthorizeRedirectsToConsent() {
+	client, _ := ts.createTestOAuthClient()
+
+	w := ts.authorize(client, url.Values{
+		"response_type":         {"code"},
+		"redirect_uri":          {"https://example.com/callback"},
+		"code_challenge":        {testCodeChallenge},
+		"code_challenge_method": {"S256"},
+		"state":                 {"xyz"},
+	})
+	require.Equal(ts.T(), http.StatusFound, w.Code)
+
+	location, err := url.Parse(w.Header().Get("Location"))
+	require.NoError(ts.T(), err)
+	assert.Contains(ts.T(), location.Path, ts.Config.OAuthServer.AuthorizationPath)
+
+	authorizationID := location.Query().Get("authorization_id")
+	require.NotEmpty(ts.T(), authorizationID)
+
+	authorization, err := models.FindOAuthServerAuthorizationByAuthorizationID(ts.DB, authorizationID)
+	require.NoError(ts.T(), err)
+	assert.Equal(ts.T(), client.ID, authorization.ClientID)
+	assert.Equal(ts.T(), "xyz", authorization.State.String())
+	assert.True(ts.T(), authorization.IsPending())
+
+	// requests without a scope are given the scopes allowed for the client
+	assert.Equal(ts.T(), client.AllowedScopes(), models.ParseOAuthScopes(authorization.Scope))
+}
+
+func (ts *OAuthClientTestSuite) TestOAuthServerAuthorizeValidation() {
+	client, _ := ts.createTestOAuthClient()
+
+	// unregistered redirect URIs must not be redirected to
+	req := httptest.NewRequest(http.MethodGet, "/oauth/authorize?"+url.Values{
+		"client_id":      {client.ClientID},
+		"response_type":  {"code"},
+		"redirect_uri":   {"https://evil.example.com/callback"},
+		"code_challenge": {testCodeChallenge},
+	}.Encode(), nil)
+	err := ts.Server.OAuthServerAuthorize(httptest.NewRecorder(), req)
+	require.Error(ts.T(), err)
+
+	// missing PKCE is reported back to the client
+	w := ts.authorize(client, url.Values{
+		"response_type": {"code"},
+		"redirect_uri":  {"https://example.com/callback"},
+		"state":         {"xyz"},
+	})
+	require.Equal(ts.T(), http.StatusFound, w.Code)
+
+	location, err := url.Parse(w.Header().Get("Location"))
+	require.NoError(ts.T(), err)
+	assert.Equal(ts.T(), "example.com", location.Host)
+	assert.Equal(ts.T(), "invalid_request", location.Query().Get("error"))
+	assert.Equal(ts.T(), "xyz", location.Query().Get("state"))
+
+	// the plain PKCE method is not allowed in OAuth 2.1
+	w = ts.authorize(client, url.Values{
+		"response_type":         {"code"},
+		"redirect_uri":          {"https://example.com/callback"},
+		"code_challenge":        {testCodeChallenge},
+		"code_challenge_method": {"plain"},
+	})
+	require.Equal(ts.T(), http.StatusFound, w.Code)
+
+	location, err = url.Parse(w.Header().Get("Location"))
+	require.NoError(ts.T(), err)
+	assert.Equal(ts.T(), "invalid_request", location.Query().Get("error"))
+
+	// scopes that aren't allowed for the client are rejected
+	w = ts.authorize(client, url.Values{
+		"response_type":         {"code"},
+		"redirect_uri":          {"https://example.com/callback"},
+		"code_challenge":        {testCodeChallenge},
+		"code_challenge_method": {"S256"},
+		"scope":                 {"openid admin"},
+	})
+	require.Equal(ts.T(), http.StatusFound, w.Code)
+
+	location, err = url.Parse(w.Header().Get("Location"))
+	require.NoError(ts.T(), err)
+	assert.Equal(ts.T(), "invalid_scope", location.Query().Get("error"))
+}
+
+func (ts *OAuthClientTestSuite) TestOAuthServerConsent() {
+	client, _ := ts.createTestOAuthClient()
+	user := ts.createTestUser("consent@example.com")
+
+	cases := []struct {
+		action      string
+		expectCode  bool
+		expectError string
+	}{
+		{action: "approve", expectCode: true},
+		{action: "deny", expectError: "access_denied"},
+	}
+
+	for _, c := range cases {
+		w := ts.authorize(client, url.Values{
+			"response_type":         {"code"},
+			"redirect_uri":          {"https://example.com/callback"},
+			"code_challenge":        {testCodeChallenge},
+			"code_challenge_method": {"S256"},
+			"state":                 {"xyz"},
+		})
+		location, err := url.Parse(w.Header().Get("Location"))
+		require.NoError(ts.T(), err)
+		authorizationID := location.Query().Get("authorization_id")
+
+		w = httptest.NewRecorder()
+		require.NoError(ts.T(), ts.Server.OAuthServerGetAuthorization(w, ts.authorizationRequest(http.MethodGet, authorizationID, user, nil)))
+
+		var details AuthorizationDetailsResponse
+		require.NoError(ts.T(), json.Unmarshal(w.Body.Bytes(), &details))
+		assert.Equal(ts.T(), client.ClientID, details.Client.ClientID)
+		assert.Equal(ts.T(), user.ID.String(), details.User.ID)
+
+		// another user can no longer act on this authorization
+		other := ts.createTestUser(c.action + "-other@example.com")
+		err = ts.Server.OAuthServerGetAuthorization(httptest.NewRecorder(), ts.authorizationRequest(http.MethodGet, authorizationID, other, nil))
+		require.Error(ts.T(), err)
+
+		body, err := json.Marshal(ConsentParams{Action: c.action})
+		require.NoError(ts.T(), err)
+
+		w = httptest.NewRecorder()
+		require.NoError(ts.T(), ts.Server.OAuthServerConsent(w, ts.authorizationRequest(http.MethodPost, authorizationID, user, body)))
+
+		var response ConsentResponse
+		require.NoError(ts.T(), json.Unmarshal(w.Body.Bytes(), &response))
+
+		redirectURL, err := url.Parse(response.RedirectURL)
+		require.NoError(ts.T(), err)
+		assert.Equal(ts.T(), "xyz", redirectURL.Query().Get("state"))
+		assert.Equal(ts.T(), c.expectCode, redirectURL.Query().Get("code") != "")
+		assert.Equal(ts.T(), c.expectError, redirectURL.Query().Get("error"))
+
+		// approvals record how the user authenticated for the ID token
+		authorization, err := models.FindOAuthServerAuthorizationByAuthorizationID(ts.DB, authorizationID)
+		require.NoError(ts.T(), err)
+		if c.expectCode {
+			require.NotNil(ts.T(), authorization.Authentication())
+			assert.Equal(ts.T(), "aal1", authorization.Authentication().AAL)
+			assert.Equal(ts.T(), []string{models.PasswordGrant.String()}, authorization.Authentication().AMR)
+		} else {
+			assert.Nil(ts.T(), authorization.Authentication())
+		}
+
+		// consent can only be given once
+		err = ts.Server.OAuthServerConsent(httptest.NewRecorder(), ts.authorizationRequest(http.MethodPost, authorizationID, user, body))
+		require.Error(ts.T(), err)
+	}
+}
+
+func TestValidateCodeChallenge(t *testing.T) {
+	_, err := validateCodeChallenge(testCodeChallenge, "S256")
+	require.Nil(t, err)
+
+	_, err = validateCodeChallenge("", "S256")
+	require.NotNil(t, err)
+
+	_, err = validateCodeChallenge("short", "S256")
+	require.NotNil(t, err)
+
+	_, err = validateCodeChallenge(testCodeChallenge, "")
+	require.NotNil(t, err)
+
+	_, err = validateCodeChallenge(testCodeChallenge, "md5")
+	require.NotNil(t, err)
+}
@@ -0,0 +1,301 @@
+package oauthserver
+
+import (
+	"context"
+	"errors"
+	"fmt"
+	"net"
+	"net/http"
+	"slices"
+	"sync"
+	"syscall"
+	"time"
+
+	"github.com/golang-jwt/jwt/v5"
+	"github.com/lestrrat-go/jwx/v2/jwk"
+	"github.com/supabase/auth/internal/api/apierrors"
+	"github.com/supabase/auth/internal/models"
+)
+
+// ClientAssertionTypeJWTBearer is the RFC 7523 client assertion type used by
+// clients authenticating with private_key_jwt
+const ClientAssertionTypeJWTBearer = "urn:ietf:params:oauth:client-assertion-type:jwt-bearer"
+
+// maxClientAssertionLifetime limits how far in the future client assertions
+// may expire, which bounds how long their jti needs to be remembered
+const maxClientAssertionLifetime = time.Hour
+
+// ClientAssertionSigningMethods are the asymmetric algorithms accepted for
+// client assertions
+var ClientAssertionSigningMethods = []string{
+	"RS256", "RS384", "RS512",
+	"PS256", "PS384", "PS512",
+	"ES256", "ES384", "ES512",
+	"EdDSA",
+}
+
+// jwksMinRefreshInterval limits how often the keys of a client registered
+// with a jwks_uri are fetched
+const jwksMinRefreshInterval = 5 * time.Minute
+
+// maxCachedJWKS bounds the number of jwks_uri whose keys are cached
+const maxCachedJWKS = 1000
+
+// jwksHTTPClient is used to fetch the keys of clients registered with a
+// jwks_uri. It only connects to public addresses, so that jwks_uri can't be
+// used to reach the internal network.
+var jwksHTTPClient = &http.Client{
+	Timeout: 5 * time.Second,
+	Transport: &http.Transport{
+		DialContext: (&net.Dialer{
+			Timeout: 5 * time.Second,
+			Control: dialPublicAddressesOnly,
+		}).DialContext,
+		TLSHandshakeTimeout: 5 * time.Second,
+	},
+	CheckRedirect: func(req *http.Request, via []*http.Request) error {
+		return errors.New("jwks_uri must not redirect")
+	},
+}
+
+// dialPublicAddressesOnly is a net.Dialer control function that refuses
+// connections to loopback, private and link-local addresses. It runs after
+// DNS resolution, so host names resolving to such addresses are refused too.
+func dialPublicAddressesOnly(network, address string, _ syscall.RawConn) error {
+	host, _, err := net.SplitHostPort(address)
+	if err != nil {
+		return err
+	}
+
+	ip := net.ParseIP(host)
+	if ip == nil {
+		return fmt.Errorf("invalid address %q", address)
+	}
+
+	if ip.IsLoopback() || ip.IsPrivate() || ip.IsLinkLocalUnicast() || ip.IsLinkLocalMulticast() || ip.IsUnspecified() || ip.IsMulticast() {
+		return fmt.Errorf("connections to %s are not allowed", ip)
+	}
+
+	return nil
+}
+
+// ExtractClientAssertion extracts an RFC 7523 client assertion from the
+// request form. An empty assertion is returned if none was provided.
+func ExtractClientAssertion(r *http.Request) (clientID, assertion string, err error) {
+	if err := r.ParseForm(); err != nil {
+		return "", "", errors.New("failed to parse form")
+	}
+
+	assertionType := r.FormValue("client_assertion_type")
+	assertion = r.FormValue("client_assertion")
+
+	if assertionType == "" && assertion == "" {
+		return "", "", nil
+	}
+
+	if assertionType != ClientAssertionTypeJWTBearer {
+		return "", "", fmt.Errorf("client_assertion_type must be '%s'", ClientAssertionTypeJWTBearer)
+	}
+
+	if assertion == "" {
+		return "", "", errors.New("client_assertion is required")
+	}
+
+	return r.FormValue("client_id"), assertion, nil
+}
+
+// invalidClientAssertion returns the error reported for assertions that can't
+// be used to authenticate the client
+func invalidClientAssertion(reason string) *apierrors.HTTPError {
+	return apierrors.NewBadRequestError(apierrors.ErrorCodeInvalidCredentials, "Invalid client assertion: %s", reason)
+}
+
+// AuthenticateClientAssertion authenticates an OAuth server client using
+// private_key_jwt. The assertion must be signed with one of the client's
+// registered keys, be addressed to one of the provided audiences and can only
+// be used once.
+func (s *Server) AuthenticateClientAssertion(ctx context.Context, clientID, assertion string, audiences []string) (*models.OAuthServerClient, error) {
+	db := s.db.WithContext(ctx)
+
+	unverified := &jwt.RegisteredClaims{}
+	if _, _, err := jwt.NewParser().ParseUnverified(assertion, unverified); err != nil {
+		return nil, invalidClientAssertion("malformed JWT")
+	}
+
+	if unverified.Issuer == "" || unverified.Issuer != unverified.Subject {
+		return nil, invalidClientAssertion("iss and sub must be the client_id")
+	}
+
+	if clientID != "" && clientID != unverified.Issuer {
+		return nil, invalidClientAssertion("client_id does not match the assertion")
+	}
+
+	client, err := models.FindOAuthServerClientByClientID(db, unverified.Issuer)
+	if err != nil {
+		if models.IsNotFoundError(err) {
+			return nil, apierrors.NewBadRequestError(apierrors.ErrorCodeInvalidCredentials, "Invalid client credentials")
+		}
+		return nil, apierrors.NewInternalServerError("Error validating client credentials").WithInternalError(err)
+	}
+
+	if !client.UsesPrivateKeyJWT() {
+		return nil, invalidClientAssertion("client is not registered for private_key_jwt")
+	}
+
+	keys, err := s.getOAuthServerClientJWKS(ctx, client)
+	if err != nil {
+		return nil, invalidClientAssertion("unable to load the client's keys").WithInternalError(err)
+	}
+
+	claims := &jwt.RegisteredClaims{}
+	p := jwt.NewParser(
+		jwt.WithValidMethods(ClientAssertionSigningMethods),
+		jwt.WithExpirationRequired(),
+		jwt.WithIssuedAt(),
+	)
+	if _, err := p.ParseWithClaims(assertion, claims, func(token *jwt.Token) (interface{}, error) {
+		return findClientAssertionKey(keys, token)
+	}); err != nil {
+		return nil, invalidClientAssertion(err.Error())
+	}
+
+	if !slices.ContainsFunc(claims.Audience, func(aud string) bool {
+		return slices.Contains(audiences, aud)
+	}) {
+		return nil, invalidClientAssertion("aud must identify this server")
+	}
+
+	if claims.ID == "" {
+		return nil, invalidClientAssertion("jti is required")
+	}
+
+	if claims.ExpiresAt.After(time.Now().Add(maxClientAssertionLifetime)) {
+		return nil, invalidClientAssertion("exp is too far in the future")
+	}
+
+	recorded, err := models.RecordOAuthServerClientAssertion(db, client.ID, claims.ID, claims.ExpiresAt.Time)
+	if err != nil {
+		return nil, apierrors.NewInternalServerError("Error validating client credentials").WithInternalError(err)
+	}
+
+	if !recorded {
+		return nil, invalidClientAssertion("jti has already been used")
+	}
+
+	return client, nil
+}
+
+// getOAuthServerClientJWKS returns the keys registered for the client. Keys
+// fetched from a jwks_uri are cached, and refreshed at most every
+// jwksMinRefreshInterval.
+func (s *Server) getOAuthServerClientJWKS(ctx context.Context, client *models.OAuthServerClient) (jwk.Set, error) {
+	if client.JWKS != "" {
+		return jwk.ParseString(client.JWKS.String())
+	}
+
+	return s.jwksCache.get(ctx, client.JWKSURI.String())
+}
+
+type cachedJWKS struct {
+	keys      jwk.Set
+	expiresAt time.Time
+}
+
+// clientJWKSCache caches the keys fetched from the jwks_uri of private_key_jwt
+// clients. Keys are only fetched when a client authenticates, so nothing is
+// refreshed in the background, and at most maxCachedJWKS entries are kept.
+type clientJWKSCache struct {
+	entries map[string]*cachedJWKS
+	mutex   sync.Mutex
+}
+
+// newClientJWKSCache creates an empty cache
+func newClientJWKSCache() *clientJWKSCache {
+	return &clientJWKSCache{
+		entries: make(map[string]*cachedJWKS),
+	}
+}
+
+// get returns the keys published at the jwks_uri, fetching them if they
+// aren't cached or were cached more than jwksMinRefreshInterval ago.
+func (c *clientJWKSCache) get(ctx context.Context, jwksURI string) (jwk.Set, error) {
+	now := time.Now()
+
+	c.mutex.Lock()
+	cached, ok := c.entries[jwksURI]
+	c.mutex.Unlock()
+
+	if ok && now.Before(cached.expiresAt) {
+		return cached.keys, nil
+	}
+
+	keys, err := jwk.Fetch(ctx, jwksURI, jwk.WithHTTPClient(jwksHTTPClient))
+	if err != nil {
+		return nil, err
+	}
+
+	c.mutex.Lock()
+	defer c.mutex.Unlock()
+
+	if _, ok := c.entries[jwksURI]; !ok && len(c.entries) >= maxCachedJWKS {
+		c.evict(now)
+	}
+	c.entries[jwksURI] = &cachedJWKS{
+		keys:      keys,
+		expiresAt: now.Add(jwksMinRefreshInterval),
+	}
+
+	return keys, nil
+}
+
+// invalidate removes the keys cached for the jwks_uri, so that they are
+// fetched again the next time they are used.
+func (c *clientJWKSCache) invalidate(jwksURI string) {
+	c.mutex.Lock()
+	defer c.mutex.Unlock()
+
+	delete(c.entries, jwksURI)
+}
+
+// evict removes the expired entries, or the entry expiring soonest when none
+// have expired. The mutex must be held.
+func (c *clientJWKSCache) evict(now time.Time) {
+	var oldest string
+	for uri, cached := range c.entries {
+		if now.After(cached.expiresAt) {
+			delete(c.entries, uri)
+		} else if oldest == "" || cached.expiresAt.Before(c.entries[oldest].expiresAt) {
+			oldest = uri
+		}
+	}
+
+	if len(c.entries) >= maxCachedJWKS {
+		delete(c.entries, oldest)
+	}
+}
+
+// findClientAssertionKey returns the public key the assertion was signed with
+func findClientAssertionKey(keys jwk.Set, token *jwt.Token) (interface{}, error) {
+	var key jwk.Key
+
+	if kid, ok := token.Header["kid"].(string); ok && kid != "" {
+		key, ok = keys.LookupKeyID(kid)
+		if !ok {
+			return nil, fmt.Errorf("no key found for kid %q", kid)
+		}
+	} else if keys.Len() == 1 {
+		key, _ = keys.Key(0)
+	} else {
+		return nil, errors.New("kid is required when the client has more than one key")
+	}
+
+	if isPrivate, err := jwk.IsPrivateKey(key); err != nil || isPrivate {
+		return nil, errors.New("only asymmetric public keys can be used")
+	}
+
+	var raw interface{}
+	if err := key.Raw(&raw); err != nil {
+		return nil, err
+	}
+	return raw, nil
+}
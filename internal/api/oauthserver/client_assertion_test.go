@@ -0,0 +1,309 @@
+package oauthserver
+
+import (
+	"context"
+	"crypto/ecdsa"
+	"crypto/elliptic"
+	"crypto/rand"
+	"encoding/json"
+	"fmt"
+	"net/http"
+	"net/http/httptest"
+	"net/url"
+	"strings"
+	"testing"
+	"time"
+
+	"github.com/gofrs/uuid"
+	"github.com/golang-jwt/jwt/v5"
+	"github.com/lestrrat-go/jwx/v2/jwk"
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+	"github.com/supabase/auth/internal/models"
+)
+
+const testAssertionAudience = "https://auth.example.com/token"
+
+func (ts *OAuthServiceTestSuite) createPrivateKeyJWTClient() (*models.OAuthServerClient, *ecdsa.PrivateKey) {
+	privateKey, err := ecdsa.GenerateKey(elliptic.P256(), rand.Reader)
+	require.NoError(ts.T(), err)
+
+	publicKey, err := jwk.FromRaw(privateKey.Public())
+	require.NoError(ts.T(), err)
+	require.NoError(ts.T(), publicKey.Set(jwk.KeyIDKey, "test-key"))
+
+	set := jwk.NewSet()
+	require.NoError(ts.T(), set.AddKey(publicKey))
+	jwks, err := json.Marshal(set)
+	require.NoError(ts.T(), err)
+
+	params := &OAuthServerClientRegisterParams{
+		ClientName:              "Test Service",
+		GrantTypes:              []string{"client_credentials"},
+		TokenEndpointAuthMethod: models.PrivateKeyJWTAuthMethod,
+		JWKS:                    jwks,
+		RegistrationType:        "manual",
+	}
+
+	client, secret, err := ts.Server.registerOAuthServerClient(context.Background(), params)
+	require.NoError(ts.T(), err)
+	require.Empty(ts.T(), secret, "private_key_jwt clients must not receive a secret")
+
+	return client, privateKey
+}
+
+func signClientAssertion(t require.TestingT, key *ecdsa.PrivateKey, claims jwt.RegisteredClaims) string {
+	token := jwt.NewWithClaims(jwt.SigningMethodES256, claims)
+	token.Header["kid"] = "test-key"
+
+	signed, err := token.SignedString(key)
+	require.NoError(t, err)
+	return signed
+}
+
+func (ts *OAuthServiceTestSuite) TestAuthenticateClientAssertion() {
+	client, key := ts.createPrivateKeyJWTClient()
+	ctx := context.Background()
+	audiences := []string{testAssertionAudience}
+
+	claims := jwt.RegisteredClaims{
+		Issuer:    client.ClientID,
+		Subject:   client.ClientID,
+		Audience:  jwt.ClaimStrings{testAssertionAudience},
+		ExpiresAt: jwt.NewNumericDate(time.Now().Add(time.Minute)),
+		ID:        uuid.Must(uuid.NewV4()).String(),
+	}
+	assertion := signClientAssertion(ts.T(), key, claims)
+
+	authenticated, err := ts.Server.AuthenticateClientAssertion(ctx, "", assertion, audiences)
+	require.NoError(ts.T(), err)
+	assert.Equal(ts.T(), client.ID, authenticated.ID)
+
+	// assertions can only be used once
+	_, err = ts.Server.AuthenticateClientAssertion(ctx, "", assertion, audiences)
+	require.Error(ts.T(), err)
+	assert.Contains(ts.T(), err.Error(), "jti has already been used")
+
+	// private_key_jwt clients can't authenticate with a secret
+	assert.False(ts.T(), ValidateOAuthServerClientSecret(client, ""))
+
+	otherKey, err := ecdsa.GenerateKey(elliptic.P256(), rand.Reader)
+	require.NoError(ts.T(), err)
+
+	cases := []struct {
+		desc     string
+		clientID string
+		claims   func(claims jwt.RegisteredClaims) jwt.RegisteredClaims
+		key      *ecdsa.PrivateKey
+	}{
+		{
+			desc: "wrong audience",
+			claims: func(claims jwt.RegisteredClaims) jwt.RegisteredClaims {
+				claims.Audience = jwt.ClaimStrings{"https://other.example.com"}
+				return claims
+			},
+		},
+		{
+			desc: "expired",
+			claims: func(claims jwt.RegisteredClaims) jwt.RegisteredClaims {
+				claims.ExpiresAt = jwt.NewNumericDate(time.Now().Add(-time.Minute))
+				return claims
+			},
+		},
+		{
+			desc: "missing jti",
+			claims: func(claims jwt.RegisteredClaims) jwt.RegisteredClaims {
+				claims.ID = ""
+				return claims
+			},
+		},
+		{
+			desc: "expires too late",
+			claims: func(claims jwt.RegisteredClaims) jwt.RegisteredClaims {
+				claims.ExpiresAt = jwt.NewNumericDate(time.Now().Add(24 * time.Hour))
+				return claims
+			},
+		},
+		{
+			desc:     "mismatched client_id",
+			clientID: "other-client",
+		},
+		{
+			desc: "signed with an unregistered key",
+			key:  otherKey,
+		},
+	}
+
+	for _, c := range cases {
+		ts.Run(c.desc, func() {
+			caseClaims := claims
+			caseClaims.ID = uuid.Must(uuid.NewV4()).String()
+			if c.claims != nil {
+				caseClaims = c.claims(caseClaims)
+			}
+
+			signingKey := key
+			if c.key != nil {
+				signingKey = c.key
+			}
+
+			_, err := ts.Server.AuthenticateClientAssertion(ctx, c.clientID, signClientAssertion(ts.T(), signingKey, caseClaims), audiences)
+			require.Error(ts.T(), err)
+		})
+	}
+}
+
+func (ts *OAuthServiceTestSuite) TestPrivateKeyJWTRegistrationValidation() {
+	ctx := context.Background()
+
+	params := &OAuthServerClientRegisterParams{
+		GrantTypes:              []string{"client_credentials"},
+		TokenEndpointAuthMethod: models.PrivateKeyJWTAuthMethod,
+		RegistrationType:        "manual",
+	}
+
+	_, _, err := ts.Server.registerOAuthServerClient(ctx, params)
+	require.Error(ts.T(), err)
+	assert.Contains(ts.T(), err.Error(), "Exactly one of jwks or jwks_uri is required")
+
+	params.JWKSURI = "http://example.com/jwks.json"
+	_, _, err = ts.Server.registerOAuthServerClient(ctx, params)
+	require.Error(ts.T(), err)
+	assert.Contains(ts.T(), err.Error(), "jwks_uri must be a valid HTTPS URL")
+
+	// dynamically registered clients can't make the server fetch their keys
+	dynamic := *params
+	dynamic.GrantTypes = []string{"authorization_code"}
+	dynamic.RedirectURIs = []string{"https://example.com/callback"}
+	dynamic.JWKSURI = "https://example.com/jwks.json"
+	dynamic.RegistrationType = "dynamic"
+	_, _, err = ts.Server.registerOAuthServerClient(ctx, &dynamic)
+	require.Error(ts.T(), err)
+	assert.Contains(ts.T(), err.Error(), "must register their keys with jwks")
+
+	// private keys must never be registered
+	privateKey, err := ecdsa.GenerateKey(elliptic.P256(), rand.Reader)
+	require.NoError(ts.T(), err)
+	key, err := jwk.FromRaw(privateKey)
+	require.NoError(ts.T(), err)
+	set := jwk.NewSet()
+	require.NoError(ts.T(), set.AddKey(key))
+	jwks, err := json.Marshal(set)
+	require.NoError(ts.T(), err)
+
+	params.JWKSURI = ""
+	params.JWKS = jwks
+	_, _, err = ts.Server.registerOAuthServerClient(ctx, params)
+	require.Error(ts.T(), err)
+	assert.Contains(ts.T(), err.Error(), "jwks must only contain asymmetric public keys")
+
+	params.TokenEndpointAuthMethod = ""
+	_, _, err = ts.Server.registerOAuthServerClient(ctx, params)
+	require.Error(ts.T(), err)
+	assert.Contains(ts.T(), err.Error(), "can only be used with the private_key_jwt")
+}
+
+func TestDialPublicAddressesOnly(t *testing.T) {
+	for _, address := range []string{"127.0.0.1:443", "[::1]:443", "10.0.0.1:443", "192.168.1.1:443", "169.254.169.254:80", "[fe80::1]:443", "0.0.0.0:443"} {
+		require.Error(t, dialPublicAddressesOnly("tcp", address, nil), address)
+	}
+
+	for _, address := range []string{"93.184.216.34:443", "[2606:2800:220:1:248:1893:25c8:1946]:443"} {
+		require.NoError(t, dialPublicAddressesOnly("tcp", address, nil), address)
+	}
+}
+
+func TestClientJWKSCache(t *testing.T) {
+	cache := newClientJWKSCache()
+	keys := jwk.NewSet()
+	now := time.Now()
+
+	for i := 0; i < maxCachedJWKS; i++ {
+		cache.entries[fmt.Sprintf("https://client%d.example.com/jwks", i)] = &cachedJWKS{
+			keys:      keys,
+			expiresAt: now.Add(time.Duration(i) * time.Second),
+		}
+	}
+
+	// cached keys are returned without fetching them
+	cached, err := cache.get(context.Background(), "https://client999.example.com/jwks")
+	require.NoError(t, err)
+	assert.Equal(t, keys, cached)
+
+	// the entry expiring soonest makes room for new ones
+	cache.mutex.Lock()
+	cache.evict(now)
+	cache.mutex.Unlock()
+	assert.Len(t, cache.entries, maxCachedJWKS-1)
+	assert.NotContains(t, cache.entries, "https://client0.example.com/jwks")
+
+	// expired entries are all removed
+	cache.mutex.Lock()
+	cache.evict(now.Add(time.Duration(maxCachedJWKS/2) * time.Second))
+	cache.mutex.Unlock()
+	assert.Len(t, cache.entries, maxCachedJWKS/2)
+
+	cache.invalidate("https://client999.example.com/jwks")
+	assert.NotContains(t, cache.entries, "https://client999.example.com/jwks")
+}
+
+func TestExtractClientAssertion(t *testing.T) {
+	newRequest := func(form url.Values) *http.Request {
+		req := httptest.NewRequest(http.MethodPost, "/token", strings.NewReader(form.Encode()))
+		req.Header.Set("Content-Type", "application/x-www-form-urlencoded")
+		return req
+	}
+
+	clientID, assertion, err := ExtractClientAssertion(newRequest(url.Values{
+		"client_id": {"client"},
+	}))
+	require.NoError(t, err)
+	assert.Empty(t, clientID)
+	assert.Empty(t, assertion)
+
+	clientID, assertion, err = ExtractClientAssertion(newRequest(url.Values{
+		"client_id":             {"client"},
+		"client_assertion_type": {ClientAssertionTypeJWTBearer},
+		"client_assertion":      {"assertion"},
+	}))
+	require.NoError(t, err)
+	assert.Equal(t, "client", clientID)
+	assert.Equal(t, "assertion", assertion)
+
+	_, _, err = ExtractClientAssertion(newRequest(url.Values{
+		"client_assertion_type": {"urn:example:unknown"},
+		"client_assertion":      {"assertion"},
+	}))
+	require.Error(t, err)
+
+	_, _, err = ExtractClientAssertion(newRequest(url.Values{
+		"client_assertion_type": {ClientAssertionTypeJWTBearer},
+	}))
+	require.Error(t, err)
+}
+
+func (ts *OAuthServiceTestSuite) TestUpdatePrivateKeyJWTClientToClientSecret() {
+	client, _ := ts.createPrivateKeyJWTClient()
+
+	secret, err := ts.Server.updateOAuthServerClient(context.Background(), client, &OAuthServerClientRegisterParams{
+		ClientName:              "Test Service",
+		GrantTypes:              []string{"client_credentials"},
+		TokenEndpointAuthMethod: "client_secret_basic",
+	})
+	require.NoError(ts.T(), err)
+	require.NotEmpty(ts.T(), secret, "clients switching to a secret based method must be issued a secret")
+
+	updated, err := ts.Server.getOAuthServerClient(context.Background(), client.ClientID)
+	require.NoError(ts.T(), err)
+	assert.False(ts.T(), updated.UsesPrivateKeyJWT())
+	assert.True(ts.T(), ValidateOAuthServerClientSecret(updated, secret))
+
+	// updates that keep the authentication method don't issue a new secret
+	secret, err = ts.Server.updateOAuthServerClient(context.Background(), updated, &OAuthServerClientRegisterParams{
+		ClientName:              "Renamed Service",
+		GrantTypes:              []string{"client_credentials"},
+		TokenEndpointAuthMethod: "client_secret_basic",
+	})
+	require.NoError(ts.T(), err)
+	assert.Empty(ts.T(), secret)
+}
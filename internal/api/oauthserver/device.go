@@ -0,0 +1,223 @@
+package oauthserver
+
+import (
+	"encoding/json"
+	"net/http"
+	"net/url"
+	"strings"
+
+	"github.com/go-chi/chi/v5"
+	"github.com/supabase/auth/internal/api/apierrors"
+	"github.com/supabase/auth/internal/api/shared"
+	"github.com/supabase/auth/internal/models"
+	"github.com/supabase/auth/internal/storage"
+)
+
+// DeviceCodeGrantType is the grant type used by devices to poll the token
+// endpoint with a device code (RFC 8628)
+const DeviceCodeGrantType = "urn:ietf:params:oauth:grant-type:device_code"
+
+// DeviceAuthorizationResponse is the RFC 8628 device authorization response
+type DeviceAuthorizationResponse struct {
+	DeviceCode              string `json:"device_code"`
+	UserCode                string `json:"user_code"`
+	VerificationURI         string `json:"verification_uri"`
+	VerificationURIComplete string `json:"verification_uri_complete"`
+	ExpiresIn               int    `json:"expires_in"`
+	Interval                int    `json:"interval"`
+}
+
+// DeviceAuthorizationDetailsResponse describes a pending device authorization
+// request to the verification UI
+type DeviceAuthorizationDetailsResponse struct {
+	UserCode string                    `json:"user_code"`
+	Scope    string                    `json:"scope"`
+	Client   AuthorizationClientDetail `json:"client"`
+	User     AuthorizationUserDetail   `json:"user"`
+}
+
+// DeviceConsentResponse reports the outcome of the user's decision
+type DeviceConsentResponse struct {
+	Status models.OAuthServerAuthorizationStatus `json:"status"`
+}
+
+// buildDeviceVerificationURLs returns the URL users are asked to visit to
+// enter the user code, and the same URL with the user code filled in.
+func (s *Server) buildDeviceVerificationURLs(userCode string) (string, string, error) {
+	u, err := url.Parse(s.config.SiteURL)
+	if err != nil {
+		return "", "", err
+	}
+
+	u.Path = strings.TrimSuffix(u.Path, "/") + "/" + strings.TrimPrefix(s.config.OAuthServer.DeviceVerificationPath, "/")
+	verificationURI := u.String()
+
+	q := u.Query()
+	q.Set("user_code", userCode)
+	u.RawQuery = q.Encode()
+
+	return verificationURI, u.String(), nil
+}
+
+// OAuthServerDeviceAuthorize handles POST /oauth/device/code (RFC 8628 device
+// authorization endpoint). The client must have been authenticated by the
+// oauthClientAuth middleware.
+func (s *Server) OAuthServerDeviceAuthorize(w http.ResponseWriter, r *http.Request) error {
+	ctx := r.Context()
+	db := s.db.WithContext(ctx)
+	config := s.config
+
+	client := GetOAuthServerClient(ctx)
+	if client == nil {
+		return apierrors.NewOAuthError("invalid_client", "Client authentication is required")
+	}
+
+	if !client.HasGrantType(DeviceCodeGrantType) {
+		return apierrors.NewOAuthError("unauthorized_client", "Client is not allowed to use the device authorization grant")
+	}
+
+	scope, oauthErr := resolveRequestedScope(client, r.FormValue("scope"))
+	if oauthErr != nil {
+		return oauthErr
+	}
+
+	authorization := models.NewOAuthServerDeviceAuthorization(client, scope, config.OAuthServer.DeviceCodeTTL, config.OAuthServer.DevicePollingInterval)
+	if err := db.Create(authorization); err != nil {
+		return apierrors.NewInternalServerError("Error creating OAuth device authorization").WithInternalError(err)
+	}
+
+	verificationURI, verificationURIComplete, err := s.buildDeviceVerificationURLs(authorization.UserCode)
+	if err != nil {
+		return apierrors.NewInternalServerError("Error building verification URL").WithInternalError(err)
+	}
+
+	w.Header().Set("Cache-Control", "no-store")
+	return shared.SendJSON(w, http.StatusOK, &DeviceAuthorizationResponse{
+		DeviceCode:              authorization.DeviceCode,
+		UserCode:                authorization.UserCode,
+		VerificationURI:         verificationURI,
+		VerificationURIComplete: verificationURIComplete,
+		ExpiresIn:               int(config.OAuthServer.DeviceCodeTTL.Seconds()),
+		Interval:                authorization.PollingInterval,
+	})
+}
+
+// loadPendingDeviceAuthorization finds the pending device authorization
+// request for the user code in the URL.
+func (s *Server) loadPendingDeviceAuthorization(tx *storage.Connection, r *http.Request) (*models.OAuthServerDeviceAuthorization, *models.OAuthServerClient, error) {
+	userCode := chi.URLParam(r, "user_code")
+	if userCode == "" {
+		return nil, nil, apierrors.NewBadRequestError(apierrors.ErrorCodeValidationFailed, "user_code is required")
+	}
+
+	authorization, err := models.FindOAuthServerDeviceAuthorizationByUserCode(tx, userCode)
+	if err != nil {
+		if models.IsNotFoundError(err) {
+			return nil, nil, apierrors.NewNotFoundError(apierrors.ErrorCodeOAuthAuthorizationNotFound, "OAuth device authorization not found")
+		}
+		return nil, nil, apierrors.NewInternalServerError("Error loading OAuth device authorization").WithInternalError(err)
+	}
+
+	if !authorization.IsPending() || authorization.IsExpired() {
+		return nil, nil, apierrors.NewBadRequestError(apierrors.ErrorCodeOAuthAuthorizationExpired, "OAuth device authorization has expired or was already used")
+	}
+
+	client, err := models.FindOAuthServerClientByID(tx, authorization.ClientID)
+	if err != nil {
+		if models.IsNotFoundError(err) {
+			return nil, nil, apierrors.NewNotFoundError(apierrors.ErrorCodeOAuthClientNotFound, "OAuth client not found")
+		}
+		return nil, nil, apierrors.NewInternalServerError("Error loading OAuth client").WithInternalError(err)
+	}
+
+	return authorization, client, nil
+}
+
+// OAuthServerGetDeviceAuthorization handles GET /oauth/device/authorizations/{user_code}
+func (s *Server) OAuthServerGetDeviceAuthorization(w http.ResponseWriter, r *http.Request) error {
+	ctx := r.Context()
+	db := s.db.WithContext(ctx)
+	user := shared.GetUser(ctx)
+
+	authorization, client, err := s.loadPendingDeviceAuthorization(db, r)
+	if err != nil {
+		return err
+	}
+
+	return shared.SendJSON(w, http.StatusOK, &DeviceAuthorizationDetailsResponse{
+		UserCode: authorization.UserCode,
+		Scope:    authorization.Scope,
+		Client: AuthorizationClientDetail{
+			ClientID:   client.ClientID,
+			ClientName: client.ClientName.String(),
+			ClientURI:  client.ClientURI.String(),
+			LogoURI:    client.LogoURI.String(),
+		},
+		User: AuthorizationUserDetail{
+			ID:    user.ID.String(),
+			Email: user.GetEmail(),
+		},
+	})
+}
+
+// OAuthServerDeviceConsent handles POST /oauth/device/authorizations/{user_code}/consent
+//
+// Approving the request lets the device exchange its device code for tokens
+// on behalf of the user the next time it polls the token endpoint.
+func (s *Server) OAuthServerDeviceConsent(w http.ResponseWriter, r *http.Request) error {
+	ctx := r.Context()
+	db := s.db.WithContext(ctx)
+	user := shared.GetUser(ctx)
+
+	var params ConsentParams
+	if err := json.NewDecoder(r.Body).Decode(&params); err != nil {
+		return apierrors.NewBadRequestError(apierrors.ErrorCodeBadJSON, "Invalid JSON body")
+	}
+
+	if params.Action != "approve" && params.Action != "deny" {
+		return apierrors.NewBadRequestError(apierrors.ErrorCodeValidationFailed, "action must be 'approve' or 'deny'")
+	}
+
+	var authorization *models.OAuthServerDeviceAuthorization
+	err := db.Transaction(func(tx *storage.Connection) error {
+		var client *models.OAuthServerClient
+		var terr error
+
+		authorization, client, terr = s.loadPendingDeviceAuthorization(tx, r)
+		if terr != nil {
+			return terr
+		}
+
+		if params.Action == "deny" {
+			if terr := authorization.Deny(tx, user.ID); terr != nil {
+				return apierrors.NewInternalServerError("Error updating OAuth device authorization").WithInternalError(terr)
+			}
+			return nil
+		}
+
+		authentication, terr := consentAuthentication(ctx, user)
+		if terr != nil {
+			return terr
+		}
+
+		if terr := authorization.Approve(tx, user.ID, authentication); terr != nil {
+			return apierrors.NewInternalServerError("Error updating OAuth device authorization").WithInternalError(terr)
+		}
+
+		if _, terr := models.GrantOAuthServerConsent(tx, user.ID, client.ID, models.ParseOAuthScopes(authorization.Scope)); terr != nil {
+			return apierrors.NewInternalServerError("Error saving OAuth consent").WithInternalError(terr)
+		}
+
+		return models.NewAuditLogEntry(s.config.AuditLog, r, tx, user, models.OAuthConsentGrantedAction, "", map[string]interface{}{
+			"client_id": client.ClientID,
+			"scope":     authorization.Scope,
+		})
+	})
+	if err != nil {
+		return err
+	}
+
+	return shared.SendJSON(w, http.StatusOK, &DeviceConsentResponse{
+		Status: authorization.Status,
+	})
+}
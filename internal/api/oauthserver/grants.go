@@ -0,0 +1,122 @@
+package oauthserver
+
+import (
+	"net/http"
+	"time"
+
+	"github.com/go-chi/chi/v5"
+	"github.com/gofrs/uuid"
+	"github.com/supabase/auth/internal/api/apierrors"
+	"github.com/supabase/auth/internal/api/shared"
+	"github.com/supabase/auth/internal/models"
+	"github.com/supabase/auth/internal/storage"
+)
+
+// OAuthGrantResponse describes an OAuth client the user has authorized
+type OAuthGrantResponse struct {
+	Client    AuthorizationClientDetail `json:"client"`
+	Scopes    []string                  `json:"scopes"`
+	GrantedAt time.Time                 `json:"granted_at"`
+}
+
+// OAuthGrantListResponse represents the response for listing a user's OAuth grants
+type OAuthGrantListResponse struct {
+	Grants []OAuthGrantResponse `json:"grants"`
+}
+
+// UserOAuthGrantList handles GET /user/oauth/grants
+func (s *Server) UserOAuthGrantList(w http.ResponseWriter, r *http.Request) error {
+	ctx := r.Context()
+	db := s.db.WithContext(ctx)
+	user := shared.GetUser(ctx)
+
+	consents, err := models.FindActiveOAuthServerConsentsByUser(db, user.ID)
+	if err != nil {
+		return apierrors.NewInternalServerError("Error loading OAuth grants").WithInternalError(err)
+	}
+
+	clients, err := models.FindOAuthServerClientsWithActiveConsent(db, user.ID)
+	if err != nil {
+		return apierrors.NewInternalServerError("Error loading OAuth clients").WithInternalError(err)
+	}
+
+	clientsByID := make(map[uuid.UUID]*models.OAuthServerClient, len(clients))
+	for _, client := range clients {
+		clientsByID[client.ID] = client
+	}
+
+	grants := make([]OAuthGrantResponse, 0, len(consents))
+	for _, consent := range consents {
+		client, ok := clientsByID[consent.ClientID]
+		if !ok {
+			// deleted clients are not shown
+			continue
+		}
+
+		grants = append(grants, OAuthGrantResponse{
+			Client: AuthorizationClientDetail{
+				ClientID:   client.ClientID,
+				ClientName: client.ClientName.String(),
+				ClientURI:  client.ClientURI.String(),
+				LogoURI:    client.LogoURI.String(),
+			},
+			Scopes:    consent.GetScopes(),
+			GrantedAt: consent.GrantedAt,
+		})
+	}
+
+	return shared.SendJSON(w, http.StatusOK, &OAuthGrantListResponse{
+		Grants: grants,
+	})
+}
+
+// UserOAuthGrantRevoke handles DELETE /user/oauth/grants/{client_id}
+//
+// Revoking a grant also signs the user out of all sessions issued to the
+// client, which invalidates their refresh tokens.
+func (s *Server) UserOAuthGrantRevoke(w http.ResponseWriter, r *http.Request) error {
+	ctx := r.Context()
+	db := s.db.WithContext(ctx)
+	user := shared.GetUser(ctx)
+
+	clientID := chi.URLParam(r, "client_id")
+	if clientID == "" {
+		return apierrors.NewBadRequestError(apierrors.ErrorCodeValidationFailed, "client_id is required")
+	}
+
+	err := db.Transaction(func(tx *storage.Connection) error {
+		client, terr := models.FindOAuthServerClientByClientID(tx, clientID)
+		if terr != nil {
+			if models.IsNotFoundError(terr) {
+				return apierrors.NewNotFoundError(apierrors.ErrorCodeOAuthClientNotFound, "OAuth client not found")
+			}
+			return apierrors.NewInternalServerError("Error loading OAuth client").WithInternalError(terr)
+		}
+
+		consent, terr := models.FindActiveOAuthServerConsent(tx, user.ID, client.ID)
+		if terr != nil {
+			if models.IsNotFoundError(terr) {
+				return apierrors.NewNotFoundError(apierrors.ErrorCodeOAuthConsentNotFound, "No grant found for this OAuth client")
+			}
+			return apierrors.NewInternalServerError("Error loading OAuth grant").WithInternalError(terr)
+		}
+
+		if terr := consent.Revoke(tx); terr != nil {
+			return apierrors.NewInternalServerError("Error revoking OAuth grant").WithInternalError(terr)
+		}
+
+		if terr := models.LogoutOAuthClientSessions(tx, user.ID, client.ID); terr != nil {
+			return apierrors.NewInternalServerError("Error revoking OAuth client sessions").WithInternalError(terr)
+		}
+
+		return models.NewAuditLogEntry(s.config.AuditLog, r, tx, user, models.OAuthConsentRevokedAction, "", map[string]interface{}{
+			"client_id": client.ClientID,
+		})
+	})
+	if err != nil {
+		return err
+	}
+
+	w.WriteHeader(http.StatusNoContent)
+	return nil
+}
@@ -0,0 +1,93 @@
+package oauthserver
+
+import (
+	"context"
+	"encoding/json"
+	"net/http"
+	"net/http/httptest"
+	"net/url"
+
+	"github.com/go-chi/chi/v5"
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+	"github.com/supabase/auth/internal/api/shared"
+	"github.com/supabase/auth/internal/models"
+)
+
+func (ts *OAuthClientTestSuite) TestOAuthServerConsentSkippedWhenGranted() {
+	client, _ := ts.createTestOAuthClient()
+	user := ts.createTestUser("granted@example.com")
+
+	_, err := models.GrantOAuthServerConsent(ts.DB, user.ID, client.ID, []string{"email"})
+	require.NoError(ts.T(), err)
+
+	w := ts.authorize(client, url.Values{
+		"response_type":         {"code"},
+		"redirect_uri":          {"https://example.com/callback"},
+		"scope":                 {"email"},
+		"code_challenge":        {testCodeChallenge},
+		"code_challenge_method": {"S256"},
+	})
+	location, err := url.Parse(w.Header().Get("Location"))
+	require.NoError(ts.T(), err)
+	authorizationID := location.Query().Get("authorization_id")
+
+	w = httptest.NewRecorder()
+	require.NoError(ts.T(), ts.Server.OAuthServerGetAuthorization(w, ts.authorizationRequest(http.MethodGet, authorizationID, user, nil)))
+
+	var response ConsentResponse
+	require.NoError(ts.T(), json.Unmarshal(w.Body.Bytes(), &response))
+
+	redirectURL, err := url.Parse(response.RedirectURL)
+	require.NoError(ts.T(), err)
+	assert.NotEmpty(ts.T(), redirectURL.Query().Get("code"))
+}
+
+func (ts *OAuthClientTestSuite) TestUserOAuthGrants() {
+	client, _ := ts.createTestOAuthClient()
+	user := ts.createTestUser("grants@example.com")
+
+	_, err := models.GrantOAuthServerConsent(ts.DB, user.ID, client.ID, []string{"openid", "email"})
+	require.NoError(ts.T(), err)
+
+	_, err = models.GrantAuthenticatedUser(ts.DB, user, models.GrantParams{
+		OAuthClientID: &client.ID,
+	})
+	require.NoError(ts.T(), err)
+
+	firstPartyToken, err := models.GrantAuthenticatedUser(ts.DB, user, models.GrantParams{})
+	require.NoError(ts.T(), err)
+
+	// list grants
+	req := httptest.NewRequest(http.MethodGet, "/user/oauth/grants", nil)
+	req = req.WithContext(shared.WithUser(req.Context(), user))
+
+	w := httptest.NewRecorder()
+	require.NoError(ts.T(), ts.Server.UserOAuthGrantList(w, req))
+
+	var list OAuthGrantListResponse
+	require.NoError(ts.T(), json.Unmarshal(w.Body.Bytes(), &list))
+	require.Len(ts.T(), list.Grants, 1)
+	assert.Equal(ts.T(), client.ClientID, list.Grants[0].Client.ClientID)
+	assert.Equal(ts.T(), []string{"openid", "email"}, list.Grants[0].Scopes)
+
+	// revoke the grant
+	req = httptest.NewRequest(http.MethodDelete, "/user/oauth/grants/"+client.ClientID, nil)
+	rctx := chi.NewRouteContext()
+	rctx.URLParams.Add("client_id", client.ClientID)
+	req = req.WithContext(shared.WithUser(context.WithValue(req.Context(), chi.RouteCtxKey, rctx), user))
+
+	w = httptest.NewRecorder()
+	require.NoError(ts.T(), ts.Server.UserOAuthGrantRevoke(w, req))
+	assert.Equal(ts.T(), http.StatusNoContent, w.Code)
+
+	// only the first-party session remains
+	sessions, err := models.FindAllSessionsForUser(ts.DB, user.ID, false)
+	require.NoError(ts.T(), err)
+	require.Len(ts.T(), sessions, 1)
+	assert.Equal(ts.T(), *firstPartyToken.SessionId, sessions[0].ID)
+
+	// revoking again fails as there's no active grant
+	err = ts.Server.UserOAuthGrantRevoke(httptest.NewRecorder(), req)
+	require.Error(ts.T(), err)
+}
@@ -3,7 +3,10 @@ package oauthserver
 import (
 	"context"
 	"encoding/json"
+	"errors"
 	"net/http"
+	"net/url"
+	"strings"
 	"time"
 
 	"github.com/go-chi/chi/v5"
@@ -25,6 +28,21 @@ type OAuthServerClientResponse struct {
 	ClientName              string   `json:"client_name,omitempty"`
 	ClientURI               string   `json:"client_uri,omitempty"`
 	LogoURI                 string   `json:"logo_uri,omitempty"`
+	Scope                   string   `json:"scope,omitempty"`
+	Audience                string   `json:"audience,omitempty"`
+
+	// Keys of clients using private_key_jwt
+	JWKS    json.RawMessage `json:"jwks,omitempty"`
+	JWKSURI string          `json:"jwks_uri,omitempty"`
+
+	TokenExchangeAudiences []string `json:"token_exchange_audiences,omitempty"`
+
+	RequirePushedAuthorizationRequests bool `json:"require_pushed_authorization_requests"`
+
+	// RFC 7592 client configuration endpoint, only returned to dynamically
+	// registered clients
+	RegistrationAccessToken string `json:"registration_access_token,omitempty"`
+	RegistrationClientURI   string `json:"registration_client_uri,omitempty"`
 
 	// Metadata fields
 	RegistrationType string    `json:"registration_type"`
@@ -50,6 +68,12 @@ func oauthServerClientToResponse(client *models.OAuthServerClient, includeSecret
 		ClientName:              client.ClientName.String(),
 		ClientURI:               client.ClientURI.String(),
 		LogoURI:                 client.LogoURI.String(),
+		Scope:                   client.Scopes.String(),
+		Audience:                client.Audience.String(),
+		JWKSURI:                 client.JWKSURI.String(),
+		TokenExchangeAudiences:  client.GetTokenExchangeAudiences(),
+
+		RequirePushedAuthorizationRequests: client.RequirePushedAuthorizationRequests,
 
 		// Metadata fields
 		RegistrationType: client.RegistrationType,
@@ -57,6 +81,13 @@ func oauthServerClientToResponse(client *models.OAuthServerClient, includeSecret
 		UpdatedAt:        client.UpdatedAt,
 	}
 
+	if client.UsesPrivateKeyJWT() {
+		response.TokenEndpointAuthMethod = []string{models.PrivateKeyJWTAuthMethod}
+		if client.JWKS != "" {
+			response.JWKS = json.RawMessage(client.JWKS)
+		}
+	}
+
 	// Only include client_secret during registration
 	if includeSecret {
 		// Note: This will be filled in by the handler with the plaintext secret
@@ -89,6 +120,16 @@ func (s *Server) LoadOAuthServerClient(w http.ResponseWriter, r *http.Request) (
 	return ctx, nil
 }
 
+// oauthServerClientError returns validation errors of client registrations as
+// they are, and hides any other error behind an internal server error
+func oauthServerClientError(err error, message string) error {
+	var httpErr *apierrors.HTTPError
+	if errors.As(err, &httpErr) {
+		return httpErr
+	}
+	return apierrors.NewInternalServerError(message).WithInternalError(err)
+}
+
 // AdminOAuthServerClientRegister handles POST /admin/oauth/clients (manual registration by admins)
 func (s *Server) AdminOAuthServerClientRegister(w http.ResponseWriter, r *http.Request) error {
 	ctx := r.Context()
@@ -103,7 +144,7 @@ func (s *Server) AdminOAuthServerClientRegister(w http.ResponseWriter, r *http.R
 
 	client, plaintextSecret, err := s.registerOAuthServerClient(ctx, &params)
 	if err != nil {
-		return apierrors.NewBadRequestError(apierrors.ErrorCodeValidationFailed, err.Error())
+		return oauthServerClientError(err, "Error registering OAuth client")
 	}
 
 	response := oauthServerClientToResponse(client, true)
@@ -130,11 +171,18 @@ func (s *Server) OAuthServerClientDynamicRegister(w http.ResponseWriter, r *http
 
 	client, plaintextSecret, err := s.registerOAuthServerClient(ctx, &params)
 	if err != nil {
-		return apierrors.NewBadRequestError(apierrors.ErrorCodeValidationFailed, err.Error())
+		return oauthServerClientError(err, "Error registering OAuth client")
+	}
+
+	registrationAccessToken, err := s.issueRegistrationAccessToken(ctx, client)
+	if err != nil {
+		return apierrors.NewInternalServerError("Error issuing registration access token").WithInternalError(err)
 	}
 
 	response := oauthServerClientToResponse(client, true)
 	response.ClientSecret = plaintextSecret
+	response.RegistrationAccessToken = registrationAccessToken
+	response.RegistrationClientURI = s.registrationClientURI(client)
 
 	return shared.SendJSON(w, http.StatusCreated, response)
 }
@@ -148,7 +196,49 @@ func (s *Server) OAuthServerClientGet(w http.ResponseWriter, r *http.Request) er
 	return shared.SendJSON(w, http.StatusOK, response)
 }
 
-// OAuthServerClientDelete handles DELETE /admin/oauth/clients/{client_id}
+// OAuthServerClientUpdate handles PUT /admin/oauth/clients/{client_id}
+func (s *Server) OAuthServerClientUpdate(w http.ResponseWriter, r *http.Request) error {
+	ctx := r.Context()
+	client := GetOAuthServerClient(ctx)
+
+	var params OAuthServerClientRegisterParams
+	if err := json.NewDecoder(r.Body).Decode(&params); err != nil {
+		return apierrors.NewBadRequestError(apierrors.ErrorCodeBadJSON, "Invalid JSON body")
+	}
+
+	plaintextSecret, err := s.updateOAuthServerClient(ctx, client, &params)
+	if err != nil {
+		return oauthServerClientError(err, "Error updating OAuth client")
+	}
+
+	// only set when the client was switched to a secret based method
+	response := oauthServerClientToResponse(client, plaintextSecret != "")
+	response.ClientSecret = plaintextSecret
+	return shared.SendJSON(w, http.StatusOK, response)
+}
+
+// OAuthServerClientRotateSecret handles POST /admin/oauth/clients/{client_id}/rotate_secret
+func (s *Server) OAuthServerClientRotateSecret(w http.ResponseWriter, r *http.Request) error {
+	ctx := r.Context()
+	client := GetOAuthServerClient(ctx)
+
+	if client.UsesPrivateKeyJWT() {
+		return apierrors.NewBadRequestError(apierrors.ErrorCodeValidationFailed, "Clients using private_key_jwt don't have a client secret")
+	}
+
+	plaintextSecret, err := s.rotateOAuthServerClientSecret(ctx, client)
+	if err != nil {
+		return apierrors.NewInternalServerError("Error rotating OAuth client secret").WithInternalError(err)
+	}
+
+	response := oauthServerClientToResponse(client, true)
+	response.ClientSecret = plaintextSecret
+
+	return shared.SendJSON(w, http.StatusOK, response)
+}
+
+// OAuthServerClientDelete handles DELETE /admin/oauth/clients/{client_id} and
+// DELETE /oauth/clients/{client_id} (RFC 7592)
 func (s *Server) OAuthServerClientDelete(w http.ResponseWriter, r *http.Request) error {
 	ctx := r.Context()
 	client := GetOAuthServerClient(ctx)
@@ -182,3 +272,90 @@ func (s *Server) OAuthServerClientList(w http.ResponseWriter, r *http.Request) e
 
 	return shared.SendJSON(w, http.StatusOK, response)
 }
+
+// registrationClientURI returns the RFC 7592 client configuration endpoint
+func (s *Server) registrationClientURI(client *models.OAuthServerClient) string {
+	return strings.TrimSuffix(s.config.API.ExternalURL, "/") + "/oauth/clients/" + url.PathEscape(client.ClientID)
+}
+
+// LoadSelfManagedOAuthServerClient is middleware that loads the OAuth server
+// client from the URL parameter, authenticating the request with the client's
+// registration access token (RFC 7592).
+func (s *Server) LoadSelfManagedOAuthServerClient(w http.ResponseWriter, r *http.Request) (context.Context, error) {
+	ctx := r.Context()
+	clientID := chi.URLParam(r, "client_id")
+
+	observability.LogEntrySetField(r, "oauth_client_id", clientID)
+
+	// Unknown clients and invalid tokens are indistinguishable to callers
+	invalidTokenErr := apierrors.NewHTTPError(http.StatusUnauthorized, apierrors.ErrorCodeInvalidCredentials, "Invalid registration access token")
+
+	token := extractBearerToken(r)
+	if clientID == "" || token == "" {
+		return nil, invalidTokenErr
+	}
+
+	client, err := s.getOAuthServerClient(ctx, clientID)
+	if err != nil {
+		if models.IsNotFoundError(err) {
+			return nil, invalidTokenErr
+		}
+		return nil, apierrors.NewInternalServerError("Error loading OAuth client").WithInternalError(err)
+	}
+
+	if !ValidateRegistrationAccessToken(client, token) {
+		return nil, invalidTokenErr
+	}
+
+	ctx = WithOAuthServerClient(ctx, client)
+	return ctx, nil
+}
+
+// OAuthServerClientSelfGet handles GET /oauth/clients/{client_id} (RFC 7592)
+func (s *Server) OAuthServerClientSelfGet(w http.ResponseWriter, r *http.Request) error {
+	ctx := r.Context()
+	client := GetOAuthServerClient(ctx)
+
+	response := oauthServerClientToResponse(client, false)
+	response.RegistrationClientURI = s.registrationClientURI(client)
+
+	return shared.SendJSON(w, http.StatusOK, response)
+}
+
+// OAuthServerClientSelfUpdateParams are the parameters accepted when a
+// client updates its own registration. RFC 7592 requires the client_id to
+// be included. Clients can only change the metadata they could register
+// dynamically, everything else can only be changed by admins.
+type OAuthServerClientSelfUpdateParams struct {
+	ClientID string `json:"client_id"`
+
+	RedirectURIs []string `json:"redirect_uris"`
+	ClientName   string   `json:"client_name,omitempty"`
+	ClientURI    string   `json:"client_uri,omitempty"`
+	LogoURI      string   `json:"logo_uri,omitempty"`
+	Scope        string   `json:"scope,omitempty"`
+}
+
+// OAuthServerClientSelfUpdate handles PUT /oauth/clients/{client_id} (RFC 7592)
+func (s *Server) OAuthServerClientSelfUpdate(w http.ResponseWriter, r *http.Request) error {
+	ctx := r.Context()
+	client := GetOAuthServerClient(ctx)
+
+	var params OAuthServerClientSelfUpdateParams
+	if err := json.NewDecoder(r.Body).Decode(&params); err != nil {
+		return apierrors.NewBadRequestError(apierrors.ErrorCodeBadJSON, "Invalid JSON body")
+	}
+
+	if params.ClientID != client.ClientID {
+		return apierrors.NewBadRequestError(apierrors.ErrorCodeValidationFailed, "client_id must match the client being updated")
+	}
+
+	if err := s.updateOAuthServerClientMetadata(ctx, client, &params); err != nil {
+		return oauthServerClientError(err, "Error updating OAuth client")
+	}
+
+	response := oauthServerClientToResponse(client, false)
+	response.RegistrationClientURI = s.registrationClientURI(client)
+
+	return shared.SendJSON(w, http.StatusOK, response)
+}
@@ -7,7 +7,9 @@ import (
 	"net/http"
 	"net/http/httptest"
 	"testing"
+	"time"
 
+	"github.com/go-chi/chi/v5"
 	"github.com/stretchr/testify/assert"
 	"github.com/stretchr/testify/require"
 	"github.com/stretchr/testify/suite"
@@ -140,6 +142,91 @@ func (ts *OAuthClientTestSuite) TestOAuthServerClientDynamicRegisterHandler() {
 	assert.Equal(ts.T(), "dynamic", response.RegistrationType) // Dynamic registration
 }
 
+func (ts *OAuthClientTestSuite) selfManagementRequest(method, clientID, token string, body []byte) (*http.Request, error) {
+	req := httptest.NewRequest(method, "/oauth/clients/"+clientID, bytes.NewReader(body))
+	req.Header.Set("Authorization", "Bearer "+token)
+
+	rctx := chi.NewRouteContext()
+	rctx.URLParams.Add("client_id", clientID)
+	req = req.WithContext(context.WithValue(req.Context(), chi.RouteCtxKey, rctx))
+
+	ctx, err := ts.Server.LoadSelfManagedOAuthServerClient(httptest.NewRecorder(), req)
+	if err != nil {
+		return nil, err
+	}
+	return req.WithContext(ctx), nil
+}
+
+func (ts *OAuthClientTestSuite) TestOAuthServerClientSelfManagement() {
+	body, err := json.Marshal(OAuthServerClientRegisterParams{
+		ClientName:   "Self Managed Client",
+		RedirectURIs: []string{"https://app.example.com/callback"},
+	})
+	require.NoError(ts.T(), err)
+
+	w := httptest.NewRecorder()
+	require.NoError(ts.T(), ts.Server.OAuthServerClientDynamicRegister(w, httptest.NewRequest(http.MethodPost, "/oauth/clients/register", bytes.NewReader(body))))
+
+	var registration OAuthServerClientResponse
+	require.NoError(ts.T(), json.Unmarshal(w.Body.Bytes(), &registration))
+	require.NotEmpty(ts.T(), registration.RegistrationAccessToken)
+	assert.Contains(ts.T(), registration.RegistrationClientURI, "/oauth/clients/"+registration.ClientID)
+
+	// the registration access token is required
+	_, err = ts.selfManagementRequest(http.MethodGet, registration.ClientID, "invalid", nil)
+	require.Error(ts.T(), err)
+
+	// clients registered by admins can't manage themselves
+	manualClient, _ := ts.createTestOAuthClient()
+	_, err = ts.selfManagementRequest(http.MethodGet, manualClient.ClientID, registration.RegistrationAccessToken, nil)
+	require.Error(ts.T(), err)
+
+	req, err := ts.selfManagementRequest(http.MethodGet, registration.ClientID, registration.RegistrationAccessToken, nil)
+	require.NoError(ts.T(), err)
+	w = httptest.NewRecorder()
+	require.NoError(ts.T(), ts.Server.OAuthServerClientSelfGet(w, req))
+
+	var response OAuthServerClientResponse
+	require.NoError(ts.T(), json.Unmarshal(w.Body.Bytes(), &response))
+	assert.Equal(ts.T(), "Self Managed Client", response.ClientName)
+	assert.Empty(ts.T(), response.ClientSecret)
+
+	// only the client's metadata can be changed, privileged fields are ignored
+	body, err = json.Marshal(map[string]interface{}{
+		"client_id":                  registration.ClientID,
+		"client_name":                "Renamed Client",
+		"redirect_uris":              []string{"https://app.example.com/callback"},
+		"grant_types":                []string{"client_credentials"},
+		"audience":                   "https://api.example.com",
+		"token_endpoint_auth_method": "client_secret_post",
+	})
+	require.NoError(ts.T(), err)
+	req, err = ts.selfManagementRequest(http.MethodPut, registration.ClientID, registration.RegistrationAccessToken, body)
+	require.NoError(ts.T(), err)
+	w = httptest.NewRecorder()
+	require.NoError(ts.T(), ts.Server.OAuthServerClientSelfUpdate(w, req))
+	require.NoError(ts.T(), json.Unmarshal(w.Body.Bytes(), &response))
+	assert.Equal(ts.T(), "Renamed Client", response.ClientName)
+	assert.Equal(ts.T(), []string{"https://app.example.com/callback"}, response.RedirectURIs)
+	assert.Equal(ts.T(), registration.GrantTypes, response.GrantTypes)
+	assert.Empty(ts.T(), response.Audience)
+
+	updated, err := ts.Server.getOAuthServerClient(context.Background(), registration.ClientID)
+	require.NoError(ts.T(), err)
+	assert.False(ts.T(), updated.HasGrantType("client_credentials"))
+	assert.Empty(ts.T(), updated.Audience.String())
+	assert.Empty(ts.T(), updated.TokenEndpointAuthMethod.String())
+
+	req, err = ts.selfManagementRequest(http.MethodDelete, registration.ClientID, registration.RegistrationAccessToken, nil)
+	require.NoError(ts.T(), err)
+	w = httptest.NewRecorder()
+	require.NoError(ts.T(), ts.Server.OAuthServerClientDelete(w, req))
+	assert.Equal(ts.T(), http.StatusNoContent, w.Code)
+
+	_, err = ts.selfManagementRequest(http.MethodGet, registration.ClientID, registration.RegistrationAccessToken, nil)
+	require.Error(ts.T(), err)
+}
+
 func (ts *OAuthClientTestSuite) TestOAuthServerClientDynamicRegisterDisabled() {
 	// Disable dynamic registration
 	ts.Config.OAuthServer.AllowDynamicRegistration = false
@@ -212,6 +299,92 @@ func (ts *OAuthClientTestSuite) TestOAuthServerClientDeleteHandler() {
 	assert.Nil(ts.T(), deletedClient)
 }
 
+func (ts *OAuthClientTestSuite) TestOAuthServerClientUpdateHandler() {
+	client, _ := ts.createTestOAuthClient()
+
+	cases := []struct {
+		desc         string
+		params       OAuthServerClientRegisterParams
+		expectError  string
+		expectedName string
+	}{
+		{
+			desc: "Valid update",
+			params: OAuthServerClientRegisterParams{
+				ClientName:   "Updated Client",
+				RedirectURIs: []string{"https://example.com/new-callback"},
+				LogoURI:      "https://example.com/logo.png",
+			},
+			expectedName: "Updated Client",
+		},
+		{
+			desc: "Invalid redirect URI",
+			params: OAuthServerClientRegisterParams{
+				ClientName:   "Invalid Client",
+				RedirectURIs: []string{"invalid-uri"},
+			},
+			expectError: "invalid redirect_uri",
+		},
+	}
+
+	for _, c := range cases {
+		ts.Run(c.desc, func() {
+			body, err := json.Marshal(c.params)
+			require.NoError(ts.T(), err)
+
+			req := httptest.NewRequest(http.MethodPut, "/admin/oauth/clients/"+client.ClientID, bytes.NewReader(body))
+			req = req.WithContext(WithOAuthServerClient(req.Context(), client))
+
+			w := httptest.NewRecorder()
+			err = ts.Server.OAuthServerClientUpdate(w, req)
+			if c.expectError != "" {
+				require.Error(ts.T(), err)
+				assert.Contains(ts.T(), err.Error(), c.expectError)
+				return
+			}
+			require.NoError(ts.T(), err)
+
+			var response OAuthServerClientResponse
+			require.NoError(ts.T(), json.Unmarshal(w.Body.Bytes(), &response))
+			assert.Equal(ts.T(), c.expectedName, response.ClientName)
+			assert.Equal(ts.T(), c.params.RedirectURIs, response.RedirectURIs)
+			assert.Equal(ts.T(), "dynamic", response.RegistrationType)
+		})
+	}
+
+	updated, err := ts.Server.getOAuthServerClient(context.Background(), client.ClientID)
+	require.NoError(ts.T(), err)
+	assert.Equal(ts.T(), "Updated Client", updated.ClientName.String())
+}
+
+func (ts *OAuthClientTestSuite) TestOAuthServerClientRotateSecretHandler() {
+	client, oldSecret := ts.createTestOAuthClient()
+
+	req := httptest.NewRequest(http.MethodPost, "/admin/oauth/clients/"+client.ClientID+"/rotate_secret", nil)
+	req = req.WithContext(WithOAuthServerClient(req.Context(), client))
+
+	w := httptest.NewRecorder()
+	require.NoError(ts.T(), ts.Server.OAuthServerClientRotateSecret(w, req))
+
+	var response OAuthServerClientResponse
+	require.NoError(ts.T(), json.Unmarshal(w.Body.Bytes(), &response))
+	require.NotEmpty(ts.T(), response.ClientSecret)
+	assert.NotEqual(ts.T(), oldSecret, response.ClientSecret)
+
+	rotated, err := ts.Server.getOAuthServerClient(context.Background(), client.ClientID)
+	require.NoError(ts.T(), err)
+
+	// both secrets are valid during the overlap window
+	assert.True(ts.T(), ValidateOAuthServerClientSecret(rotated, response.ClientSecret))
+	assert.True(ts.T(), ValidateOAuthServerClientSecret(rotated, oldSecret))
+
+	// the previous secret is rejected once the overlap window has passed
+	expired := time.Now().Add(-time.Second)
+	rotated.PreviousClientSecretExpiresAt = &expired
+	assert.True(ts.T(), ValidateOAuthServerClientSecret(rotated, response.ClientSecret))
+	assert.False(ts.T(), ValidateOAuthServerClientSecret(rotated, oldSecret))
+}
+
 func (ts *OAuthClientTestSuite) TestOAuthServerClientListHandler() {
 	// Create a couple test clients first
 	client1, _ := ts.createTestOAuthClient()
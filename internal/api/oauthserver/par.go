@@ -0,0 +1,130 @@
+package oauthserver
+
+import (
+	"context"
+	"net/http"
+	"time"
+
+	"github.com/supabase/auth/internal/api/apierrors"
+	"github.com/supabase/auth/internal/api/shared"
+	"github.com/supabase/auth/internal/models"
+	"github.com/supabase/auth/internal/storage"
+)
+
+// PushedAuthorizationResponse is the RFC 9126 pushed authorization response
+type PushedAuthorizationResponse struct {
+	RequestURI string `json:"request_uri"`
+	ExpiresIn  int    `json:"expires_in"`
+}
+
+// OAuthServerPushedAuthorizationRequest handles POST /oauth/par (RFC 9126
+// pushed authorization request endpoint). The client must have been
+// authenticated by the oauthClientAuth middleware.
+//
+// The authorization parameters are validated right away and stored, and the
+// returned request_uri is then used in place of them on /oauth/authorize.
+func (s *Server) OAuthServerPushedAuthorizationRequest(w http.ResponseWriter, r *http.Request) error {
+	ctx := r.Context()
+	db := s.db.WithContext(ctx)
+	config := s.config
+
+	client := GetOAuthServerClient(ctx)
+	if client == nil {
+		return apierrors.NewOAuthError("invalid_client", "Client authentication is required")
+	}
+
+	if !client.HasGrantType("authorization_code") {
+		return apierrors.NewOAuthError("unauthorized_client", "Client is not allowed to use the authorization_code grant")
+	}
+
+	if err := r.ParseForm(); err != nil {
+		return apierrors.NewOAuthError("invalid_request", "Invalid form body")
+	}
+	form := r.PostForm
+
+	if form.Has("request_uri") {
+		return apierrors.NewOAuthError("invalid_request", "request_uri can't be pushed")
+	}
+
+	params := authorizeParamsFromValues(form)
+	if params.ClientID != "" && params.ClientID != client.ClientID {
+		return apierrors.NewOAuthError("invalid_request", "client_id does not match the authenticated client")
+	}
+
+	// only the redirect_uri pushed by the client is stored, as it must also
+	// be sent to the token endpoint when it was part of the request
+	if _, ok := resolveRedirectURI(client, params.RedirectURI); !ok {
+		return apierrors.NewOAuthError("invalid_request", "redirect_uri is missing or not registered for this client")
+	}
+
+	if params.ResponseType != "code" {
+		return apierrors.NewOAuthError("unsupported_response_type", "response_type must be 'code'")
+	}
+
+	if _, oauthErr := validateCodeChallenge(params.CodeChallenge, params.CodeChallengeMethod); oauthErr != nil {
+		return oauthErr
+	}
+
+	if _, oauthErr := resolveRequestedScope(client, params.Scope); oauthErr != nil {
+		return oauthErr
+	}
+
+	// client credentials are never stored with the pushed parameters
+	form.Del("client_secret")
+	form.Del("client_assertion")
+	form.Del("client_assertion_type")
+	form.Set("client_id", client.ClientID)
+
+	request := models.NewOAuthServerPushedAuthorizationRequest(client, form, config.OAuthServer.PushedAuthorizationRequestTTL)
+	if err := db.Create(request); err != nil {
+		return apierrors.NewInternalServerError("Error creating OAuth pushed authorization request").WithInternalError(err)
+	}
+
+	w.Header().Set("Cache-Control", "no-store")
+	return shared.SendJSON(w, http.StatusCreated, &PushedAuthorizationResponse{
+		RequestURI: request.RequestURI,
+		ExpiresIn:  int(time.Until(request.ExpiresAt).Seconds()),
+	})
+}
+
+// loadPushedAuthorizeParams returns the authorization parameters pushed by
+// the client for the request_uri. Each request_uri can only be used once, and
+// is only consumed when it was pushed by the client and hasn't expired.
+func (s *Server) loadPushedAuthorizeParams(ctx context.Context, client *models.OAuthServerClient, requestURI string) (*AuthorizeParams, error) {
+	db := s.db.WithContext(ctx)
+
+	var request *models.OAuthServerPushedAuthorizationRequest
+	err := db.Transaction(func(tx *storage.Connection) error {
+		var terr error
+		request, terr = models.FindOAuthServerPushedAuthorizationRequestForUpdate(tx, requestURI)
+		if terr != nil {
+			if models.IsNotFoundError(terr) {
+				return apierrors.NewBadRequestError(apierrors.ErrorCodeValidationFailed, "Invalid request_uri")
+			}
+			return apierrors.NewInternalServerError("Error loading OAuth pushed authorization request").WithInternalError(terr)
+		}
+
+		if request.ClientID != client.ID {
+			return apierrors.NewBadRequestError(apierrors.ErrorCodeValidationFailed, "Invalid request_uri")
+		}
+
+		if request.IsExpired() {
+			return apierrors.NewBadRequestError(apierrors.ErrorCodeValidationFailed, "request_uri has expired")
+		}
+
+		if terr := request.Consume(tx); terr != nil {
+			return apierrors.NewInternalServerError("Error loading OAuth pushed authorization request").WithInternalError(terr)
+		}
+		return nil
+	})
+	if err != nil {
+		return nil, err
+	}
+
+	values, err := request.GetParameters()
+	if err != nil {
+		return nil, apierrors.NewInternalServerError("Error loading OAuth pushed authorization request").WithInternalError(err)
+	}
+
+	return authorizeParamsFromValues(values), nil
+}
@@ -0,0 +1,127 @@
+package oauthserver
+
+import (
+	"encoding/json"
+	"net/http"
+	"net/http/httptest"
+	"net/url"
+	"strings"
+
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+	"github.com/supabase/auth/internal/models"
+)
+
+func (ts *OAuthClientTestSuite) pushAuthorizationRequest(client *models.OAuthServerClient, form url.Values) *httptest.ResponseRecorder {
+	req := httptest.NewRequest(http.MethodPost, "/oauth/par", strings.NewReader(form.Encode()))
+	req.Header.Set("Content-Type", "application/x-www-form-urlencoded")
+	req = req.WithContext(WithOAuthServerClient(req.Context(), client))
+
+	w := httptest.NewRecorder()
+	if err := ts.Server.OAuthServerPushedAuthorizationRequest(w, req); err != nil {
+		w.Code = http.StatusBadRequest
+	}
+	return w
+}
+
+func (ts *OAuthClientTestSuite) TestOAuthServerPushedAuthorizationRequest() {
+	client, _ := ts.createTestOAuthClient()
+
+	w := ts.pushAuthorizationRequest(client, url.Values{
+		"response_type":         {"code"},
+		"redirect_uri":          {"https://example.com/callback"},
+		"code_challenge":        {testCodeChallenge},
+		"code_challenge_method": {"S256"},
+		"state":                 {"pushed-state"},
+	})
+	require.Equal(ts.T(), http.StatusCreated, w.Code)
+
+	var response PushedAuthorizationResponse
+	require.NoError(ts.T(), json.NewDecoder(w.Body).Decode(&response))
+	assert.True(ts.T(), strings.HasPrefix(response.RequestURI, models.PushedAuthorizationRequestURIPrefix))
+	assert.Greater(ts.T(), response.ExpiresIn, 0)
+
+	// other clients can't use, or use up, the request_uri
+	other, _ := ts.createTestOAuthClient()
+	req := httptest.NewRequest(http.MethodGet, "/oauth/authorize?"+url.Values{
+		"client_id":   {other.ClientID},
+		"request_uri": {response.RequestURI},
+	}.Encode(), nil)
+	require.Error(ts.T(), ts.Server.OAuthServerAuthorize(httptest.NewRecorder(), req))
+
+	// the pushed parameters are used in place of the query
+	w = ts.authorize(client, url.Values{
+		"request_uri": {response.RequestURI},
+	})
+	require.Equal(ts.T(), http.StatusFound, w.Code)
+
+	location, err := url.Parse(w.Header().Get("Location"))
+	require.NoError(ts.T(), err)
+	authorization, err := models.FindOAuthServerAuthorizationByAuthorizationID(ts.DB, location.Query().Get("authorization_id"))
+	require.NoError(ts.T(), err)
+	assert.Equal(ts.T(), "pushed-state", authorization.State.String())
+	assert.True(ts.T(), authorization.RedirectURIProvided)
+
+	// each request_uri can only be used once
+	req = httptest.NewRequest(http.MethodGet, "/oauth/authorize?"+url.Values{
+		"client_id":   {client.ClientID},
+		"request_uri": {response.RequestURI},
+	}.Encode(), nil)
+	require.Error(ts.T(), ts.Server.OAuthServerAuthorize(httptest.NewRecorder(), req))
+
+	// invalid parameters are rejected when pushed
+	w = ts.pushAuthorizationRequest(client, url.Values{
+		"response_type": {"code"},
+		"redirect_uri":  {"https://evil.example.com/callback"},
+	})
+	assert.Equal(ts.T(), http.StatusBadRequest, w.Code)
+}
+
+func (ts *OAuthClientTestSuite) TestOAuthServerRequirePushedAuthorizationRequests() {
+	client, _ := ts.createTestOAuthClient()
+	client.RequirePushedAuthorizationRequests = true
+	require.NoError(ts.T(), models.UpdateOAuthServerClient(ts.DB, client))
+
+	req := httptest.NewRequest(http.MethodGet, "/oauth/authorize?"+url.Values{
+		"client_id":             {client.ClientID},
+		"response_type":         {"code"},
+		"redirect_uri":          {"https://example.com/callback"},
+		"code_challenge":        {testCodeChallenge},
+		"code_challenge_method": {"S256"},
+	}.Encode(), nil)
+	err := ts.Server.OAuthServerAuthorize(httptest.NewRecorder(), req)
+	require.Error(ts.T(), err)
+	assert.Contains(ts.T(), err.Error(), "pushed authorization requests")
+}
+
+func (ts *OAuthClientTestSuite) TestOAuthServerPushedAuthorizationRequestWithoutRedirectURI() {
+	// redirect_uri can only be omitted by clients with one registered
+	client, _ := ts.createTestOAuthClient()
+	client.SetRedirectURIs([]string{"https://example.com/callback"})
+	require.NoError(ts.T(), ts.DB.UpdateOnly(client, "redirect_uris"))
+
+	w := ts.pushAuthorizationRequest(client, url.Values{
+		"response_type":         {"code"},
+		"code_challenge":        {testCodeChallenge},
+		"code_challenge_method": {"S256"},
+	})
+	require.Equal(ts.T(), http.StatusCreated, w.Code)
+
+	var response PushedAuthorizationResponse
+	require.NoError(ts.T(), json.NewDecoder(w.Body).Decode(&response))
+
+	w = ts.authorize(client, url.Values{
+		"request_uri": {response.RequestURI},
+	})
+	require.Equal(ts.T(), http.StatusFound, w.Code)
+
+	location, err := url.Parse(w.Header().Get("Location"))
+	require.NoError(ts.T(), err)
+	authorization, err := models.FindOAuthServerAuthorizationByAuthorizationID(ts.DB, location.Query().Get("authorization_id"))
+	require.NoError(ts.T(), err)
+
+	// the registered redirect_uri is used, but isn't required at the token
+	// endpoint as the client didn't send it
+	assert.Equal(ts.T(), "https://example.com/callback", authorization.RedirectURI)
+	assert.False(ts.T(), authorization.RedirectURIProvided)
+}
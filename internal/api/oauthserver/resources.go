@@ -0,0 +1,240 @@
+package oauthserver
+
+import (
+	"context"
+	"encoding/json"
+	"net/http"
+	"net/url"
+	"time"
+
+	"github.com/go-chi/chi/v5"
+	"github.com/gofrs/uuid"
+	"github.com/supabase/auth/internal/api/apierrors"
+	"github.com/supabase/auth/internal/api/shared"
+	"github.com/supabase/auth/internal/models"
+	"github.com/supabase/auth/internal/storage"
+)
+
+// maxResourceURILength mirrors the length constraint of the oauth_resources table
+const maxResourceURILength = 2048
+
+// OAuthServerResourceParams are the parameters accepted when registering or
+// updating a resource
+type OAuthServerResourceParams struct {
+	Resource          string   `json:"resource"`
+	Name              string   `json:"name"`
+	AccessTokenExpiry *int     `json:"access_token_expiry"`
+	AllowedClientIDs  []string `json:"allowed_client_ids"`
+}
+
+// OAuthServerResourceResponse represents a registered resource
+type OAuthServerResourceResponse struct {
+	ID                uuid.UUID `json:"id"`
+	Resource          string    `json:"resource"`
+	Name              string    `json:"name,omitempty"`
+	AccessTokenExpiry *int      `json:"access_token_expiry,omitempty"`
+	AllowedClientIDs  []string  `json:"allowed_client_ids"`
+	CreatedAt         time.Time `json:"created_at"`
+	UpdatedAt         time.Time `json:"updated_at"`
+}
+
+// OAuthServerResourceListResponse represents the response for listing resources
+type OAuthServerResourceListResponse struct {
+	Resources []OAuthServerResourceResponse `json:"resources"`
+}
+
+func oauthServerResourceToResponse(resource *models.OAuthServerResource) *OAuthServerResourceResponse {
+	return &OAuthServerResourceResponse{
+		ID:                resource.ID,
+		Resource:          resource.Resource,
+		Name:              resource.Name.String(),
+		AccessTokenExpiry: resource.AccessTokenExpiry,
+		AllowedClientIDs:  resource.GetAllowedClientIDs(),
+		CreatedAt:         resource.CreatedAt,
+		UpdatedAt:         resource.UpdatedAt,
+	}
+}
+
+// ValidateResourceURI checks that the value is a valid RFC 8707 resource
+// indicator, an absolute URI without a fragment.
+func ValidateResourceURI(resource string) bool {
+	if resource == "" || len(resource) > maxResourceURILength {
+		return false
+	}
+
+	u, err := url.Parse(resource)
+	if err != nil {
+		return false
+	}
+
+	return u.IsAbs() && u.Fragment == "" && u.Host != ""
+}
+
+// validate validates the resource parameters
+func (p *OAuthServerResourceParams) validate(tx *storage.Connection) error {
+	if !ValidateResourceURI(p.Resource) {
+		return apierrors.NewBadRequestError(apierrors.ErrorCodeValidationFailed, "resource must be an absolute URI without a fragment")
+	}
+
+	if p.AccessTokenExpiry != nil && *p.AccessTokenExpiry <= 0 {
+		return apierrors.NewBadRequestError(apierrors.ErrorCodeValidationFailed, "access_token_expiry must be a positive number of seconds")
+	}
+
+	for _, clientID := range p.AllowedClientIDs {
+		if _, err := models.FindOAuthServerClientByClientID(tx, clientID); err != nil {
+			if models.IsNotFoundError(err) {
+				return apierrors.NewBadRequestError(apierrors.ErrorCodeOAuthClientNotFound, "OAuth client %q not found", clientID)
+			}
+			return apierrors.NewInternalServerError("Error loading OAuth client").WithInternalError(err)
+		}
+	}
+
+	return nil
+}
+
+func (p *OAuthServerResourceParams) apply(resource *models.OAuthServerResource) {
+	resource.Resource = p.Resource
+	resource.Name = storage.NullString(p.Name)
+	resource.AccessTokenExpiry = p.AccessTokenExpiry
+	resource.SetAllowedClientIDs(p.AllowedClientIDs)
+}
+
+// GetOAuthServerResource retrieves a resource from the context
+func GetOAuthServerResource(ctx context.Context) *models.OAuthServerResource {
+	obj := ctx.Value(oauthServerResourceKey)
+	if obj == nil {
+		return nil
+	}
+	return obj.(*models.OAuthServerResource)
+}
+
+// LoadOAuthServerResource is middleware that loads a resource from the URL parameter
+func (s *Server) LoadOAuthServerResource(w http.ResponseWriter, r *http.Request) (context.Context, error) {
+	ctx := r.Context()
+	db := s.db.WithContext(ctx)
+
+	resourceID, err := uuid.FromString(chi.URLParam(r, "resource_id"))
+	if err != nil {
+		return nil, apierrors.NewNotFoundError(apierrors.ErrorCodeOAuthResourceNotFound, "OAuth resource not found")
+	}
+
+	resource, err := models.FindOAuthServerResourceByID(db, resourceID)
+	if err != nil {
+		if models.IsNotFoundError(err) {
+			return nil, apierrors.NewNotFoundError(apierrors.ErrorCodeOAuthResourceNotFound, "OAuth resource not found")
+		}
+		return nil, apierrors.NewInternalServerError("Error loading OAuth resource").WithInternalError(err)
+	}
+
+	return context.WithValue(ctx, oauthServerResourceKey, resource), nil
+}
+
+// AdminOAuthServerResourceList handles GET /admin/oauth/resources
+func (s *Server) AdminOAuthServerResourceList(w http.ResponseWriter, r *http.Request) error {
+	ctx := r.Context()
+	db := s.db.WithContext(ctx)
+
+	resources, err := models.FindAllOAuthServerResources(db)
+	if err != nil {
+		return apierrors.NewInternalServerError("Error listing OAuth resources").WithInternalError(err)
+	}
+
+	responses := make([]OAuthServerResourceResponse, len(resources))
+	for i := range resources {
+		responses[i] = *oauthServerResourceToResponse(&resources[i])
+	}
+
+	return shared.SendJSON(w, http.StatusOK, &OAuthServerResourceListResponse{
+		Resources: responses,
+	})
+}
+
+// AdminOAuthServerResourceCreate handles POST /admin/oauth/resources
+func (s *Server) AdminOAuthServerResourceCreate(w http.ResponseWriter, r *http.Request) error {
+	ctx := r.Context()
+	db := s.db.WithContext(ctx)
+
+	var params OAuthServerResourceParams
+	if err := json.NewDecoder(r.Body).Decode(&params); err != nil {
+		return apierrors.NewBadRequestError(apierrors.ErrorCodeBadJSON, "Invalid JSON body")
+	}
+
+	resource := models.NewOAuthServerResource(params.Resource)
+	err := db.Transaction(func(tx *storage.Connection) error {
+		if terr := params.validate(tx); terr != nil {
+			return terr
+		}
+
+		if _, terr := models.FindOAuthServerResourceByResource(tx, params.Resource); terr == nil {
+			return apierrors.NewBadRequestError(apierrors.ErrorCodeValidationFailed, "A resource with this URI is already registered")
+		} else if !models.IsNotFoundError(terr) {
+			return apierrors.NewInternalServerError("Error loading OAuth resource").WithInternalError(terr)
+		}
+
+		params.apply(resource)
+		if terr := tx.Create(resource); terr != nil {
+			return apierrors.NewInternalServerError("Error creating OAuth resource").WithInternalError(terr)
+		}
+		return nil
+	})
+	if err != nil {
+		return err
+	}
+
+	return shared.SendJSON(w, http.StatusCreated, oauthServerResourceToResponse(resource))
+}
+
+// AdminOAuthServerResourceGet handles GET /admin/oauth/resources/{resource_id}
+func (s *Server) AdminOAuthServerResourceGet(w http.ResponseWriter, r *http.Request) error {
+	resource := GetOAuthServerResource(r.Context())
+	return shared.SendJSON(w, http.StatusOK, oauthServerResourceToResponse(resource))
+}
+
+// AdminOAuthServerResourceUpdate handles PUT /admin/oauth/resources/{resource_id}
+func (s *Server) AdminOAuthServerResourceUpdate(w http.ResponseWriter, r *http.Request) error {
+	ctx := r.Context()
+	db := s.db.WithContext(ctx)
+	resource := GetOAuthServerResource(ctx)
+
+	var params OAuthServerResourceParams
+	if err := json.NewDecoder(r.Body).Decode(&params); err != nil {
+		return apierrors.NewBadRequestError(apierrors.ErrorCodeBadJSON, "Invalid JSON body")
+	}
+
+	err := db.Transaction(func(tx *storage.Connection) error {
+		if terr := params.validate(tx); terr != nil {
+			return terr
+		}
+
+		if existing, terr := models.FindOAuthServerResourceByResource(tx, params.Resource); terr == nil && existing.ID != resource.ID {
+			return apierrors.NewBadRequestError(apierrors.ErrorCodeValidationFailed, "A resource with this URI is already registered")
+		} else if terr != nil && !models.IsNotFoundError(terr) {
+			return apierrors.NewInternalServerError("Error loading OAuth resource").WithInternalError(terr)
+		}
+
+		params.apply(resource)
+		if terr := tx.Update(resource); terr != nil {
+			return apierrors.NewInternalServerError("Error updating OAuth resource").WithInternalError(terr)
+		}
+		return nil
+	})
+	if err != nil {
+		return err
+	}
+
+	return shared.SendJSON(w, http.StatusOK, oauthServerResourceToResponse(resource))
+}
+
+// AdminOAuthServerResourceDelete handles DELETE /admin/oauth/resources/{resource_id}
+func (s *Server) AdminOAuthServerResourceDelete(w http.ResponseWriter, r *http.Request) error {
+	ctx := r.Context()
+	db := s.db.WithContext(ctx)
+	resource := GetOAuthServerResource(ctx)
+
+	if err := db.Destroy(resource); err != nil {
+		return apierrors.NewInternalServerError("Error deleting OAuth resource").WithInternalError(err)
+	}
+
+	w.WriteHeader(http.StatusNoContent)
+	return nil
+}
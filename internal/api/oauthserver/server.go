@@ -9,12 +9,17 @@ import (
 type Server struct {
 	config *conf.GlobalConfiguration
 	db     *storage.Connection
+
+	// jwksCache holds the keys fetched from the jwks_uri of private_key_jwt
+	// clients
+	jwksCache *clientJWKSCache
 }
 
 // NewServer creates a new OAuth server instance
 func NewServer(config *conf.GlobalConfiguration, db *storage.Connection) *Server {
 	return &Server{
-		config: config,
-		db:     db,
+		config:    config,
+		db:        db,
+		jwksCache: newClientJWKSCache(),
 	}
 }
@@ -2,10 +2,15 @@ package oauthserver
 
 import (
 	"context"
+	"crypto/subtle"
+	"encoding/json"
 	"fmt"
 	"net/url"
+	"slices"
+	"strings"
 	"time"
 
+	"github.com/lestrrat-go/jwx/v2/jwk"
 	"github.com/pkg/errors"
 	"github.com/supabase/auth/internal/api/apierrors"
 	"github.com/supabase/auth/internal/crypto"
@@ -14,6 +19,12 @@ import (
 	"golang.org/x/crypto/bcrypt"
 )
 
+// TokenExchangeGrantType is the RFC 8693 token exchange grant type
+const TokenExchangeGrantType = "urn:ietf:params:oauth:grant-type:token-exchange"
+
+// supportedGrantTypes are the grant types OAuth clients can be registered for
+var supportedGrantTypes = []string{"authorization_code", "refresh_token", "client_credentials", DeviceCodeGrantType, TokenExchangeGrantType}
+
 // OAuthServerClientRegisterParams contains parameters for registering a new OAuth client
 type OAuthServerClientRegisterParams struct {
 	// Required fields
@@ -24,14 +35,65 @@ type OAuthServerClientRegisterParams struct {
 	ClientURI  string   `json:"client_uri,omitempty"`
 	LogoURI    string   `json:"logo_uri,omitempty"`
 
+	// Scope are the scopes the client is allowed to request, and Audience is
+	// included in the access tokens issued through the client_credentials
+	// grant
+	Scope    string `json:"scope,omitempty"`
+	Audience string `json:"audience,omitempty"`
+
+	// Clients using private_key_jwt authentication register the public keys
+	// used to verify their client assertions instead of receiving a secret
+	TokenEndpointAuthMethod string          `json:"token_endpoint_auth_method,omitempty"`
+	JWKS                    json.RawMessage `json:"jwks,omitempty"`
+	JWKSURI                 string          `json:"jwks_uri,omitempty"`
+
+	// TokenExchangeAudiences are the audiences the client may exchange user
+	// access tokens for (RFC 8693)
+	TokenExchangeAudiences []string `json:"token_exchange_audiences,omitempty"`
+
+	// RequirePushedAuthorizationRequests requires the client to push its
+	// authorization parameters to /oauth/par before redirecting the user
+	RequirePushedAuthorizationRequests bool `json:"require_pushed_authorization_requests,omitempty"`
+
 	// Internal field
 	RegistrationType string `json:"-"`
 }
 
 // validate validates the OAuth client registration parameters
 func (p *OAuthServerClientRegisterParams) validate() error {
-	// Validate redirect URIs (required)
-	if len(p.RedirectURIs) == 0 {
+	for _, grantType := range p.GrantTypes {
+		if !slices.Contains(supportedGrantTypes, grantType) {
+			return apierrors.NewBadRequestError(apierrors.ErrorCodeValidationFailed, "grant_types must only contain 'authorization_code', 'refresh_token', 'client_credentials', '%s' and/or '%s'", DeviceCodeGrantType, TokenExchangeGrantType)
+		}
+	}
+
+	// only trusted clients registered by admins can exchange user tokens
+	if p.RegistrationType == "dynamic" && (slices.Contains(p.GrantTypes, TokenExchangeGrantType) || len(p.TokenExchangeAudiences) > 0) {
+		return apierrors.NewBadRequestError(apierrors.ErrorCodeValidationFailed, "Dynamically registered clients can't use token exchange")
+	}
+
+	// only trusted clients registered by admins can obtain tokens on their
+	// own behalf, for an audience of their choosing
+	if p.RegistrationType == "dynamic" && (slices.Contains(p.GrantTypes, "client_credentials") || p.Audience != "") {
+		return apierrors.NewBadRequestError(apierrors.ErrorCodeValidationFailed, "Dynamically registered clients can't use the client_credentials grant or set an audience")
+	}
+
+	// keys of dynamically registered clients must be registered inline, as
+	// fetching them from a jwks_uri of the client's choosing would let anyone
+	// make the server send requests to arbitrary URLs
+	if p.RegistrationType == "dynamic" && p.JWKSURI != "" {
+		return apierrors.NewBadRequestError(apierrors.ErrorCodeValidationFailed, "Dynamically registered clients must register their keys with jwks rather than jwks_uri")
+	}
+
+	for _, audience := range p.TokenExchangeAudiences {
+		if audience == "" || len(audience) > 2048 || strings.ContainsAny(audience, " \t\r\n") {
+			return apierrors.NewBadRequestError(apierrors.ErrorCodeValidationFailed, "token_exchange_audiences must be non-empty strings without whitespace")
+		}
+	}
+
+	// Validate redirect URIs (required unless the client can't use the
+	// authorization_code grant)
+	if len(p.RedirectURIs) == 0 && (len(p.GrantTypes) == 0 || slices.Contains(p.GrantTypes, "authorization_code")) {
 		return apierrors.NewBadRequestError(apierrors.ErrorCodeValidationFailed, "redirect_uris is required")
 	}
 
@@ -45,12 +107,6 @@ func (p *OAuthServerClientRegisterParams) validate() error {
 		}
 	}
 
-	for _, grantType := range p.GrantTypes {
-		if grantType != "authorization_code" && grantType != "refresh_token" {
-			return apierrors.NewBadRequestError(apierrors.ErrorCodeValidationFailed, "grant_types must only contain 'authorization_code' and/or 'refresh_token'")
-		}
-	}
-
 	if len(p.ClientName) > 1024 {
 		return apierrors.NewBadRequestError(apierrors.ErrorCodeValidationFailed, "client_name cannot exceed 1024 characters")
 	}
@@ -73,6 +129,27 @@ func (p *OAuthServerClientRegisterParams) validate() error {
 		}
 	}
 
+	if len(p.Scope) > 2048 {
+		return apierrors.NewBadRequestError(apierrors.ErrorCodeValidationFailed, "scope cannot exceed 2048 characters")
+	}
+
+	// only admins can allow scopes beyond the OpenID Connect scopes
+	if p.RegistrationType == "dynamic" {
+		for _, scope := range models.ParseOAuthScopes(p.Scope) {
+			if !slices.Contains(models.DefaultOAuthScopes, scope) {
+				return apierrors.NewBadRequestError(apierrors.ErrorCodeValidationFailed, "scope '%s' can't be requested by dynamically registered clients", scope)
+			}
+		}
+	}
+
+	if len(p.Audience) > 2048 {
+		return apierrors.NewBadRequestError(apierrors.ErrorCodeValidationFailed, "audience cannot exceed 2048 characters")
+	}
+
+	if err := p.validateClientAuthentication(); err != nil {
+		return err
+	}
+
 	if p.RegistrationType != "dynamic" && p.RegistrationType != "manual" {
 		return apierrors.NewBadRequestError(apierrors.ErrorCodeValidationFailed, "registration_type must be 'dynamic' or 'manual'")
 	}
@@ -80,6 +157,53 @@ func (p *OAuthServerClientRegisterParams) validate() error {
 	return nil
 }
 
+// validateClientAuthentication validates the token endpoint authentication
+// method and the keys registered for private_key_jwt clients
+func (p *OAuthServerClientRegisterParams) validateClientAuthentication() error {
+	switch p.TokenEndpointAuthMethod {
+	case "", "client_secret_basic", "client_secret_post":
+		if len(p.JWKS) > 0 || p.JWKSURI != "" {
+			return apierrors.NewBadRequestError(apierrors.ErrorCodeValidationFailed, "jwks and jwks_uri can only be used with the private_key_jwt token_endpoint_auth_method")
+		}
+		return nil
+
+	case models.PrivateKeyJWTAuthMethod:
+		// handled below
+
+	default:
+		return apierrors.NewBadRequestError(apierrors.ErrorCodeValidationFailed, "token_endpoint_auth_method must be 'client_secret_basic', 'client_secret_post' or 'private_key_jwt'")
+	}
+
+	if (len(p.JWKS) > 0) == (p.JWKSURI != "") {
+		return apierrors.NewBadRequestError(apierrors.ErrorCodeValidationFailed, "Exactly one of jwks or jwks_uri is required for private_key_jwt clients")
+	}
+
+	if p.JWKSURI != "" {
+		if len(p.JWKSURI) > 2048 {
+			return apierrors.NewBadRequestError(apierrors.ErrorCodeValidationFailed, "jwks_uri cannot exceed 2048 characters")
+		}
+		u, err := url.ParseRequestURI(p.JWKSURI)
+		if err != nil || u.Scheme != "https" {
+			return apierrors.NewBadRequestError(apierrors.ErrorCodeValidationFailed, "jwks_uri must be a valid HTTPS URL")
+		}
+		return nil
+	}
+
+	set, err := jwk.Parse(p.JWKS)
+	if err != nil || set.Len() == 0 {
+		return apierrors.NewBadRequestError(apierrors.ErrorCodeValidationFailed, "jwks must be a valid JWK set")
+	}
+
+	for i := 0; i < set.Len(); i++ {
+		key, _ := set.Key(i)
+		if isPrivate, err := jwk.IsPrivateKey(key); err != nil || isPrivate {
+			return apierrors.NewBadRequestError(apierrors.ErrorCodeValidationFailed, "jwks must only contain asymmetric public keys")
+		}
+	}
+
+	return nil
+}
+
 // validateRedirectURI validates OAuth 2.1 redirect URIs
 func validateRedirectURI(uri string) error {
 	if uri == "" {
@@ -115,6 +239,27 @@ func validateRedirectURI(uri string) error {
 	return nil
 }
 
+// generateRegistrationAccessToken generates the token a dynamically
+// registered client uses to manage its registration
+func generateRegistrationAccessToken() string {
+	return crypto.SecureAlphanumeric(64)
+}
+
+// hashRegistrationAccessToken hashes a registration access token. These
+// tokens have enough entropy that a fast hash is sufficient.
+func hashRegistrationAccessToken(client *models.OAuthServerClient, token string) string {
+	return crypto.GenerateTokenHash(client.ClientID, token)
+}
+
+// ValidateRegistrationAccessToken validates a registration access token
+// presented by the client
+func ValidateRegistrationAccessToken(client *models.OAuthServerClient, token string) bool {
+	if token == "" || client.RegistrationAccessTokenHash == "" {
+		return false
+	}
+	return subtle.ConstantTimeCompare([]byte(hashRegistrationAccessToken(client, token)), []byte(client.RegistrationAccessTokenHash.String())) == 1
+}
+
 // generateClientID generates a URL-safe random client ID
 func generateClientID() string {
 	// Generate a 32-character alphanumeric client ID
@@ -142,6 +287,21 @@ func ValidateClientSecret(secret, hash string) bool {
 	return err == nil
 }
 
+// ValidateOAuthServerClientSecret validates a secret presented by the client,
+// accepting the previous secret during the overlap window after a rotation.
+func ValidateOAuthServerClientSecret(client *models.OAuthServerClient, secret string) bool {
+	if client.UsesPrivateKeyJWT() {
+		// these clients can only authenticate with client assertions
+		return false
+	}
+
+	if ValidateClientSecret(secret, client.ClientSecretHash) {
+		return true
+	}
+
+	return client.HasValidPreviousClientSecret() && ValidateClientSecret(secret, client.PreviousClientSecretHash.String())
+}
+
 // registerOAuthServerClient creates a new OAuth server client with generated credentials
 func (s *Server) registerOAuthServerClient(ctx context.Context, params *OAuthServerClientRegisterParams) (*models.OAuthServerClient, string, error) {
 	// Validate all parameters
@@ -163,24 +323,159 @@ func (s *Server) registerOAuthServerClient(ctx context.Context, params *OAuthSer
 		ClientName:       storage.NullString(params.ClientName),
 		ClientURI:        storage.NullString(params.ClientURI),
 		LogoURI:          storage.NullString(params.LogoURI),
+		Audience:         storage.NullString(params.Audience),
+		Scopes:           storage.NullString(strings.Join(models.ParseOAuthScopes(params.Scope), " ")),
+	}
+	client.TokenExchangeAudiences = storage.NullString(strings.Join(params.TokenExchangeAudiences, " "))
+	client.RequirePushedAuthorizationRequests = params.RequirePushedAuthorizationRequests
+
+	client.SetRedirectURIs(params.RedirectURIs)
+	client.SetGrantTypes(grantTypes)
+	setClientAuthentication(client, params)
+
+	// Generate client secret for all clients, except for those using
+	// private_key_jwt which must never receive a shared secret
+	var plaintextSecret string
+	if !client.UsesPrivateKeyJWT() {
+		plaintextSecret = generateClientSecret()
+		hash, err := hashClientSecret(plaintextSecret)
+		if err != nil {
+			return nil, "", errors.Wrap(err, "failed to hash client secret")
+		}
+		client.ClientSecretHash = hash
+	}
+
+	if err := models.CreateOAuthServerClient(db, client); err != nil {
+		return nil, "", errors.Wrap(err, "failed to create OAuth client")
+	}
+
+	return client, plaintextSecret, nil
+}
+
+// setClientAuthentication stores the token endpoint authentication method
+// and keys of the client
+func setClientAuthentication(client *models.OAuthServerClient, params *OAuthServerClientRegisterParams) {
+	client.TokenEndpointAuthMethod = storage.NullString(params.TokenEndpointAuthMethod)
+	client.JWKS = storage.NullString(params.JWKS)
+	client.JWKSURI = storage.NullString(params.JWKSURI)
+}
+
+// updateOAuthServerClient replaces the registered metadata of an OAuth client.
+// Clients switching from private_key_jwt to a secret based authentication
+// method are issued a new secret, which is returned.
+func (s *Server) updateOAuthServerClient(ctx context.Context, client *models.OAuthServerClient, params *OAuthServerClientRegisterParams) (string, error) {
+	// The registration type can't be changed
+	params.RegistrationType = client.RegistrationType
+
+	if err := params.validate(); err != nil {
+		return "", err
 	}
 
+	grantTypes := params.GrantTypes
+	if len(grantTypes) == 0 {
+		grantTypes = []string{"authorization_code", "refresh_token"}
+	}
+
+	db := s.db.WithContext(ctx)
+	usedPrivateKeyJWT := client.UsesPrivateKeyJWT()
+	previousJWKSURI := client.JWKSURI.String()
+
+	client.ClientName = storage.NullString(params.ClientName)
+	client.ClientURI = storage.NullString(params.ClientURI)
+	client.LogoURI = storage.NullString(params.LogoURI)
+	client.Audience = storage.NullString(params.Audience)
+	client.Scopes = storage.NullString(strings.Join(models.ParseOAuthScopes(params.Scope), " "))
+	client.TokenExchangeAudiences = storage.NullString(strings.Join(params.TokenExchangeAudiences, " "))
+	client.RequirePushedAuthorizationRequests = params.RequirePushedAuthorizationRequests
 	client.SetRedirectURIs(params.RedirectURIs)
 	client.SetGrantTypes(grantTypes)
+	setClientAuthentication(client, params)
+
+	var plaintextSecret string
+	if usedPrivateKeyJWT && !client.UsesPrivateKeyJWT() {
+		plaintextSecret = generateClientSecret()
+		hash, err := hashClientSecret(plaintextSecret)
+		if err != nil {
+			return "", errors.Wrap(err, "failed to hash client secret")
+		}
+		client.ClientSecretHash = hash
+		client.PreviousClientSecretHash = ""
+		client.PreviousClientSecretExpiresAt = nil
+	}
+
+	if err := models.UpdateOAuthServerClient(db, client); err != nil {
+		return "", errors.Wrap(err, "failed to update OAuth client")
+	}
+
+	if previousJWKSURI != "" {
+		s.jwksCache.invalidate(previousJWKSURI)
+	}
+
+	return plaintextSecret, nil
+}
+
+// updateOAuthServerClientMetadata replaces the metadata a client may change
+// through the RFC 7592 client configuration endpoint, keeping its grant
+// types, audience and authentication method as registered.
+func (s *Server) updateOAuthServerClientMetadata(ctx context.Context, client *models.OAuthServerClient, params *OAuthServerClientSelfUpdateParams) error {
+	registerParams := &OAuthServerClientRegisterParams{
+		RedirectURIs: params.RedirectURIs,
+		ClientName:   params.ClientName,
+		ClientURI:    params.ClientURI,
+		LogoURI:      params.LogoURI,
+		Scope:        params.Scope,
+
+		GrantTypes:                         client.GetGrantTypes(),
+		Audience:                           client.Audience.String(),
+		TokenEndpointAuthMethod:            client.TokenEndpointAuthMethod.String(),
+		JWKSURI:                            client.JWKSURI.String(),
+		TokenExchangeAudiences:             client.GetTokenExchangeAudiences(),
+		RequirePushedAuthorizationRequests: client.RequirePushedAuthorizationRequests,
+	}
+	if client.JWKS != "" {
+		registerParams.JWKS = json.RawMessage(client.JWKS)
+	}
+
+	_, err := s.updateOAuthServerClient(ctx, client, registerParams)
+	return err
+}
+
+// rotateOAuthServerClientSecret generates a new secret for the client. The
+// previous secret remains valid for the configured overlap window.
+func (s *Server) rotateOAuthServerClientSecret(ctx context.Context, client *models.OAuthServerClient) (string, error) {
+	db := s.db.WithContext(ctx)
 
-	// Generate client secret for all clients
 	plaintextSecret := generateClientSecret()
 	hash, err := hashClientSecret(plaintextSecret)
 	if err != nil {
-		return nil, "", errors.Wrap(err, "failed to hash client secret")
+		return "", errors.Wrap(err, "failed to hash client secret")
 	}
+
+	expiresAt := time.Now().Add(s.config.OAuthServer.ClientSecretRotationOverlap)
+	client.PreviousClientSecretHash = storage.NullString(client.ClientSecretHash)
+	client.PreviousClientSecretExpiresAt = &expiresAt
 	client.ClientSecretHash = hash
 
-	if err := models.CreateOAuthServerClient(db, client); err != nil {
-		return nil, "", errors.Wrap(err, "failed to create OAuth client")
+	if err := models.UpdateOAuthServerClient(db, client); err != nil {
+		return "", errors.Wrap(err, "failed to rotate OAuth client secret")
 	}
 
-	return client, plaintextSecret, nil
+	return plaintextSecret, nil
+}
+
+// issueRegistrationAccessToken generates a new registration access token for
+// the client, replacing any previously issued token
+func (s *Server) issueRegistrationAccessToken(ctx context.Context, client *models.OAuthServerClient) (string, error) {
+	db := s.db.WithContext(ctx)
+
+	token := generateRegistrationAccessToken()
+	client.RegistrationAccessTokenHash = storage.NullString(hashRegistrationAccessToken(client, token))
+
+	if err := db.UpdateOnly(client, "registration_access_token_hash"); err != nil {
+		return "", errors.Wrap(err, "failed to store registration access token")
+	}
+
+	return token, nil
 }
 
 // getOAuthServerClient retrieves an OAuth client by client_id
@@ -212,5 +507,9 @@ func (s *Server) deleteOAuthServerClient(ctx context.Context, clientID string) e
 		return errors.Wrap(err, "failed to delete OAuth client")
 	}
 
+	if jwksURI := client.JWKSURI.String(); jwksURI != "" {
+		s.jwksCache.invalidate(jwksURI)
+	}
+
 	return nil
 }
@@ -96,6 +96,50 @@ func (ts *OAuthServiceTestSuite) TestOAuthServerClientServiceMethods() {
 
 }
 
+func (ts *OAuthServiceTestSuite) TestRegisterClientCredentialsClient() {
+	// clients that can't use the authorization_code grant don't need redirect URIs
+	params := &OAuthServerClientRegisterParams{
+		ClientName:       "Test Service",
+		GrantTypes:       []string{"client_credentials"},
+		Scope:            "read  write",
+		Audience:         "https://api.example.com",
+		RegistrationType: "manual",
+	}
+
+	ctx := context.Background()
+	client, _, err := ts.Server.registerOAuthServerClient(ctx, params)
+	require.NoError(ts.T(), err)
+	assert.Empty(ts.T(), client.GetRedirectURIs())
+	assert.Equal(ts.T(), []string{"client_credentials"}, client.GetGrantTypes())
+	assert.Equal(ts.T(), []string{"read", "write"}, client.GetScopes())
+	assert.Equal(ts.T(), "https://api.example.com", client.Audience.String())
+
+	params.GrantTypes = []string{"client_credentials", "authorization_code"}
+	_, _, err = ts.Server.registerOAuthServerClient(ctx, params)
+	assert.Error(ts.T(), err)
+	assert.Contains(ts.T(), err.Error(), "redirect_uris is required")
+
+	// only admins can register clients for the client_credentials grant
+	params.GrantTypes = []string{"client_credentials"}
+	params.RegistrationType = "dynamic"
+	_, _, err = ts.Server.registerOAuthServerClient(ctx, params)
+	assert.Error(ts.T(), err)
+	assert.Contains(ts.T(), err.Error(), "can't use the client_credentials grant or set an audience")
+
+	// or set their audience
+	params.GrantTypes = []string{"authorization_code"}
+	params.RedirectURIs = []string{"https://example.com/callback"}
+	_, _, err = ts.Server.registerOAuthServerClient(ctx, params)
+	assert.Error(ts.T(), err)
+	assert.Contains(ts.T(), err.Error(), "can't use the client_credentials grant or set an audience")
+
+	// or allow custom scopes
+	params.Audience = ""
+	_, _, err = ts.Server.registerOAuthServerClient(ctx, params)
+	assert.Error(ts.T(), err)
+	assert.Contains(ts.T(), err.Error(), "can't be requested by dynamically registered clients")
+}
+
 func (ts *OAuthServiceTestSuite) TestHashClientSecret() {
 	secret := "test-secret-123"
 
@@ -190,7 +234,7 @@ func (ts *OAuthServiceTestSuite) TestValidationEdgeCases() {
 
 	_, _, err = ts.Server.registerOAuthServerClient(ctx, params)
 	assert.Error(ts.T(), err)
-	assert.Contains(ts.T(), err.Error(), "grant_types must only contain 'authorization_code' and/or 'refresh_token'")
+	assert.Contains(ts.T(), err.Error(), "grant_types must only contain 'authorization_code', 'refresh_token', 'client_credentials', 'urn:ietf:params:oauth:grant-type:device_code' and/or 'urn:ietf:params:oauth:grant-type:token-exchange'")
 
 	// Test client name too long
 	params = &OAuthServerClientRegisterParams{
@@ -328,3 +372,23 @@ func (ts *OAuthServiceTestSuite) TestGrantTypeDefaults() {
 	assert.Contains(ts.T(), grantTypes, "refresh_token")
 	assert.Len(ts.T(), grantTypes, 2)
 }
+
+func (ts *OAuthServiceTestSuite) TestRegisterTokenExchangeClient() {
+	params := &OAuthServerClientRegisterParams{
+		ClientName:             "Orders Service",
+		GrantTypes:             []string{TokenExchangeGrantType},
+		TokenExchangeAudiences: []string{"https://orders.internal"},
+		RegistrationType:       "manual",
+	}
+
+	ctx := context.Background()
+	client, _, err := ts.Server.registerOAuthServerClient(ctx, params)
+	require.NoError(ts.T(), err)
+	assert.Equal(ts.T(), []string{"https://orders.internal"}, client.GetTokenExchangeAudiences())
+
+	// only clients registered by admins can exchange user tokens
+	params.RegistrationType = "dynamic"
+	_, _, err = ts.Server.registerOAuthServerClient(ctx, params)
+	require.Error(ts.T(), err)
+	assert.Contains(ts.T(), err.Error(), "can't use token exchange")
+}
@@ -10,7 +10,8 @@ import (
 type contextKey string
 
 const (
-	oauthServerClientKey contextKey = "oauth_server_client"
+	oauthServerClientKey   contextKey = "oauth_server_client"
+	oauthServerResourceKey contextKey = "oauth_server_resource"
 )
 
 // WithOAuthServerClient adds an OAuth server client to the context
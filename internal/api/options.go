@@ -31,7 +31,12 @@ type LimiterOptions struct {
 	SSO                 *limiter.Limiter
 	SAMLAssertion       *limiter.Limiter
 	Web3                *limiter.Limiter
+	Passkey             *limiter.Limiter
 	OAuthClientRegister *limiter.Limiter
+
+	// OAuthDeviceVerification limits user code lookups, as user codes are
+	// short enough to be brute forced (RFC 8628 section 5.1)
+	OAuthDeviceVerification *limiter.Limiter
 }
 
 func (lo *LimiterOptions) apply(a *API) { a.limiterOpts = lo }
@@ -92,6 +97,11 @@ func NewLimiterOptions(gc *conf.GlobalConfiguration) *LimiterOptions {
 			DefaultExpirationTTL: time.Hour,
 		}).SetBurst(30)
 
+	o.Passkey = tollbooth.NewLimiter(gc.RateLimitPasskey/(60*5),
+		&limiter.ExpirableOptions{
+			DefaultExpirationTTL: time.Hour,
+		}).SetBurst(30)
+
 	// These all use the OTP limit per 5 min with 1hour ttl and burst of 30.
 	o.Recover = newLimiterPer5mOver1h(gc.RateLimitOtp)
 	o.Resend = newLimiterPer5mOver1h(gc.RateLimitOtp)
@@ -99,6 +109,7 @@ func NewLimiterOptions(gc *conf.GlobalConfiguration) *LimiterOptions {
 	o.Otp = newLimiterPer5mOver1h(gc.RateLimitOtp)
 
 	o.OAuthClientRegister = newLimiterPer5mOver1h(gc.RateLimitOAuthDynamicClientRegister)
+	o.OAuthDeviceVerification = newLimiterPer5mOver1h(gc.RateLimitOAuthDeviceVerification)
 
 	return o
 }
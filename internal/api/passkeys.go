@@ -0,0 +1,665 @@
+package api
+
+import (
+	"bytes"
+	"context"
+	"encoding/json"
+	"net/http"
+	"unicode/utf8"
+
+	"github.com/fatih/structs"
+	"github.com/go-chi/chi/v5"
+	wbnprotocol "github.com/go-webauthn/webauthn/protocol"
+	"github.com/go-webauthn/webauthn/webauthn"
+	"github.com/gofrs/uuid"
+	"github.com/supabase/auth/internal/api/apierrors"
+	"github.com/supabase/auth/internal/api/provider"
+	"github.com/supabase/auth/internal/metering"
+	"github.com/supabase/auth/internal/models"
+	"github.com/supabase/auth/internal/storage"
+)
+
+const passkeyProvider = "passkey"
+
+// maxPasskeyFriendlyNameLength is the maximum number of characters of
+// passkey names
+const maxPasskeyFriendlyNameLength = 255
+
+// PasskeyChallengeParams are the parameters of POST /passkeys/challenge
+type PasskeyChallengeParams struct {
+	// Type is signin (the default) or signup
+	Type string `json:"type"`
+	// Name is shown by the authenticator for passkeys created at signup
+	Name string `json:"name"`
+}
+
+type PasskeyChallengeResponse struct {
+	ID                        uuid.UUID                        `json:"challenge_id"`
+	Type                      string                           `json:"type"`
+	ExpiresAt                 int64                            `json:"expires_at"`
+	CredentialRequestOptions  *wbnprotocol.CredentialAssertion `json:"credential_request_options,omitempty"`
+	CredentialCreationOptions *wbnprotocol.CredentialCreation  `json:"credential_creation_options,omitempty"`
+}
+
+// PasskeyGrantParams are the parameters of POST /token?grant_type=webauthn
+type PasskeyGrantParams struct {
+	ChallengeID uuid.UUID              `json:"challenge_id"`
+	Credential  json.RawMessage        `json:"credential"`
+	Data        map[string]interface{} `json:"data"`
+}
+
+type PasskeyRegistrationParams struct {
+	ChallengeID  uuid.UUID       `json:"challenge_id"`
+	Credential   json.RawMessage `json:"credential"`
+	FriendlyName string          `json:"friendly_name"`
+}
+
+type PasskeyUpdateParams struct {
+	FriendlyName string `json:"friendly_name"`
+}
+
+// validatePasskeyFriendlyName checks the name users gave a passkey
+func validatePasskeyFriendlyName(friendlyName string) error {
+	if utf8.RuneCountInString(friendlyName) > maxPasskeyFriendlyNameLength {
+		return apierrors.NewBadRequestError(apierrors.ErrorCodeValidationFailed, "friendly_name cannot exceed %d characters", maxPasskeyFriendlyNameLength)
+	}
+	return nil
+}
+
+type PasskeyListResponse struct {
+	Passkeys []*models.Passkey `json:"passkeys"`
+}
+
+func (a *API) requirePasskeyEnabled(w http.ResponseWriter, req *http.Request) (context.Context, error) {
+	ctx := req.Context()
+	if !a.config.Passkey.Enabled {
+		return nil, apierrors.NewNotFoundError(apierrors.ErrorCodePasskeyProviderDisabled, "Passkeys are disabled")
+	}
+	return ctx, nil
+}
+
+func (a *API) passkeyWebAuthn() (*webauthn.WebAuthn, error) {
+	config := a.config.Passkey
+
+	displayName := config.RPDisplayName
+	if displayName == "" {
+		displayName = config.RPID
+	}
+
+	return webauthn.New(&webauthn.Config{
+		RPID:          config.RPID,
+		RPDisplayName: displayName,
+		RPOrigins:     config.RPOrigins,
+		AuthenticatorSelection: wbnprotocol.AuthenticatorSelection{
+			ResidentKey:        wbnprotocol.ResidentKeyRequirementRequired,
+			RequireResidentKey: wbnprotocol.ResidentKeyRequired(),
+			UserVerification:   wbnprotocol.VerificationRequired,
+		},
+	})
+}
+
+// beginPasskeyRegistration issues the options to create a passkey for the
+// user and stores the challenge.
+func (a *API) beginPasskeyRegistration(db *storage.Connection, user *models.PasskeyUser, challengeType string) (*PasskeyChallengeResponse, error) {
+	config := a.config
+
+	webAuthn, err := a.passkeyWebAuthn()
+	if err != nil {
+		return nil, apierrors.NewInternalServerError("Failed to configure passkeys").WithInternalError(err)
+	}
+
+	exclusions := make([]wbnprotocol.CredentialDescriptor, 0, len(user.Passkeys))
+	for _, credential := range user.WebAuthnCredentials() {
+		exclusions = append(exclusions, credential.Descriptor())
+	}
+
+	options, sessionData, err := webAuthn.BeginRegistration(user, webauthn.WithExclusions(exclusions))
+	if err != nil {
+		return nil, apierrors.NewInternalServerError("Failed to generate passkey registration options").WithInternalError(err)
+	}
+
+	challenge := models.NewPasskeyChallenge(&user.ID, challengeType, sessionData, config.Passkey.ChallengeExpiryDuration)
+	if err := db.Create(challenge); err != nil {
+		return nil, apierrors.NewInternalServerError("Database error creating passkey challenge").WithInternalError(err)
+	}
+
+	return &PasskeyChallengeResponse{
+		ID:                        challenge.ID,
+		Type:                      challengeType,
+		ExpiresAt:                 challenge.ExpiresAt.Unix(),
+		CredentialCreationOptions: options,
+	}, nil
+}
+
+// consumePasskeyChallenge returns the unexpired challenge of the given type,
+// deleting it so that it can only be answered once.
+func consumePasskeyChallenge(db *storage.Connection, id uuid.UUID, challengeTypes ...string) (*models.PasskeyChallenge, error) {
+	var challenge *models.PasskeyChallenge
+	err := db.Transaction(func(tx *storage.Connection) error {
+		var terr error
+		challenge, terr = models.ConsumePasskeyChallenge(tx, id)
+		return terr
+	})
+	if err != nil {
+		if models.IsNotFoundError(err) {
+			return nil, nil
+		}
+		return nil, apierrors.NewInternalServerError("Database error finding passkey challenge").WithInternalError(err)
+	}
+
+	if challenge.IsExpired() {
+		return nil, nil
+	}
+
+	for _, challengeType := range challengeTypes {
+		if challenge.ChallengeType == challengeType {
+			return challenge, nil
+		}
+	}
+
+	return nil, nil
+}
+
+// PasskeyChallenge handles POST /passkeys/challenge, issuing the options to
+// sign in with a discoverable credential or to create one for a new user
+func (a *API) PasskeyChallenge(w http.ResponseWriter, r *http.Request) error {
+	ctx := r.Context()
+	config := a.config
+	db := a.db.WithContext(ctx)
+
+	params := &PasskeyChallengeParams{}
+	if err := retrieveRequestParams(r, params); err != nil {
+		return err
+	}
+
+	switch params.Type {
+	case "", models.PasskeyChallengeSignIn:
+		webAuthn, err := a.passkeyWebAuthn()
+		if err != nil {
+			return apierrors.NewInternalServerError("Failed to configure passkeys").WithInternalError(err)
+		}
+
+		options, sessionData, err := webAuthn.BeginDiscoverableLogin(webauthn.WithUserVerification(wbnprotocol.VerificationRequired))
+		if err != nil {
+			return apierrors.NewInternalServerError("Failed to generate passkey sign-in options").WithInternalError(err)
+		}
+
+		challenge := models.NewPasskeyChallenge(nil, models.PasskeyChallengeSignIn, sessionData, config.Passkey.ChallengeExpiryDuration)
+		if err := db.Create(challenge); err != nil {
+			return apierrors.NewInternalServerError("Database error creating passkey challenge").WithInternalError(err)
+		}
+
+		return sendJSON(w, http.StatusOK, &PasskeyChallengeResponse{
+			ID:                       challenge.ID,
+			Type:                     models.PasskeyChallengeSignIn,
+			ExpiresAt:                challenge.ExpiresAt.Unix(),
+			CredentialRequestOptions: options,
+		})
+
+	case models.PasskeyChallengeSignUp:
+		if config.DisableSignup {
+			return apierrors.NewUnprocessableEntityError(apierrors.ErrorCodeSignupDisabled, "Signups not allowed for this instance")
+		}
+
+		// the user is only created once the passkey was created, under
+		// the ID the authenticator stores as the user handle
+		user := &models.PasskeyUser{
+			User: &models.User{ID: uuid.Must(uuid.NewV4())},
+			Name: params.Name,
+		}
+
+		response, err := a.beginPasskeyRegistration(db, user, models.PasskeyChallengeSignUp)
+		if err != nil {
+			return err
+		}
+
+		return sendJSON(w, http.StatusOK, response)
+
+	default:
+		return apierrors.NewBadRequestError(apierrors.ErrorCodeValidationFailed, "type needs to be signin or signup")
+	}
+}
+
+// PasskeyGrant implements grant_type=webauthn, signing in the user the
+// passkey's user handle identifies or signing up a new user with the passkey.
+func (a *API) PasskeyGrant(ctx context.Context, w http.ResponseWriter, r *http.Request) error {
+	db := a.db.WithContext(ctx)
+
+	if !a.config.Passkey.Enabled {
+		return apierrors.NewUnprocessableEntityError(apierrors.ErrorCodePasskeyProviderDisabled, "Passkeys are disabled")
+	}
+
+	params := &PasskeyGrantParams{}
+	if err := retrieveRequestParams(r, params); err != nil {
+		return err
+	}
+
+	if params.ChallengeID == uuid.Nil || len(params.Credential) == 0 {
+		return apierrors.NewBadRequestError(apierrors.ErrorCodeValidationFailed, "challenge_id and credential are required")
+	}
+
+	challenge, err := consumePasskeyChallenge(db, params.ChallengeID, models.PasskeyChallengeSignIn, models.PasskeyChallengeSignUp)
+	if err != nil {
+		return err
+	}
+	if challenge == nil {
+		return apierrors.NewOAuthError("invalid_grant", "Passkey challenge not found or expired")
+	}
+
+	webAuthn, err := a.passkeyWebAuthn()
+	if err != nil {
+		return apierrors.NewInternalServerError("Failed to configure passkeys").WithInternalError(err)
+	}
+
+	if challenge.ChallengeType == models.PasskeyChallengeSignUp {
+		return a.passkeySignUp(ctx, w, r, webAuthn, challenge, params)
+	}
+
+	return a.passkeySignIn(ctx, w, r, webAuthn, challenge, params)
+}
+
+func (a *API) passkeySignIn(ctx context.Context, w http.ResponseWriter, r *http.Request, webAuthn *webauthn.WebAuthn, challenge *models.PasskeyChallenge, params *PasskeyGrantParams) error {
+	config := a.config
+	db := a.db.WithContext(ctx)
+
+	parsedResponse, err := wbnprotocol.ParseCredentialRequestResponseBody(bytes.NewReader(params.Credential))
+	if err != nil {
+		return apierrors.NewBadRequestError(apierrors.ErrorCodeValidationFailed, "Invalid credential")
+	}
+
+	var passkey *models.Passkey
+	webAuthnUser, credential, err := webAuthn.ValidatePasskeyLogin(func(rawID, userHandle []byte) (webauthn.User, error) {
+		userID, err := uuid.FromString(string(userHandle))
+		if err != nil {
+			return nil, err
+		}
+
+		user, err := models.FindUserByID(db, userID)
+		if err != nil {
+			return nil, err
+		}
+
+		passkeys, err := models.FindPasskeysByUserID(db, user.ID)
+		if err != nil {
+			return nil, err
+		}
+
+		for _, p := range passkeys {
+			if bytes.Equal(p.Credential.ID, rawID) {
+				passkey = p
+			}
+		}
+
+		return &models.PasskeyUser{User: user, Passkeys: passkeys}, nil
+	}, *challenge.SessionData.SessionData, parsedResponse)
+	if err != nil || passkey == nil {
+		return apierrors.NewOAuthError("invalid_grant", "Invalid passkey").WithInternalError(err)
+	}
+
+	user := webAuthnUser.(*models.PasskeyUser).User
+	if user.IsBanned() {
+		return apierrors.NewBadRequestError(apierrors.ErrorCodeUserBanned, "User is banned")
+	}
+
+	var grantParams models.GrantParams
+	grantParams.FillGrantParams(r)
+	grantParams.Provider = passkeyProvider
+
+	var token *AccessTokenResponse
+	err = db.Transaction(func(tx *storage.Connection) error {
+		var terr error
+		if terr = passkey.RecordUse(tx, credential); terr != nil {
+			return terr
+		}
+		if terr = models.NewAuditLogEntry(config.AuditLog, r, tx, user, models.LoginAction, "", map[string]interface{}{
+			"provider":   passkeyProvider,
+			"passkey_id": passkey.ID,
+		}); terr != nil {
+			return terr
+		}
+		token, terr = a.issueRefreshToken(r, tx, user, models.PasskeySignIn, grantParams)
+		if terr != nil {
+			return terr
+		}
+		return nil
+	})
+	if err != nil {
+		return err
+	}
+
+	metering.RecordLogin(metering.LoginTypePasskey, user.ID, &metering.LoginData{
+		Provider: passkeyProvider,
+	})
+
+	return sendJSON(w, http.StatusOK, token)
+}
+
+func (a *API) passkeySignUp(ctx context.Context, w http.ResponseWriter, r *http.Request, webAuthn *webauthn.WebAuthn, challenge *models.PasskeyChallenge, params *PasskeyGrantParams) error {
+	config := a.config
+	db := a.db.WithContext(ctx)
+
+	if config.DisableSignup {
+		return apierrors.NewUnprocessableEntityError(apierrors.ErrorCodeSignupDisabled, "Signups not allowed for this instance")
+	}
+
+	parsedResponse, err := wbnprotocol.ParseCredentialCreationResponseBody(bytes.NewReader(params.Credential))
+	if err != nil {
+		return apierrors.NewBadRequestError(apierrors.ErrorCodeValidationFailed, "Invalid credential")
+	}
+
+	signupParams := &SignupParams{
+		Provider: passkeyProvider,
+		Aud:      a.requestAud(ctx, r),
+		Data:     params.Data,
+	}
+	newUser, err := signupParams.ToUserModel(false /* <- isSSOUser */)
+	if err != nil {
+		return err
+	}
+	newUser.ID = *challenge.UserID
+
+	credential, err := webAuthn.CreateCredential(&models.PasskeyUser{User: newUser}, *challenge.SessionData.SessionData, parsedResponse)
+	if err != nil {
+		return apierrors.NewOAuthError("invalid_grant", "Invalid passkey").WithInternalError(err)
+	}
+
+	passkey, err := models.NewPasskey(newUser.ID, credential, "")
+	if err != nil {
+		return apierrors.NewBadRequestError(apierrors.ErrorCodeValidationFailed, "Invalid credential").WithInternalError(err)
+	}
+
+	if err := a.triggerBeforeUserCreated(r, db, newUser); err != nil {
+		return err
+	}
+
+	var grantParams models.GrantParams
+	grantParams.FillGrantParams(r)
+	grantParams.Provider = passkeyProvider
+
+	var token *AccessTokenResponse
+	err = db.Transaction(func(tx *storage.Connection) error {
+		user, terr := a.signupNewUser(tx, newUser)
+		if terr != nil {
+			return terr
+		}
+		identity, terr := a.createNewIdentity(tx, user, passkeyProvider, structs.Map(provider.Claims{
+			Subject: user.ID.String(),
+		}))
+		if terr != nil {
+			return terr
+		}
+		user.Identities = []models.Identity{*identity}
+
+		if terr = tx.Create(passkey); terr != nil {
+			return apierrors.NewInternalServerError("Database error saving passkey").WithInternalError(terr)
+		}
+		if terr = models.NewAuditLogEntry(config.AuditLog, r, tx, user, models.UserSignedUpAction, "", map[string]interface{}{
+			"provider": passkeyProvider,
+		}); terr != nil {
+			return terr
+		}
+		if terr = models.NewAuditLogEntry(config.AuditLog, r, tx, user, models.PasskeyRegisteredAction, "", map[string]interface{}{
+			"passkey_id": passkey.ID,
+		}); terr != nil {
+			return terr
+		}
+		token, terr = a.issueRefreshToken(r, tx, user, models.PasskeySignIn, grantParams)
+		if terr != nil {
+			return terr
+		}
+		return nil
+	})
+	if err != nil {
+		return err
+	}
+
+	metering.RecordLogin(metering.LoginTypePasskey, token.User.ID, &metering.LoginData{
+		Provider: passkeyProvider,
+	})
+
+	return sendJSON(w, http.StatusOK, token)
+}
+
+// requirePasskeyAAL requires users with verified MFA factors to have stepped
+// up before changing the passkeys they can sign in with.
+func requirePasskeyAAL(user *models.User, session *models.Session) error {
+	if session == nil || session.IsAAL2() {
+		return nil
+	}
+
+	for _, factor := range user.Factors {
+		if factor.IsVerified() {
+			return apierrors.NewForbiddenError(apierrors.ErrorCodeInsufficientAAL, "AAL2 required to manage passkeys")
+		}
+	}
+
+	return nil
+}
+
+// UserPasskeyList handles GET /user/passkeys, listing the passkeys the user
+// can sign in with
+func (a *API) UserPasskeyList(w http.ResponseWriter, r *http.Request) error {
+	ctx := r.Context()
+	user := getUser(ctx)
+	db := a.db.WithContext(ctx)
+
+	passkeys, err := models.FindPasskeysByUserID(db, user.ID)
+	if err != nil {
+		return apierrors.NewInternalServerError("Database error finding passkeys").WithInternalError(err)
+	}
+
+	return sendJSON(w, http.StatusOK, &PasskeyListResponse{
+		Passkeys: passkeys,
+	})
+}
+
+// UserPasskeyChallenge handles POST /user/passkeys/challenge, issuing the
+// options to create a passkey for the user
+func (a *API) UserPasskeyChallenge(w http.ResponseWriter, r *http.Request) error {
+	ctx := r.Context()
+	config := a.config
+	user := getUser(ctx)
+	session := getSession(ctx)
+	db := a.db.WithContext(ctx)
+
+	if err := db.Load(user, "Factors"); err != nil {
+		return apierrors.NewInternalServerError("Database error loading factors").WithInternalError(err)
+	}
+
+	if err := requirePasskeyAAL(user, session); err != nil {
+		return err
+	}
+
+	passkeys, err := models.FindPasskeysByUserID(db, user.ID)
+	if err != nil {
+		return apierrors.NewInternalServerError("Database error finding passkeys").WithInternalError(err)
+	}
+
+	if len(passkeys) >= config.Passkey.MaxPerUser {
+		return apierrors.NewUnprocessableEntityError(apierrors.ErrorCodeTooManyPasskeys, "Maximum number of passkeys reached, delete one to continue")
+	}
+
+	response, err := a.beginPasskeyRegistration(db, &models.PasskeyUser{User: user, Passkeys: passkeys}, models.PasskeyChallengeRegistration)
+	if err != nil {
+		return err
+	}
+
+	return sendJSON(w, http.StatusOK, response)
+}
+
+// UserPasskeyCreate handles POST /user/passkeys, verifying the response to a
+// registration challenge and saving the passkey
+func (a *API) UserPasskeyCreate(w http.ResponseWriter, r *http.Request) error {
+	ctx := r.Context()
+	config := a.config
+	user := getUser(ctx)
+	db := a.db.WithContext(ctx)
+
+	params := &PasskeyRegistrationParams{}
+	if err := retrieveRequestParams(r, params); err != nil {
+		return err
+	}
+
+	if params.ChallengeID == uuid.Nil || len(params.Credential) == 0 {
+		return apierrors.NewBadRequestError(apierrors.ErrorCodeValidationFailed, "challenge_id and credential are required")
+	}
+
+	if err := validatePasskeyFriendlyName(params.FriendlyName); err != nil {
+		return err
+	}
+
+	challenge, err := consumePasskeyChallenge(db, params.ChallengeID, models.PasskeyChallengeRegistration)
+	if err != nil {
+		return err
+	}
+	if challenge == nil || challenge.UserID == nil || *challenge.UserID != user.ID {
+		return apierrors.NewUnprocessableEntityError(apierrors.ErrorCodeValidationFailed, "Passkey challenge not found or expired")
+	}
+
+	parsedResponse, err := wbnprotocol.ParseCredentialCreationResponseBody(bytes.NewReader(params.Credential))
+	if err != nil {
+		return apierrors.NewBadRequestError(apierrors.ErrorCodeValidationFailed, "Invalid credential")
+	}
+
+	webAuthn, err := a.passkeyWebAuthn()
+	if err != nil {
+		return apierrors.NewInternalServerError("Failed to configure passkeys").WithInternalError(err)
+	}
+
+	credential, err := webAuthn.CreateCredential(&models.PasskeyUser{User: user}, *challenge.SessionData.SessionData, parsedResponse)
+	if err != nil {
+		return apierrors.NewBadRequestError(apierrors.ErrorCodeValidationFailed, "Invalid credential").WithInternalError(err)
+	}
+
+	passkey, err := models.NewPasskey(user.ID, credential, params.FriendlyName)
+	if err != nil {
+		return apierrors.NewBadRequestError(apierrors.ErrorCodeValidationFailed, "Invalid credential").WithInternalError(err)
+	}
+
+	err = db.Transaction(func(tx *storage.Connection) error {
+		// the limit is checked again, as several challenges can be issued
+		// before any of them is used
+		count, terr := models.CountPasskeysByUserIDForUpdate(tx, user.ID)
+		if terr != nil {
+			return apierrors.NewInternalServerError("Database error counting passkeys").WithInternalError(terr)
+		}
+		if count >= config.Passkey.MaxPerUser {
+			return apierrors.NewUnprocessableEntityError(apierrors.ErrorCodeTooManyPasskeys, "Maximum number of passkeys reached, delete one to continue")
+		}
+
+		if terr := tx.Create(passkey); terr != nil {
+			return apierrors.NewInternalServerError("Database error saving passkey").WithInternalError(terr)
+		}
+		if terr := models.NewAuditLogEntry(config.AuditLog, r, tx, user, models.PasskeyRegisteredAction, "", map[string]interface{}{
+			"passkey_id": passkey.ID,
+		}); terr != nil {
+			return terr
+		}
+		return nil
+	})
+	if err != nil {
+		return err
+	}
+
+	return sendJSON(w, http.StatusOK, passkey)
+}
+
+// findUserPasskey finds one of the user's passkeys by the passkey_id URL
+// parameter.
+func findUserPasskey(tx *storage.Connection, r *http.Request, user *models.User) (*models.Passkey, error) {
+	passkeyID, err := uuid.FromString(chi.URLParam(r, "passkey_id"))
+	if err != nil {
+		return nil, apierrors.NewNotFoundError(apierrors.ErrorCodePasskeyNotFound, "Passkey not found")
+	}
+
+	passkey, err := models.FindPasskeyByUserIDAndID(tx, user.ID, passkeyID)
+	if err != nil {
+		if models.IsNotFoundError(err) {
+			return nil, apierrors.NewNotFoundError(apierrors.ErrorCodePasskeyNotFound, "Passkey not found")
+		}
+		return nil, apierrors.NewInternalServerError("Database error finding passkey").WithInternalError(err)
+	}
+
+	return passkey, nil
+}
+
+// UserPasskeyUpdate handles PUT /user/passkeys/{passkey_id}, renaming one of
+// the user's passkeys
+func (a *API) UserPasskeyUpdate(w http.ResponseWriter, r *http.Request) error {
+	ctx := r.Context()
+	config := a.config
+	user := getUser(ctx)
+	db := a.db.WithContext(ctx)
+
+	params := &PasskeyUpdateParams{}
+	if err := retrieveRequestParams(r, params); err != nil {
+		return err
+	}
+
+	if err := validatePasskeyFriendlyName(params.FriendlyName); err != nil {
+		return err
+	}
+
+	passkey, err := findUserPasskey(db, r, user)
+	if err != nil {
+		return err
+	}
+
+	err = db.Transaction(func(tx *storage.Connection) error {
+		if terr := passkey.UpdateFriendlyName(tx, params.FriendlyName); terr != nil {
+			return terr
+		}
+		if terr := models.NewAuditLogEntry(config.AuditLog, r, tx, user, models.PasskeyUpdatedAction, "", map[string]interface{}{
+			"passkey_id":    passkey.ID,
+			"friendly_name": passkey.FriendlyName,
+		}); terr != nil {
+			return terr
+		}
+		return nil
+	})
+	if err != nil {
+		return apierrors.NewInternalServerError("Database error updating passkey").WithInternalError(err)
+	}
+
+	return sendJSON(w, http.StatusOK, passkey)
+}
+
+// UserPasskeyDelete handles DELETE /user/passkeys/{passkey_id}, deleting one
+// of the user's passkeys. Sessions signed in with it are kept.
+func (a *API) UserPasskeyDelete(w http.ResponseWriter, r *http.Request) error {
+	ctx := r.Context()
+	config := a.config
+	user := getUser(ctx)
+	session := getSession(ctx)
+	db := a.db.WithContext(ctx)
+
+	if err := db.Load(user, "Factors"); err != nil {
+		return apierrors.NewInternalServerError("Database error loading factors").WithInternalError(err)
+	}
+
+	if err := requirePasskeyAAL(user, session); err != nil {
+		return err
+	}
+
+	passkey, err := findUserPasskey(db, r, user)
+	if err != nil {
+		return err
+	}
+
+	err = db.Transaction(func(tx *storage.Connection) error {
+		if terr := tx.Destroy(passkey); terr != nil {
+			return terr
+		}
+		if terr := models.NewAuditLogEntry(config.AuditLog, r, tx, user, models.PasskeyDeletedAction, "", map[string]interface{}{
+			"passkey_id": passkey.ID,
+		}); terr != nil {
+			return terr
+		}
+		return nil
+	})
+	if err != nil {
+		return apierrors.NewInternalServerError("Database error deleting passkey").WithInternalError(err)
+	}
+
+	return sendJSON(w, http.StatusOK, map[string]interface{}{})
+}
@@ -0,0 +1,383 @@
+package api
+
+import (
+	"bytes"
+	"crypto/ecdsa"
+	"crypto/elliptic"
+	"crypto/rand"
+	"crypto/sha256"
+	"encoding/base64"
+	"encoding/binary"
+	"encoding/json"
+	"fmt"
+	"net/http"
+	"net/http/httptest"
+	"strings"
+	"testing"
+
+	"github.com/go-webauthn/webauthn/protocol/webauthncbor"
+	"github.com/go-webauthn/webauthn/protocol/webauthncose"
+	"github.com/gofrs/uuid"
+	"github.com/stretchr/testify/require"
+	"github.com/stretchr/testify/suite"
+	"github.com/supabase/auth/internal/api/apierrors"
+	"github.com/supabase/auth/internal/conf"
+	"github.com/supabase/auth/internal/models"
+)
+
+const (
+	passkeyTestRPID   = "example.com"
+	passkeyTestOrigin = "https://example.com"
+)
+
+type PasskeyTestSuite struct {
+	suite.Suite
+	API    *API
+	Config *conf.GlobalConfiguration
+}
+
+func TestPasskeys(t *testing.T) {
+	api, config, err := setupAPIForTest()
+	require.NoError(t, err)
+
+	ts := &PasskeyTestSuite{
+		API:    api,
+		Config: config,
+	}
+	defer api.db.Close()
+
+	suite.Run(t, ts)
+}
+
+func (ts *PasskeyTestSuite) SetupTest() {
+	models.TruncateAll(ts.API.db)
+
+	ts.Config.Passkey.Enabled = true
+	ts.Config.Passkey.RPID = passkeyTestRPID
+	ts.Config.Passkey.RPOrigins = []string{passkeyTestOrigin}
+	ts.Config.Passkey.MaxPerUser = 10
+	ts.Config.DisableSignup = false
+}
+
+// softAuthenticator creates and uses a single ES256 passkey without
+// attestation, like a platform authenticator would.
+type softAuthenticator struct {
+	key          *ecdsa.PrivateKey
+	credentialID []byte
+	userHandle   []byte
+	counter      uint32
+}
+
+func newSoftAuthenticator(t *testing.T) *softAuthenticator {
+	key, err := ecdsa.GenerateKey(elliptic.P256(), rand.Reader)
+	require.NoError(t, err)
+
+	credentialID := make([]byte, 16)
+	_, err = rand.Read(credentialID)
+	require.NoError(t, err)
+
+	return &softAuthenticator{
+		key:          key,
+		credentialID: credentialID,
+	}
+}
+
+func (a *softAuthenticator) clientData(ceremony, challenge string) []byte {
+	clientData, _ := json.Marshal(map[string]interface{}{
+		"type":      ceremony,
+		"challenge": challenge,
+		"origin":    passkeyTestOrigin,
+	})
+	return clientData
+}
+
+func (a *softAuthenticator) authenticatorData(flags byte, attestedCredentialData []byte) []byte {
+	rpIDHash := sha256.Sum256([]byte(passkeyTestRPID))
+
+	a.counter++
+	counter := make([]byte, 4)
+	binary.BigEndian.PutUint32(counter, a.counter)
+
+	data := append(rpIDHash[:], flags)
+	data = append(data, counter...)
+	return append(data, attestedCredentialData...)
+}
+
+// create answers the options of a registration challenge
+func (a *softAuthenticator) create(t *testing.T, options map[string]interface{}) json.RawMessage {
+	publicKey := options["publicKey"].(map[string]interface{})
+	user := publicKey["user"].(map[string]interface{})
+
+	userHandle, err := base64.RawURLEncoding.DecodeString(user["id"].(string))
+	require.NoError(t, err)
+	a.userHandle = userHandle
+
+	coseKey, err := webauthncbor.Marshal(webauthncose.EC2PublicKeyData{
+		PublicKeyData: webauthncose.PublicKeyData{
+			KeyType:   int64(webauthncose.EllipticKey),
+			Algorithm: int64(webauthncose.AlgES256),
+		},
+		Curve:  1, // P-256
+		XCoord: a.key.PublicKey.X.FillBytes(make([]byte, 32)),
+		YCoord: a.key.PublicKey.Y.FillBytes(make([]byte, 32)),
+	})
+	require.NoError(t, err)
+
+	attestedCredentialData := make([]byte, 16) // zero AAGUID
+	attestedCredentialData = binary.BigEndian.AppendUint16(attestedCredentialData, uint16(len(a.credentialID)))
+	attestedCredentialData = append(attestedCredentialData, a.credentialID...)
+	attestedCredentialData = append(attestedCredentialData, coseKey...)
+
+	// user present, user verified, attested credential data included
+	authenticatorData := a.authenticatorData(0x01|0x04|0x40, attestedCredentialData)
+
+	attestationObject, err := webauthncbor.Marshal(map[string]interface{}{
+		"fmt":      "none",
+		"attStmt":  map[string]interface{}{},
+		"authData": authenticatorData,
+	})
+	require.NoError(t, err)
+
+	response, err := json.Marshal(map[string]interface{}{
+		"id":    base64.RawURLEncoding.EncodeToString(a.credentialID),
+		"rawId": base64.RawURLEncoding.EncodeToString(a.credentialID),
+		"type":  "public-key",
+		"response": map[string]interface{}{
+			"clientDataJSON":    base64.RawURLEncoding.EncodeToString(a.clientData("webauthn.create", publicKey["challenge"].(string))),
+			"attestationObject": base64.RawURLEncoding.EncodeToString(attestationObject),
+		},
+	})
+	require.NoError(t, err)
+	return response
+}
+
+// get answers the options of a sign-in challenge
+func (a *softAuthenticator) get(t *testing.T, options map[string]interface{}) json.RawMessage {
+	publicKey := options["publicKey"].(map[string]interface{})
+
+	clientData := a.clientData("webauthn.get", publicKey["challenge"].(string))
+	// user present, user verified
+	authenticatorData := a.authenticatorData(0x01|0x04, nil)
+
+	clientDataHash := sha256.Sum256(clientData)
+	digest := sha256.Sum256(append(authenticatorData, clientDataHash[:]...))
+	signature, err := ecdsa.SignASN1(rand.Reader, a.key, digest[:])
+	require.NoError(t, err)
+
+	response, err := json.Marshal(map[string]interface{}{
+		"id":    base64.RawURLEncoding.EncodeToString(a.credentialID),
+		"rawId": base64.RawURLEncoding.EncodeToString(a.credentialID),
+		"type":  "public-key",
+		"response": map[string]interface{}{
+			"clientDataJSON":    base64.RawURLEncoding.EncodeToString(clientData),
+			"authenticatorData": base64.RawURLEncoding.EncodeToString(authenticatorData),
+			"signature":         base64.RawURLEncoding.EncodeToString(signature),
+			"userHandle":        base64.RawURLEncoding.EncodeToString(a.userHandle),
+		},
+	})
+	require.NoError(t, err)
+	return response
+}
+
+type passkeyTestChallenge struct {
+	ID                        uuid.UUID              `json:"challenge_id"`
+	Type                      string                 `json:"type"`
+	CredentialRequestOptions  map[string]interface{} `json:"credential_request_options"`
+	CredentialCreationOptions map[string]interface{} `json:"credential_creation_options"`
+}
+
+func (ts *PasskeyTestSuite) request(method, path, token string, body interface{}) *httptest.ResponseRecorder {
+	var buffer bytes.Buffer
+	require.NoError(ts.T(), json.NewEncoder(&buffer).Encode(body))
+
+	req := httptest.NewRequest(method, "http://localhost"+path, &buffer)
+	req.Header.Set("Content-Type", "application/json")
+	if token != "" {
+		req.Header.Set("Authorization", "Bearer "+token)
+	}
+
+	w := httptest.NewRecorder()
+	ts.API.handler.ServeHTTP(w, req)
+	return w
+}
+
+func (ts *PasskeyTestSuite) challenge(challengeType string) *passkeyTestChallenge {
+	w := ts.request(http.MethodPost, "/passkeys/challenge", "", map[string]interface{}{
+		"type": challengeType,
+	})
+	require.Equal(ts.T(), http.StatusOK, w.Code, w.Body.String())
+
+	challenge := &passkeyTestChallenge{}
+	require.NoError(ts.T(), json.NewDecoder(w.Body).Decode(challenge))
+	return challenge
+}
+
+func (ts *PasskeyTestSuite) grant(challengeID uuid.UUID, credential json.RawMessage) *httptest.ResponseRecorder {
+	return ts.request(http.MethodPost, "/token?grant_type=webauthn", "", map[string]interface{}{
+		"challenge_id": challengeID,
+		"credential":   credential,
+	})
+}
+
+func (ts *PasskeyTestSuite) signUp(authenticator *softAuthenticator) *AccessTokenResponse {
+	challenge := ts.challenge(models.PasskeyChallengeSignUp)
+	require.Equal(ts.T(), models.PasskeyChallengeSignUp, challenge.Type)
+
+	w := ts.grant(challenge.ID, authenticator.create(ts.T(), challenge.CredentialCreationOptions))
+	require.Equal(ts.T(), http.StatusOK, w.Code, w.Body.String())
+
+	token := &AccessTokenResponse{}
+	require.NoError(ts.T(), json.NewDecoder(w.Body).Decode(token))
+	return token
+}
+
+func (ts *PasskeyTestSuite) TestPasskeysDisabled() {
+	ts.Config.Passkey.Enabled = false
+
+	w := ts.request(http.MethodPost, "/passkeys/challenge", "", map[string]interface{}{})
+	require.Equal(ts.T(), http.StatusNotFound, w.Code)
+
+	w = ts.grant(uuid.Must(uuid.NewV4()), json.RawMessage(`{}`))
+	require.Equal(ts.T(), http.StatusUnprocessableEntity, w.Code)
+}
+
+func (ts *PasskeyTestSuite) TestSignUpAndSignIn() {
+	authenticator := newSoftAuthenticator(ts.T())
+
+	signUp := ts.signUp(authenticator)
+	require.NotEmpty(ts.T(), signUp.Token)
+	require.Equal(ts.T(), string(authenticator.userHandle), signUp.User.ID.String())
+	require.Equal(ts.T(), passkeyProvider, signUp.User.AppMetaData["provider"])
+
+	challenge := ts.challenge(models.PasskeyChallengeSignIn)
+	credential := authenticator.get(ts.T(), challenge.CredentialRequestOptions)
+
+	w := ts.grant(challenge.ID, credential)
+	require.Equal(ts.T(), http.StatusOK, w.Code, w.Body.String())
+
+	signIn := &AccessTokenResponse{}
+	require.NoError(ts.T(), json.NewDecoder(w.Body).Decode(signIn))
+	require.Equal(ts.T(), signUp.User.ID, signIn.User.ID)
+
+	passkeys, err := models.FindPasskeysByUserID(ts.API.db, signIn.User.ID)
+	require.NoError(ts.T(), err)
+	require.Len(ts.T(), passkeys, 1)
+	require.NotNil(ts.T(), passkeys[0].LastUsedAt)
+	require.Equal(ts.T(), authenticator.counter, passkeys[0].Credential.Authenticator.SignCount)
+
+	// challenges can only be answered once
+	w = ts.grant(challenge.ID, credential)
+	require.Equal(ts.T(), http.StatusBadRequest, w.Code)
+}
+
+func (ts *PasskeyTestSuite) TestSignInWithUnknownPasskey() {
+	authenticator := newSoftAuthenticator(ts.T())
+	ts.signUp(authenticator)
+
+	other := newSoftAuthenticator(ts.T())
+	other.userHandle = authenticator.userHandle
+
+	challenge := ts.challenge(models.PasskeyChallengeSignIn)
+	w := ts.grant(challenge.ID, other.get(ts.T(), challenge.CredentialRequestOptions))
+	require.Equal(ts.T(), http.StatusBadRequest, w.Code)
+}
+
+func (ts *PasskeyTestSuite) TestSignUpDisabled() {
+	challenge := ts.challenge(models.PasskeyChallengeSignUp)
+
+	ts.Config.DisableSignup = true
+
+	w := ts.grant(challenge.ID, newSoftAuthenticator(ts.T()).create(ts.T(), challenge.CredentialCreationOptions))
+	require.Equal(ts.T(), http.StatusUnprocessableEntity, w.Code)
+
+	var data HTTPError
+	require.NoError(ts.T(), json.NewDecoder(w.Body).Decode(&data))
+	require.Equal(ts.T(), apierrors.ErrorCodeSignupDisabled, data.ErrorCode)
+}
+
+func (ts *PasskeyTestSuite) TestUserPasskeys() {
+	first := newSoftAuthenticator(ts.T())
+	token := ts.signUp(first).Token
+
+	// register a second passkey
+	w := ts.request(http.MethodPost, "/user/passkeys/challenge", token, map[string]interface{}{})
+	require.Equal(ts.T(), http.StatusOK, w.Code, w.Body.String())
+	challenge := &passkeyTestChallenge{}
+	require.NoError(ts.T(), json.NewDecoder(w.Body).Decode(challenge))
+	require.Equal(ts.T(), models.PasskeyChallengeRegistration, challenge.Type)
+
+	second := newSoftAuthenticator(ts.T())
+	credential := second.create(ts.T(), challenge.CredentialCreationOptions)
+	require.Equal(ts.T(), string(first.userHandle), string(second.userHandle))
+
+	w = ts.request(http.MethodPost, "/user/passkeys", token, map[string]interface{}{
+		"challenge_id":  challenge.ID,
+		"credential":    credential,
+		"friendly_name": "Laptop",
+	})
+	require.Equal(ts.T(), http.StatusOK, w.Code, w.Body.String())
+	created := &models.Passkey{}
+	require.NoError(ts.T(), json.NewDecoder(w.Body).Decode(created))
+	require.Equal(ts.T(), "Laptop", created.FriendlyName)
+
+	w = ts.request(http.MethodGet, "/user/passkeys", token, nil)
+	require.Equal(ts.T(), http.StatusOK, w.Code)
+	list := &PasskeyListResponse{}
+	require.NoError(ts.T(), json.NewDecoder(w.Body).Decode(list))
+	require.Len(ts.T(), list.Passkeys, 2)
+
+	w = ts.request(http.MethodPut, fmt.Sprintf("/user/passkeys/%s", created.ID), token, map[string]interface{}{
+		"friendly_name": "Work laptop",
+	})
+	require.Equal(ts.T(), http.StatusOK, w.Code)
+	passkey, err := models.FindPasskeyByCredentialID(ts.API.db, second.credentialID)
+	require.NoError(ts.T(), err)
+	require.Equal(ts.T(), "Work laptop", passkey.FriendlyName)
+
+	w = ts.request(http.MethodPut, fmt.Sprintf("/user/passkeys/%s", created.ID), token, map[string]interface{}{
+		"friendly_name": strings.Repeat("a", maxPasskeyFriendlyNameLength+1),
+	})
+	require.Equal(ts.T(), http.StatusBadRequest, w.Code)
+
+	w = ts.request(http.MethodDelete, fmt.Sprintf("/user/passkeys/%s", created.ID), token, nil)
+	require.Equal(ts.T(), http.StatusOK, w.Code)
+	_, err = models.FindPasskeyByCredentialID(ts.API.db, second.credentialID)
+	require.True(ts.T(), models.IsNotFoundError(err))
+
+	// deleted passkeys can no longer be used to sign in
+	signIn := ts.challenge(models.PasskeyChallengeSignIn)
+	w = ts.grant(signIn.ID, second.get(ts.T(), signIn.CredentialRequestOptions))
+	require.Equal(ts.T(), http.StatusBadRequest, w.Code)
+
+	w = ts.request(http.MethodDelete, fmt.Sprintf("/user/passkeys/%s", uuid.Must(uuid.NewV4())), token, nil)
+	require.Equal(ts.T(), http.StatusNotFound, w.Code)
+}
+
+func (ts *PasskeyTestSuite) TestUserPasskeyLimit() {
+	ts.Config.Passkey.MaxPerUser = 2
+	signedUp := ts.signUp(newSoftAuthenticator(ts.T()))
+	token := signedUp.Token
+
+	// both challenges are issued while the user is below the limit
+	challenges := make([]*passkeyTestChallenge, 2)
+	for i := range challenges {
+		w := ts.request(http.MethodPost, "/user/passkeys/challenge", token, map[string]interface{}{})
+		require.Equal(ts.T(), http.StatusOK, w.Code, w.Body.String())
+		challenges[i] = &passkeyTestChallenge{}
+		require.NoError(ts.T(), json.NewDecoder(w.Body).Decode(challenges[i]))
+	}
+
+	codes := make([]int, 0, len(challenges))
+	for _, challenge := range challenges {
+		w := ts.request(http.MethodPost, "/user/passkeys", token, map[string]interface{}{
+			"challenge_id": challenge.ID,
+			"credential":   newSoftAuthenticator(ts.T()).create(ts.T(), challenge.CredentialCreationOptions),
+		})
+		codes = append(codes, w.Code)
+	}
+	require.Equal(ts.T(), []int{http.StatusOK, http.StatusUnprocessableEntity}, codes)
+
+	passkeys, err := models.FindPasskeysByUserID(ts.API.db, signedUp.User.ID)
+	require.NoError(ts.T(), err)
+	require.Len(ts.T(), passkeys, 2)
+}
@@ -295,6 +295,7 @@ func (a *API) handleSamlAcs(w http.ResponseWriter, r *http.Request) error {
 	}
 
 	providerType := "sso:" + ssoProvider.ID.String()
+	grantParams.Provider = providerType
 	if err := a.triggerBeforeUserCreatedExternal(
 		r, db, &userProvidedData, providerType); err != nil {
 		return err
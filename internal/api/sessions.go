@@ -0,0 +1,344 @@
+package api
+
+import (
+	"net/http"
+	"slices"
+	"time"
+
+	"github.com/go-chi/chi/v5"
+	"github.com/gofrs/uuid"
+	"github.com/supabase/auth/internal/api/apierrors"
+	"github.com/supabase/auth/internal/conf"
+	"github.com/supabase/auth/internal/models"
+	"github.com/supabase/auth/internal/storage"
+)
+
+// SessionResponse describes one of a user's sessions
+type SessionResponse struct {
+	ID          uuid.UUID  `json:"id"`
+	CreatedAt   time.Time  `json:"created_at"`
+	RefreshedAt *time.Time `json:"refreshed_at,omitempty"`
+	AAL         string     `json:"aal"`
+	Tag         string     `json:"tag,omitempty"`
+	UserAgent   string     `json:"user_agent,omitempty"`
+	IP          string     `json:"ip,omitempty"`
+	Current     bool       `json:"current"`
+
+	DeviceType           string     `json:"device_type,omitempty"`
+	OS                   string     `json:"os,omitempty"`
+	Browser              string     `json:"browser,omitempty"`
+	Country              string     `json:"country,omitempty"`
+	ASN                  int64      `json:"asn,omitempty"`
+	ASNOrganization      string     `json:"asn_organization,omitempty"`
+	AuthenticationMethod string     `json:"authentication_method,omitempty"`
+	Provider             string     `json:"provider,omitempty"`
+	OAuthClientID        *uuid.UUID `json:"oauth_client_id,omitempty"`
+}
+
+// SessionListResponse is the response of the session listing endpoints
+type SessionListResponse struct {
+	Sessions []SessionResponse `json:"sessions"`
+}
+
+func sessionToResponse(session *models.Session, current *models.Session) SessionResponse {
+	response := SessionResponse{
+		ID:          session.ID,
+		CreatedAt:   session.CreatedAt,
+		RefreshedAt: session.RefreshedAt,
+		AAL:         models.AAL1.String(),
+		Current:     current != nil && current.ID == session.ID,
+	}
+
+	if session.AAL != nil && *session.AAL != "" {
+		response.AAL = *session.AAL
+	}
+	if session.Tag != nil {
+		response.Tag = *session.Tag
+	}
+	if session.UserAgent != nil {
+		response.UserAgent = *session.UserAgent
+	}
+	if session.IP != nil {
+		response.IP = *session.IP
+	}
+	if session.DeviceType != nil {
+		response.DeviceType = *session.DeviceType
+	}
+	if session.OS != nil {
+		response.OS = *session.OS
+	}
+	if session.Browser != nil {
+		response.Browser = *session.Browser
+	}
+	if session.Country != nil {
+		response.Country = *session.Country
+	}
+	if session.ASN != nil {
+		response.ASN = *session.ASN
+	}
+	if session.ASNOrganization != nil {
+		response.ASNOrganization = *session.ASNOrganization
+	}
+	if session.AuthenticationMethod != nil {
+		response.AuthenticationMethod = *session.AuthenticationMethod
+	}
+	if session.Provider != nil {
+		response.Provider = *session.Provider
+	}
+	response.OAuthClientID = session.OAuthClientID
+
+	return response
+}
+
+// fillGrantLocation looks up the country and autonomous system of the IP
+// address of the grant params when a GeoIP database is configured
+func (a *API) fillGrantLocation(params *models.GrantParams) {
+	if a.geoip == nil || params.IP == "" {
+		return
+	}
+
+	location := a.geoip.Lookup(params.IP)
+	params.Country = location.Country
+	params.ASN = location.ASN
+	params.ASNOrganization = location.ASNOrganization
+}
+
+// listSessions returns all of the user's sessions, most recently created
+// first
+func listSessions(tx *storage.Connection, user *models.User, current *models.Session) (*SessionListResponse, error) {
+	sessions, err := models.FindAllSessionsForUser(tx, user.ID, false)
+	if err != nil {
+		return nil, apierrors.NewInternalServerError("Error loading sessions").WithInternalError(err)
+	}
+
+	slices.SortFunc(sessions, func(a, b *models.Session) int {
+		return b.CreatedAt.Compare(a.CreatedAt)
+	})
+
+	response := &SessionListResponse{
+		Sessions: make([]SessionResponse, 0, len(sessions)),
+	}
+	for _, session := range sessions {
+		response.Sessions = append(response.Sessions, sessionToResponse(session, current))
+	}
+
+	return response, nil
+}
+
+// findUserSession finds one of the user's sessions by the session_id URL
+// parameter. Sessions of other users are reported as not found.
+func findUserSession(tx *storage.Connection, r *http.Request, user *models.User) (*models.Session, error) {
+	sessionID, err := uuid.FromString(chi.URLParam(r, "session_id"))
+	if err != nil {
+		return nil, apierrors.NewNotFoundError(apierrors.ErrorCodeSessionNotFound, "Session not found")
+	}
+
+	session, err := models.FindSessionByID(tx, sessionID, false)
+	if err != nil {
+		if models.IsNotFoundError(err) {
+			return nil, apierrors.NewNotFoundError(apierrors.ErrorCodeSessionNotFound, "Session not found")
+		}
+		return nil, apierrors.NewInternalServerError("Error loading session").WithInternalError(err)
+	}
+
+	if session.UserID != user.ID {
+		return nil, apierrors.NewNotFoundError(apierrors.ErrorCodeSessionNotFound, "Session not found")
+	}
+
+	return session, nil
+}
+
+// UserSessionList handles GET /user/sessions, listing the places the user is
+// signed in
+func (a *API) UserSessionList(w http.ResponseWriter, r *http.Request) error {
+	ctx := r.Context()
+	db := a.db.WithContext(ctx)
+
+	response, err := listSessions(db, getUser(ctx), getSession(ctx))
+	if err != nil {
+		return err
+	}
+
+	return sendJSON(w, http.StatusOK, response)
+}
+
+// UserSessionDelete handles DELETE /user/sessions/{session_id}, signing the
+// user out of one of their sessions
+func (a *API) UserSessionDelete(w http.ResponseWriter, r *http.Request) error {
+	ctx := r.Context()
+	db := a.db.WithContext(ctx)
+	config := a.config
+	user := getUser(ctx)
+
+	err := db.Transaction(func(tx *storage.Connection) error {
+		session, terr := findUserSession(tx, r, user)
+		if terr != nil {
+			return terr
+		}
+
+		if terr := models.NewAuditLogEntry(config.AuditLog, r, tx, user, models.LogoutAction, "", map[string]interface{}{
+			"session_id": session.ID,
+		}); terr != nil {
+			return terr
+		}
+
+		if terr := models.LogoutSession(tx, session.ID); terr != nil {
+			return apierrors.NewInternalServerError("Error revoking session").WithInternalError(terr)
+		}
+		return nil
+	})
+	if err != nil {
+		return err
+	}
+
+	w.WriteHeader(http.StatusNoContent)
+	return nil
+}
+
+// adminUserGetSessions handles GET /admin/users/{user_id}/sessions
+func (a *API) adminUserGetSessions(w http.ResponseWriter, r *http.Request) error {
+	ctx := r.Context()
+	db := a.db.WithContext(ctx)
+
+	response, err := listSessions(db, getUser(ctx), nil)
+	if err != nil {
+		return err
+	}
+
+	return sendJSON(w, http.StatusOK, response)
+}
+
+// adminUserDeleteSession handles DELETE /admin/users/{user_id}/sessions/{session_id}
+func (a *API) adminUserDeleteSession(w http.ResponseWriter, r *http.Request) error {
+	ctx := r.Context()
+	db := a.db.WithContext(ctx)
+	config := a.config
+	user := getUser(ctx)
+	adminUser := getAdminUser(ctx)
+
+	err := db.Transaction(func(tx *storage.Connection) error {
+		session, terr := findUserSession(tx, r, user)
+		if terr != nil {
+			return terr
+		}
+
+		if terr := models.NewAuditLogEntry(config.AuditLog, r, tx, adminUser, models.TokenRevokedAction, "", map[string]interface{}{
+			"user_id":    user.ID,
+			"session_id": session.ID,
+		}); terr != nil {
+			return apierrors.NewInternalServerError("Error recording audit log entry").WithInternalError(terr)
+		}
+
+		if terr := models.LogoutSession(tx, session.ID); terr != nil {
+			return apierrors.NewInternalServerError("Error revoking session").WithInternalError(terr)
+		}
+		return nil
+	})
+	if err != nil {
+		return err
+	}
+
+	w.WriteHeader(http.StatusNoContent)
+	return nil
+}
+
+// adminUserDeleteSessions handles DELETE /admin/users/{user_id}/sessions,
+// signing the user out everywhere
+func (a *API) adminUserDeleteSessions(w http.ResponseWriter, r *http.Request) error {
+	ctx := r.Context()
+	db := a.db.WithContext(ctx)
+	config := a.config
+	user := getUser(ctx)
+	adminUser := getAdminUser(ctx)
+
+	err := db.Transaction(func(tx *storage.Connection) error {
+		if terr := models.NewAuditLogEntry(config.AuditLog, r, tx, adminUser, models.TokenRevokedAction, "", map[string]interface{}{
+			"user_id": user.ID,
+		}); terr != nil {
+			return apierrors.NewInternalServerError("Error recording audit log entry").WithInternalError(terr)
+		}
+
+		if terr := models.Logout(tx, user.ID); terr != nil {
+			return apierrors.NewInternalServerError("Error revoking sessions").WithInternalError(terr)
+		}
+		return nil
+	})
+	if err != nil {
+		return err
+	}
+
+	w.WriteHeader(http.StatusNoContent)
+	return nil
+}
+
+// enforceSessionLimit makes room for a new session once the user reached
+// Sessions.MaxPerUser active sessions, by evicting the oldest created or
+// least recently refreshed ones or by rejecting the sign-in. It has to run in
+// the transaction creating the session.
+func (a *API) enforceSessionLimit(r *http.Request, tx *storage.Connection, user *models.User, grantParams *models.GrantParams) error {
+	config := a.config
+
+	limit := config.Sessions.MaxPerUser
+	if limit <= 0 {
+		return nil
+	}
+
+	sessions, err := models.LockUserSessions(tx, user.ID)
+	if err != nil {
+		return apierrors.NewInternalServerError("Database error loading sessions").WithInternalError(err)
+	}
+
+	tag := (&models.Session{Tag: grantParams.SessionTag}).DetermineTag(config.Sessions.Tags)
+
+	active := make([]*models.Session, 0, len(sessions))
+	for _, session := range sessions {
+		if config.Sessions.MaxPerUserPerTag && session.DetermineTag(config.Sessions.Tags) != tag {
+			continue
+		}
+
+		if !a.isActiveSession(session, user, nil) {
+			// sessions that can no longer be used don't count
+			// towards the limit
+			continue
+		}
+
+		active = append(active, session)
+	}
+
+	excess := len(active) - limit + 1
+	if excess <= 0 {
+		return nil
+	}
+
+	strategy := config.Sessions.MaxPerUserEviction
+	if strategy == "" {
+		strategy = conf.SessionEvictionOldestCreated
+	}
+
+	if strategy == conf.SessionEvictionReject {
+		return apierrors.NewUnprocessableEntityError(apierrors.ErrorCodeSessionLimitReached, "Maximum number of concurrent sessions reached")
+	}
+
+	slices.SortFunc(active, func(x, y *models.Session) int {
+		if strategy == conf.SessionEvictionLeastRecentlyRefreshed {
+			return x.LastRefreshedAt(nil).Compare(y.LastRefreshedAt(nil))
+		}
+		return x.CreatedAt.Compare(y.CreatedAt)
+	})
+
+	for _, session := range active[:excess] {
+		if err := models.NewAuditLogEntry(config.AuditLog, r, tx, user, models.SessionEvictedAction, "", map[string]interface{}{
+			"session_id":   session.ID.String(),
+			"max_per_user": limit,
+			"eviction":     strategy,
+		}); err != nil {
+			return apierrors.NewInternalServerError("Error recording audit log entry").WithInternalError(err)
+		}
+
+		if err := models.LogoutSession(tx, session.ID); err != nil {
+			return apierrors.NewInternalServerError("Error evicting session").WithInternalError(err)
+		}
+	}
+
+	return nil
+}
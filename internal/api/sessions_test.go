@@ -0,0 +1,329 @@
+package api
+
+import (
+	"bytes"
+	"encoding/json"
+	"fmt"
+	"net/http"
+	"net/http/httptest"
+	"net/url"
+	"strings"
+	"testing"
+	"time"
+
+	"github.com/gofrs/uuid"
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+	"github.com/stretchr/testify/suite"
+	"github.com/supabase/auth/internal/api/apierrors"
+	"github.com/supabase/auth/internal/conf"
+	"github.com/supabase/auth/internal/models"
+)
+
+type SessionsTestSuite struct {
+	suite.Suite
+	API    *API
+	Config *conf.GlobalConfiguration
+
+	user    *models.User
+	session *models.Session
+	token   string
+}
+
+func TestSessions(t *testing.T) {
+	api, config, err := setupAPIForTest()
+	require.NoError(t, err)
+
+	ts := &SessionsTestSuite{
+		API:    api,
+		Config: config,
+	}
+	defer api.db.Close()
+
+	suite.Run(t, ts)
+}
+
+func (ts *SessionsTestSuite) SetupTest() {
+	models.TruncateAll(ts.API.db)
+
+	u, err := models.NewUser("", "test@example.com", "password", ts.Config.JWT.Aud, nil)
+	require.NoError(ts.T(), err)
+	require.NoError(ts.T(), ts.API.db.Create(u))
+	ts.user = u
+
+	ts.session = ts.createSession(u)
+
+	req := httptest.NewRequest(http.MethodPost, "/token?grant_type=password", nil)
+	ts.token, _, err = ts.API.generateAccessToken(req, ts.API.db, u, &ts.session.ID, models.PasswordGrant)
+	require.NoError(ts.T(), err)
+}
+
+func (ts *SessionsTestSuite) createSession(u *models.User) *models.Session {
+	s, err := models.NewSession(u.ID, nil)
+	require.NoError(ts.T(), err)
+	userAgent := "Mozilla/5.0"
+	s.UserAgent = &userAgent
+	require.NoError(ts.T(), ts.API.db.Create(s))
+	return s
+}
+
+func (ts *SessionsTestSuite) request(method, path string) *httptest.ResponseRecorder {
+	req := httptest.NewRequest(method, "http://localhost"+path, nil)
+	req.Header.Set("Authorization", fmt.Sprintf("Bearer %s", ts.token))
+
+	w := httptest.NewRecorder()
+	ts.API.handler.ServeHTTP(w, req)
+	return w
+}
+
+func (ts *SessionsTestSuite) TestUserSessionList() {
+	other := ts.createSession(ts.user)
+
+	w := ts.request(http.MethodGet, "/user/sessions")
+	require.Equal(ts.T(), http.StatusOK, w.Code)
+
+	var response SessionListResponse
+	require.NoError(ts.T(), json.NewDecoder(w.Body).Decode(&response))
+	require.Len(ts.T(), response.Sessions, 2)
+
+	for _, session := range response.Sessions {
+		assert.Equal(ts.T(), session.ID == ts.session.ID, session.Current)
+		assert.Equal(ts.T(), "Mozilla/5.0", session.UserAgent)
+		assert.Equal(ts.T(), "aal1", session.AAL)
+	}
+	assert.Contains(ts.T(), []string{response.Sessions[0].ID.String(), response.Sessions[1].ID.String()}, other.ID.String())
+}
+
+func (ts *SessionsTestSuite) TestUserSessionDelete() {
+	other := ts.createSession(ts.user)
+
+	w := ts.request(http.MethodDelete, "/user/sessions/"+other.ID.String())
+	require.Equal(ts.T(), http.StatusNoContent, w.Code)
+
+	_, err := models.FindSessionByID(ts.API.db, other.ID, false)
+	require.True(ts.T(), models.IsNotFoundError(err))
+
+	// sessions of other users can't be revoked
+	otherUser, err := models.NewUser("", "other@example.com", "password", ts.Config.JWT.Aud, nil)
+	require.NoError(ts.T(), err)
+	require.NoError(ts.T(), ts.API.db.Create(otherUser))
+	otherUserSession := ts.createSession(otherUser)
+
+	w = ts.request(http.MethodDelete, "/user/sessions/"+otherUserSession.ID.String())
+	assert.Equal(ts.T(), http.StatusNotFound, w.Code)
+
+	_, err = models.FindSessionByID(ts.API.db, otherUserSession.ID, false)
+	require.NoError(ts.T(), err)
+}
+
+// passwordSignIn signs in with the user's password from a device with the
+// user agent
+func (ts *SessionsTestSuite) passwordSignIn(userAgent string) *httptest.ResponseRecorder {
+	if ts.user.EmailConfirmedAt == nil {
+		now := time.Now()
+		ts.user.EmailConfirmedAt = &now
+		require.NoError(ts.T(), ts.API.db.Update(ts.user))
+	}
+
+	var buffer bytes.Buffer
+	require.NoError(ts.T(), json.NewEncoder(&buffer).Encode(map[string]interface{}{
+		"email":    "test@example.com",
+		"password": "password",
+	}))
+
+	req := httptest.NewRequest(http.MethodPost, "http://localhost/token?grant_type=password", &buffer)
+	req.Header.Set("Content-Type", "application/json")
+	req.Header.Set("User-Agent", userAgent)
+	req.Header.Set("X-Forwarded-For", "203.0.113.7, 10.0.0.1")
+
+	w := httptest.NewRecorder()
+	ts.API.handler.ServeHTTP(w, req)
+	return w
+}
+
+func (ts *SessionsTestSuite) signIn(userAgent string) *AccessTokenResponse {
+	w := ts.passwordSignIn(userAgent)
+	require.Equal(ts.T(), http.StatusOK, w.Code)
+
+	var token AccessTokenResponse
+	require.NoError(ts.T(), json.NewDecoder(w.Body).Decode(&token))
+	return &token
+}
+
+func (ts *SessionsTestSuite) TestSessionClientInfo() {
+	ts.token = ts.signIn("Mozilla/5.0 (iPhone; CPU iPhone OS 17_2 like Mac OS X) AppleWebKit/605.1.15 (KHTML, like Gecko) Version/17.2 Mobile/15E148 Safari/604.1").Token
+
+	w := ts.request(http.MethodGet, "/user/sessions")
+	require.Equal(ts.T(), http.StatusOK, w.Code)
+
+	var response SessionListResponse
+	require.NoError(ts.T(), json.NewDecoder(w.Body).Decode(&response))
+
+	var current *SessionResponse
+	for i := range response.Sessions {
+		if response.Sessions[i].Current {
+			current = &response.Sessions[i]
+		}
+	}
+	require.NotNil(ts.T(), current)
+
+	assert.Equal(ts.T(), "mobile", current.DeviceType)
+	assert.Equal(ts.T(), "iOS", current.OS)
+	assert.Equal(ts.T(), "Safari", current.Browser)
+	assert.Equal(ts.T(), "203.0.113.7", current.IP)
+	assert.Equal(ts.T(), models.PasswordGrant.String(), current.AuthenticationMethod)
+	assert.Equal(ts.T(), "email", current.Provider)
+
+	session, err := models.FindSessionByID(ts.API.db, current.ID, false)
+	require.NoError(ts.T(), err)
+	require.NotNil(ts.T(), session.ForwardedFor)
+	assert.Equal(ts.T(), "203.0.113.7, 10.0.0.1", *session.ForwardedFor)
+}
+
+func (ts *SessionsTestSuite) TestNewSignInNotification() {
+	ts.Config.Mailer.NewSignInNotificationEnabled = true
+	defer func() {
+		ts.Config.Mailer.NewSignInNotificationEnabled = false
+	}()
+
+	countNotifications := func() int {
+		count, err := ts.API.db.Count(&models.SignInNotification{})
+		require.NoError(ts.T(), err)
+		return count
+	}
+
+	firefox := "Mozilla/5.0 (X11; Linux x86_64; rv:121.0) Gecko/20100101 Firefox/121.0"
+	chrome := "Mozilla/5.0 (Windows NT 10.0; Win64; x64) AppleWebKit/537.36 (KHTML, like Gecko) Chrome/120.0.0.0 Safari/537.36"
+
+	// nothing to compare with on the first sign-in
+	ts.signIn(firefox)
+	assert.Equal(ts.T(), 0, countNotifications())
+
+	ts.signIn(firefox)
+	assert.Equal(ts.T(), 0, countNotifications())
+
+	ts.signIn(chrome)
+	assert.Equal(ts.T(), 1, countNotifications())
+
+	ts.signIn(chrome)
+	assert.Equal(ts.T(), 1, countNotifications())
+}
+
+func (ts *SessionsTestSuite) reportSignIn(token string) *httptest.ResponseRecorder {
+	req := httptest.NewRequest(http.MethodPost, "http://localhost/sessions/report", strings.NewReader(url.Values{"token": {token}}.Encode()))
+	req.Header.Set("Content-Type", "application/x-www-form-urlencoded")
+
+	w := httptest.NewRecorder()
+	ts.API.handler.ServeHTTP(w, req)
+	return w
+}
+
+func (ts *SessionsTestSuite) TestReportSignIn() {
+	other := ts.createSession(ts.user)
+	notification, token := models.NewSignInNotification(other, time.Hour)
+	require.NoError(ts.T(), ts.API.db.Create(notification))
+
+	// opening the link only asks for confirmation
+	w := ts.request(http.MethodGet, "/sessions/report?token="+token)
+	require.Equal(ts.T(), http.StatusOK, w.Code)
+	assert.Contains(ts.T(), w.Header().Get("Content-Type"), "text/html")
+	assert.Contains(ts.T(), w.Body.String(), token)
+
+	_, err := models.FindSessionByID(ts.API.db, other.ID, false)
+	require.NoError(ts.T(), err)
+
+	w = ts.reportSignIn(token)
+	require.Equal(ts.T(), http.StatusSeeOther, w.Code)
+	assert.Contains(ts.T(), w.Header().Get("Location"), "message=")
+
+	_, err = models.FindSessionByID(ts.API.db, other.ID, false)
+	require.True(ts.T(), models.IsNotFoundError(err))
+
+	user, err := models.FindUserByID(ts.API.db, ts.user.ID)
+	require.NoError(ts.T(), err)
+	assert.NotNil(ts.T(), user.RecoverySentAt)
+
+	// links can only be used once
+	w = ts.reportSignIn(token)
+	require.Equal(ts.T(), http.StatusSeeOther, w.Code)
+	assert.Contains(ts.T(), w.Header().Get("Location"), "error_code=otp_expired")
+
+	// expired links can't be used
+	expired, expiredToken := models.NewSignInNotification(ts.session, -time.Minute)
+	require.NoError(ts.T(), ts.API.db.Create(expired))
+
+	w = ts.reportSignIn(expiredToken)
+	require.Equal(ts.T(), http.StatusSeeOther, w.Code)
+	assert.Contains(ts.T(), w.Header().Get("Location"), "error_code=otp_expired")
+
+	_, err = models.FindSessionByID(ts.API.db, ts.session.ID, false)
+	require.NoError(ts.T(), err)
+}
+
+func (ts *SessionsTestSuite) TestSessionLimit() {
+	defer func() {
+		ts.Config.Sessions.MaxPerUser = 0
+		ts.Config.Sessions.MaxPerUserEviction = ""
+	}()
+
+	sessionIDs := func() []uuid.UUID {
+		sessions, err := models.FindAllSessionsForUser(ts.API.db, ts.user.ID, false)
+		require.NoError(ts.T(), err)
+
+		ids := []uuid.UUID{}
+		for _, session := range sessions {
+			ids = append(ids, session.ID)
+		}
+		return ids
+	}
+
+	userAgent := "Mozilla/5.0"
+
+	ts.Run("oldest created sessions are evicted", func() {
+		ts.Config.Sessions.MaxPerUser = 2
+		ts.Config.Sessions.MaxPerUserEviction = conf.SessionEvictionOldestCreated
+
+		ts.signIn(userAgent)
+		require.Len(ts.T(), sessionIDs(), 2)
+
+		ts.signIn(userAgent)
+		ids := sessionIDs()
+		require.Len(ts.T(), ids, 2)
+		assert.NotContains(ts.T(), ids, ts.session.ID)
+
+		entries, err := models.FindAuditLogEntries(ts.API.db, []string{"payload->>'action'"}, string(models.SessionEvictedAction), nil)
+		require.NoError(ts.T(), err)
+		require.Len(ts.T(), entries, 1)
+		assert.Equal(ts.T(), ts.session.ID.String(), entries[0].Payload["traits"].(map[string]interface{})["session_id"])
+	})
+
+	ts.Run("least recently refreshed sessions are evicted", func() {
+		ts.SetupTest()
+		ts.Config.Sessions.MaxPerUser = 2
+		ts.Config.Sessions.MaxPerUserEviction = conf.SessionEvictionLeastRecentlyRefreshed
+
+		other := ts.createSession(ts.user)
+
+		// the session created first was refreshed most recently
+		refreshedAt := time.Now()
+		ts.session.RefreshedAt = &refreshedAt
+		require.NoError(ts.T(), ts.session.UpdateOnlyRefreshInfo(ts.API.db))
+
+		ts.signIn(userAgent)
+		ids := sessionIDs()
+		require.Len(ts.T(), ids, 2)
+		assert.Contains(ts.T(), ids, ts.session.ID)
+		assert.NotContains(ts.T(), ids, other.ID)
+	})
+
+	ts.Run("new sign-ins are rejected", func() {
+		ts.SetupTest()
+		ts.Config.Sessions.MaxPerUser = 1
+		ts.Config.Sessions.MaxPerUserEviction = conf.SessionEvictionReject
+
+		w := ts.passwordSignIn(userAgent)
+		require.Equal(ts.T(), http.StatusUnprocessableEntity, w.Code)
+		assert.Contains(ts.T(), w.Body.String(), string(apierrors.ErrorCodeSessionLimitReached))
+		assert.Equal(ts.T(), []uuid.UUID{ts.session.ID}, sessionIDs())
+	})
+}
@@ -38,6 +38,9 @@ type Settings struct {
 	PhoneAutoconfirm  bool             `json:"phone_autoconfirm"`
 	SmsProvider       string           `json:"sms_provider"`
 	SAMLEnabled       bool             `json:"saml_enabled"`
+	PasskeyEnabled    bool             `json:"passkey_enabled"`
+
+	MFAEnforcement MFAEnforcementSettings `json:"mfa_enforcement"`
 }
 
 func (a *API) Settings(w http.ResponseWriter, r *http.Request) error {
@@ -77,5 +80,10 @@ func (a *API) Settings(w http.ResponseWriter, r *http.Request) error {
 		PhoneAutoconfirm:  config.Sms.Autoconfirm,
 		SmsProvider:       config.Sms.Provider,
 		SAMLEnabled:       config.SAML.Enabled,
+		PasskeyEnabled:    config.Passkey.Enabled,
+		MFAEnforcement: MFAEnforcementSettings{
+			Enabled:     config.MFA.Enforcement.Enabled,
+			GracePeriod: int64(config.MFA.Enforcement.GracePeriod.Seconds()),
+		},
 	})
 }
@@ -48,6 +48,7 @@ func TestSettings_DefaultProviders(t *testing.T) {
 	require.True(t, p.WorkOS)
 	require.True(t, p.Zoom)
 
+	require.False(t, resp.MFAEnforcement.Enabled)
 }
 
 func TestSettings_EmailDisabled(t *testing.T) {
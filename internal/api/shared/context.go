@@ -0,0 +1,52 @@
+package shared
+
+import (
+	"context"
+
+	"github.com/supabase/auth/internal/models"
+)
+
+type contextKey string
+
+func (c contextKey) String() string {
+	return "gotrue api context key " + string(c)
+}
+
+const (
+	userKey    = contextKey("user")
+	sessionKey = contextKey("session")
+)
+
+// WithUser adds the user to the context.
+func WithUser(ctx context.Context, u *models.User) context.Context {
+	return context.WithValue(ctx, userKey, u)
+}
+
+// GetUser reads the user from the context.
+func GetUser(ctx context.Context) *models.User {
+	if ctx == nil {
+		return nil
+	}
+	obj := ctx.Value(userKey)
+	if obj == nil {
+		return nil
+	}
+	return obj.(*models.User)
+}
+
+// WithSession adds the session to the context.
+func WithSession(ctx context.Context, s *models.Session) context.Context {
+	return context.WithValue(ctx, sessionKey, s)
+}
+
+// GetSession reads the session from the context.
+func GetSession(ctx context.Context) *models.Session {
+	if ctx == nil {
+		return nil
+	}
+	obj := ctx.Value(sessionKey)
+	if obj == nil {
+		return nil
+	}
+	return obj.(*models.Session)
+}
@@ -0,0 +1,268 @@
+package api
+
+import (
+	"errors"
+	"html/template"
+	"net/http"
+	"net/url"
+	"time"
+
+	"github.com/gofrs/uuid"
+	"github.com/supabase/auth/internal/api/apierrors"
+	"github.com/supabase/auth/internal/hooks/v0hooks"
+	mail "github.com/supabase/auth/internal/mailer"
+	"github.com/supabase/auth/internal/models"
+	"github.com/supabase/auth/internal/observability"
+	"github.com/supabase/auth/internal/storage"
+	"github.com/supabase/auth/internal/utilities"
+)
+
+// signInNotificationExpiry is how long the "this wasn't me" link of a
+// sign-in notification can be used
+const signInNotificationExpiry = 7 * 24 * time.Hour
+
+// signInReported is the message shown after a sign-in was reported
+const signInReported = "The session was signed out. Check your email to reset your password."
+
+// signInReportPage asks the user to confirm a sign-in report before the
+// session is revoked
+var signInReportPage = template.Must(template.New("sign_in_report").Parse(`<!DOCTYPE html>
+<html>
+<head>
+<meta charset="utf-8">
+<meta name="viewport" content="width=device-width, initial-scale=1">
+<title>Report sign-in</title>
+</head>
+<body>
+<h1>Didn't sign in?</h1>
+<p>The new session will be signed out, and an email to reset your password will be sent to you.</p>
+<form method="post">
+<input type="hidden" name="token" value="{{ .Token }}">
+<button type="submit">Sign out the session</button>
+</form>
+</body>
+</html>
+`))
+
+// pendingSignInNotification is a sign-in notification waiting for the
+// transaction that creates its session to be committed
+type pendingSignInNotification struct {
+	user    *models.User
+	session *models.Session
+	token   string
+}
+
+// notifyNewSignIn records the device and country of a newly created session
+// and queues a notification email when either was never seen for the user
+// before. The email is sent by sendSignInNotifications once the request has
+// completed, so that it is never sent for sessions that are rolled back.
+func (a *API) notifyNewSignIn(r *http.Request, tx *storage.Connection, user *models.User, sessionID uuid.UUID) error {
+	if !a.config.Mailer.NewSignInNotificationEnabled || user.GetEmail() == "" {
+		return nil
+	}
+
+	session, err := models.FindSessionByID(tx, sessionID, false)
+	if err != nil {
+		return apierrors.NewInternalServerError("Database error loading session").WithInternalError(err)
+	}
+
+	country := ""
+	if session.Country != nil {
+		country = *session.Country
+	}
+
+	newDevice, newCountry, err := models.RecordKnownDevice(tx, user.ID, session.DeviceFingerprint(), country)
+	if err != nil {
+		return apierrors.NewInternalServerError("Database error recording device").WithInternalError(err)
+	}
+
+	if !newDevice && !newCountry {
+		return nil
+	}
+
+	notification, token := models.NewSignInNotification(session, signInNotificationExpiry)
+	if err := tx.Create(notification); err != nil {
+		return apierrors.NewInternalServerError("Database error creating sign-in notification").WithInternalError(err)
+	}
+
+	pending := getPendingSignInNotifications(r.Context())
+	if pending == nil {
+		observability.GetLogEntry(r).Entry.Warn("Sign-in notification can't be sent outside of a request")
+		return nil
+	}
+	*pending = append(*pending, &pendingSignInNotification{
+		user:    user,
+		session: session,
+		token:   token,
+	})
+
+	return nil
+}
+
+// sendSignInNotifications sends the sign-in notifications queued while
+// handling the request. Notifications are only sent for sessions that were
+// committed, and failing to send them doesn't fail the sign-in.
+func (a *API) sendSignInNotifications(next http.Handler) http.Handler {
+	return http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		pending := []*pendingSignInNotification{}
+		r = r.WithContext(withPendingSignInNotifications(r.Context(), &pending))
+
+		next.ServeHTTP(w, r)
+
+		if len(pending) == 0 {
+			return
+		}
+
+		db := a.db.WithContext(r.Context())
+		for _, notification := range pending {
+			if _, err := models.FindSessionByID(db, notification.session.ID, false); err != nil {
+				if !models.IsNotFoundError(err) {
+					observability.GetLogEntry(r).Entry.WithError(err).Warn("Unable to load session of sign-in notification")
+				}
+				continue
+			}
+
+			if err := a.sendNewSignInNotification(r, db, notification.user, notification.session, notification.token); err != nil {
+				observability.GetLogEntry(r).Entry.WithError(err).Warn("Unable to send sign-in notification")
+			}
+		}
+	})
+}
+
+// sendNewSignInNotification sends the sign-in notification through the send
+// email hook or the mailer
+func (a *API) sendNewSignInNotification(r *http.Request, conn *storage.Connection, u *models.User, session *models.Session, token string) error {
+	ctx := r.Context()
+	config := a.config
+
+	if !a.checkEmailAddressAuthorization(u.GetEmail()) {
+		return apierrors.NewBadRequestError(apierrors.ErrorCodeEmailAddressNotAuthorized, "Email address %q cannot be used as it is not authorized", u.GetEmail())
+	}
+
+	externalURL := getExternalHost(ctx)
+	if externalURL == nil {
+		parsed, err := url.ParseRequestURI(config.API.ExternalURL)
+		if err != nil {
+			return err
+		}
+		externalURL = parsed
+	}
+
+	path, err := url.Parse(config.Mailer.URLPaths.NewSignInNotification)
+	if err != nil {
+		return err
+	}
+	path.RawQuery = url.Values{"token": {token}}.Encode()
+	reportURL := externalURL.ResolveReference(path).String()
+
+	if config.Hook.SendEmail.Enabled {
+		input := v0hooks.SendEmailInput{
+			User: u,
+			EmailData: mail.EmailData{
+				Token:           token,
+				EmailActionType: mail.NewSignInNotification,
+				RedirectTo:      reportURL,
+				SiteURL:         externalURL.String(),
+				Session:         session,
+			},
+		}
+		output := v0hooks.SendEmailOutput{}
+		return a.hooksMgr.InvokeHook(conn, r, &input, &output)
+	}
+
+	return a.Mailer().NewSignInNotificationMail(r, u, session, reportURL)
+}
+
+// ReportSignInConfirmation handles the "this wasn't me" link of sign-in
+// notifications. It only renders a page for the user to confirm the report,
+// as links in emails are also opened by link scanners.
+func (a *API) ReportSignInConfirmation(w http.ResponseWriter, r *http.Request) error {
+	token := r.URL.Query().Get("token")
+	if token == "" {
+		return apierrors.NewBadRequestError(apierrors.ErrorCodeValidationFailed, "token is required")
+	}
+
+	w.Header().Set("Content-Type", "text/html; charset=utf-8")
+	w.Header().Set("Cache-Control", "no-store")
+	w.Header().Set("Referrer-Policy", "no-referrer")
+	w.Header().Set("Content-Security-Policy", "default-src 'none'; frame-ancestors 'none'")
+	w.WriteHeader(http.StatusOK)
+
+	return signInReportPage.Execute(w, struct{ Token string }{Token: token})
+}
+
+// ReportSignIn handles the sign-in report confirmed by the user. The session
+// created by the sign-in is revoked and a password recovery email is sent to
+// the user.
+func (a *API) ReportSignIn(w http.ResponseWriter, r *http.Request) error {
+	ctx := r.Context()
+	db := a.db.WithContext(ctx)
+	config := a.config
+
+	rurl := utilities.GetReferrer(r, config)
+
+	err := db.Transaction(func(tx *storage.Connection) error {
+		token := r.PostFormValue("token")
+		if token == "" {
+			return apierrors.NewBadRequestError(apierrors.ErrorCodeValidationFailed, "token is required")
+		}
+
+		notification, terr := models.ConsumeSignInNotification(tx, token)
+		if terr != nil {
+			if models.IsNotFoundError(terr) {
+				return apierrors.NewForbiddenError(apierrors.ErrorCodeOTPExpired, "Email link is invalid or has expired")
+			}
+			return apierrors.NewInternalServerError("Database error finding sign-in notification").WithInternalError(terr)
+		}
+
+		if notification.IsExpired() {
+			return apierrors.NewForbiddenError(apierrors.ErrorCodeOTPExpired, "Email link is invalid or has expired")
+		}
+
+		user, terr := models.FindUserByID(tx, notification.UserID)
+		if terr != nil {
+			return apierrors.NewInternalServerError("Database error finding user").WithInternalError(terr)
+		}
+
+		traits := map[string]interface{}{}
+		if notification.SessionID != nil {
+			traits["session_id"] = notification.SessionID.String()
+
+			if terr := models.LogoutSession(tx, *notification.SessionID); terr != nil {
+				return apierrors.NewInternalServerError("Error revoking session").WithInternalError(terr)
+			}
+		}
+
+		if terr := models.NewAuditLogEntry(config.AuditLog, r, tx, user, models.SessionReportedAction, "", traits); terr != nil {
+			return apierrors.NewInternalServerError("Error recording audit log entry").WithInternalError(terr)
+		}
+
+		if terr := a.sendPasswordRecovery(r, tx, user, models.ImplicitFlow); terr != nil {
+			var herr *HTTPError
+			if errors.As(terr, &herr) && herr.ErrorCode == apierrors.ErrorCodeOverEmailSendRateLimit {
+				// a recovery email was sent very recently, the
+				// session should still be revoked
+				return nil
+			}
+			return terr
+		}
+
+		return nil
+	})
+
+	if err != nil {
+		var herr *HTTPError
+		if !errors.As(err, &herr) {
+			return err
+		}
+		rurl, err = a.prepErrorRedirectURL(herr, r, rurl, models.ImplicitFlow)
+	} else {
+		rurl, err = a.prepRedirectURL(signInReported, rurl, models.ImplicitFlow)
+	}
+	if err != nil {
+		return err
+	}
+
+	http.Redirect(w, r, rurl, http.StatusSeeOther)
+	return nil
+}
@@ -13,6 +13,7 @@ import (
 	"github.com/xeipuuv/gojsonschema"
 
 	"github.com/supabase/auth/internal/api/apierrors"
+	"github.com/supabase/auth/internal/api/oauthserver"
 	"github.com/supabase/auth/internal/hooks/v0hooks"
 	"github.com/supabase/auth/internal/metering"
 	"github.com/supabase/auth/internal/models"
@@ -32,8 +33,17 @@ type AccessTokenClaims struct {
 	AuthenticationMethodReference []models.AMREntry      `json:"amr,omitempty"`
 	SessionId                     string                 `json:"session_id,omitempty"`
 	IsAnonymous                   bool                   `json:"is_anonymous"`
-	// TODO(cemalkilic) : client_id claim will be added later
-	// ClientId                      string                 `json:"client_id,omitempty"`
+	Scope                         string                 `json:"scope,omitempty"`
+
+	// Confirmation is set when the token is bound to a DPoP key
+	Confirmation *v0hooks.ConfirmationClaim `json:"cnf,omitempty"`
+
+	// MFARequired is set when the MFA enforcement policy restricts the
+	// session to enrolling a factor
+	MFARequired bool `json:"mfa_required,omitempty"`
+
+	// ClientID is set on tokens issued to OAuth server clients
+	ClientID string `json:"client_id,omitempty"`
 }
 
 // AccessTokenResponse represents an OAuth2 success response
@@ -43,10 +53,15 @@ type AccessTokenResponse struct {
 	ExpiresIn            int                `json:"expires_in"`
 	ExpiresAt            int64              `json:"expires_at"`
 	RefreshToken         string             `json:"refresh_token"`
+	IDToken              string             `json:"id_token,omitempty"`
+	Scope                string             `json:"scope,omitempty"`
 	User                 *models.User       `json:"user"`
 	ProviderAccessToken  string             `json:"provider_token,omitempty"`
 	ProviderRefreshToken string             `json:"provider_refresh_token,omitempty"`
 	WeakPassword         *WeakPasswordError `json:"weak_password,omitempty"`
+	// TrustedDeviceToken is only returned when verifying a factor on a
+	// device the user asked to trust
+	TrustedDeviceToken string `json:"trusted_device_token,omitempty"`
 }
 
 // AsRedirectURL encodes the AccessTokenResponse as a redirect URL that
@@ -94,9 +109,20 @@ func (a *API) Token(w http.ResponseWriter, r *http.Request) error {
 		handler = a.IdTokenGrant
 	case "pkce":
 		handler = a.PKCE
+	case "authorization_code":
+		handler = a.AuthorizationCodeGrant
+	case "client_credentials":
+		handler = a.ClientCredentialsGrant
+	case oauthserver.DeviceCodeGrantType:
+		handler = a.DeviceCodeGrant
+	case oauthserver.TokenExchangeGrantType:
+		handler = a.TokenExchangeGrant
 	case "web3":
 		handler = a.Web3Grant
 		limiter = a.limiterOpts.Web3
+	case "webauthn":
+		handler = a.PasskeyGrant
+		limiter = a.limiterOpts.Passkey
 	default:
 		return apierrors.NewBadRequestError(apierrors.ErrorCodeInvalidCredentials, "unsupported_grant_type")
 	}
@@ -105,6 +131,27 @@ func (a *API) Token(w http.ResponseWriter, r *http.Request) error {
 		return err
 	}
 
+	if r.Header.Get(dpopHeader) != "" {
+		// tokens issued for requests with a DPoP proof are bound to the
+		// key that signed it
+		jkt, err := a.verifyDPoPProof(r)
+		if err != nil {
+			return err
+		}
+		ctx = withDPoPJKT(ctx, jkt)
+		r = r.WithContext(ctx)
+	}
+
+	resource, err := a.loadRequestedResource(r)
+	if err != nil {
+		return err
+	}
+	if resource != nil {
+		// access tokens are restricted to the requested resource
+		ctx = withOAuthResource(ctx, resource)
+		r = r.WithContext(ctx)
+	}
+
 	return handler(ctx, w, r)
 }
 
@@ -232,6 +279,7 @@ func (a *API) ResourceOwnerPasswordGrant(ctx context.Context, w http.ResponseWri
 		}); terr != nil {
 			return terr
 		}
+		grantParams.Provider = provider
 		token, terr = a.issueRefreshToken(r, tx, user, models.PasswordGrant, grantParams)
 		if terr != nil {
 			return terr
@@ -302,6 +350,7 @@ func (a *API) PKCE(ctx context.Context, w http.ResponseWriter, r *http.Request)
 		}); terr != nil {
 			return terr
 		}
+		grantParams.Provider = flowState.ProviderType
 		token, terr = a.issueRefreshToken(r, tx, user, authMethod, grantParams)
 		if terr != nil {
 			// error type is already handled in issueRefreshToken
@@ -343,13 +392,21 @@ func (a *API) generateAccessToken(r *http.Request, tx *storage.Connection, user
 		return "", 0, terr
 	}
 
+	audience := user.Aud
+	if resource := getOAuthResource(r.Context()); resource != nil {
+		if terr := checkResourceAllowed(tx, resource, session); terr != nil {
+			return "", 0, terr
+		}
+		audience = resource.Resource
+	}
+
 	issuedAt := time.Now().UTC()
-	expiresAt := issuedAt.Add(time.Second * time.Duration(config.JWT.Exp))
+	expiresAt := issuedAt.Add(time.Second * time.Duration(a.accessTokenExpiry(r.Context())))
 
 	claims := &v0hooks.AccessTokenClaims{
 		RegisteredClaims: jwt.RegisteredClaims{
 			Subject:   user.ID.String(),
-			Audience:  jwt.ClaimStrings{user.Aud},
+			Audience:  jwt.ClaimStrings{audience},
 			IssuedAt:  jwt.NewNumericDate(issuedAt),
 			ExpiresAt: jwt.NewNumericDate(expiresAt),
 			Issuer:    config.JWT.Issuer,
@@ -364,6 +421,25 @@ func (a *API) generateAccessToken(r *http.Request, tx *storage.Connection, user
 		AuthenticationMethodReference: amr,
 		IsAnonymous:                   user.IsAnonymous,
 	}
+	if session.Scopes != nil {
+		claims.Scope = *session.Scopes
+	}
+	if session.OAuthClientID != nil {
+		client, terr := models.FindOAuthServerClientByID(tx, *session.OAuthClientID)
+		if terr != nil {
+			return "", 0, terr
+		}
+		claims.ClientID = client.ClientID
+	}
+	if session.DPoPJKT != nil {
+		claims.Confirmation = &v0hooks.ConfirmationClaim{JKT: *session.DPoPJKT}
+	}
+
+	enforcement, terr := a.mfaEnforcementState(tx, user)
+	if terr != nil {
+		return "", 0, terr
+	}
+	claims.MFARequired = enforcement.Restricted
 
 	var gotrueClaims jwt.Claims = claims
 	if config.Hook.CustomAccessToken.Enabled {
@@ -382,6 +458,14 @@ func (a *API) generateAccessToken(r *http.Request, tx *storage.Connection, user
 		if err := validateTokenClaims(output.Claims); err != nil {
 			return "", 0, err
 		}
+		if claims.Confirmation != nil {
+			// the hook can't remove or change the key binding
+			output.Claims["cnf"] = claims.Confirmation
+		}
+		if claims.MFARequired {
+			// nor lift the MFA enforcement restriction
+			output.Claims["mfa_required"] = true
+		}
 		gotrueClaims = jwt.MapClaims(output.Claims)
 	}
 
@@ -393,8 +477,6 @@ func (a *API) generateAccessToken(r *http.Request, tx *storage.Connection, user
 }
 
 func (a *API) issueRefreshToken(r *http.Request, conn *storage.Connection, user *models.User, authenticationMethod models.AuthenticationMethod, grantParams models.GrantParams) (*AccessTokenResponse, error) {
-	config := a.config
-
 	now := time.Now()
 	user.LastSignInAt = &now
 
@@ -402,9 +484,24 @@ func (a *API) issueRefreshToken(r *http.Request, conn *storage.Connection, user
 	var expiresAt int64
 	var refreshToken *models.RefreshToken
 
+	if jkt := getDPoPJKT(r.Context()); jkt != "" {
+		grantParams.DPoPJKT = &jkt
+	}
+
+	if resource := getOAuthResource(r.Context()); resource != nil {
+		grantParams.Resource = &resource.Resource
+	}
+
+	grantParams.AuthenticationMethod = authenticationMethod.String()
+	a.fillGrantLocation(&grantParams)
+
 	err := conn.Transaction(func(tx *storage.Connection) error {
 		var terr error
 
+		if terr := a.enforceSessionLimit(r, tx, user, &grantParams); terr != nil {
+			return terr
+		}
+
 		refreshToken, terr = models.GrantAuthenticatedUser(tx, user, grantParams)
 		if terr != nil {
 			return apierrors.NewInternalServerError("Database error granting user").WithInternalError(terr)
@@ -415,6 +512,14 @@ func (a *API) issueRefreshToken(r *http.Request, conn *storage.Connection, user
 			return terr
 		}
 
+		if grantParams.OAuthClientID == nil {
+			// sessions of OAuth clients are created on the client's
+			// servers rather than the user's device
+			if terr := a.notifyNewSignIn(r, tx, user, *refreshToken.SessionId); terr != nil {
+				return terr
+			}
+		}
+
 		tokenString, expiresAt, terr = a.generateAccessToken(r, tx, user, refreshToken.SessionId, authenticationMethod)
 		if terr != nil {
 			// Account for Hook Error
@@ -432,8 +537,8 @@ func (a *API) issueRefreshToken(r *http.Request, conn *storage.Connection, user
 
 	return &AccessTokenResponse{
 		Token:        tokenString,
-		TokenType:    "bearer",
-		ExpiresIn:    config.JWT.Exp,
+		TokenType:    accessTokenType(grantParams.DPoPJKT),
+		ExpiresIn:    a.accessTokenExpiry(r.Context()),
 		ExpiresAt:    expiresAt,
 		RefreshToken: refreshToken.Token,
 		User:         user,
@@ -446,6 +551,7 @@ func (a *API) updateMFASessionAndClaims(r *http.Request, tx *storage.Connection,
 	var tokenString string
 	var expiresAt int64
 	var refreshToken *models.RefreshToken
+	var dpopJKT *string
 	currentClaims := getClaims(ctx)
 	sessionId, err := uuid.FromString(currentClaims.SessionId)
 	if err != nil {
@@ -460,6 +566,7 @@ func (a *API) updateMFASessionAndClaims(r *http.Request, tx *storage.Connection,
 		if terr != nil {
 			return terr
 		}
+		dpopJKT = session.DPoPJKT
 		currentToken, terr := models.FindTokenBySessionID(tx, &session.ID)
 		if terr != nil {
 			return terr
@@ -496,8 +603,8 @@ func (a *API) updateMFASessionAndClaims(r *http.Request, tx *storage.Connection,
 	}
 	return &AccessTokenResponse{
 		Token:        tokenString,
-		TokenType:    "bearer",
-		ExpiresIn:    config.JWT.Exp,
+		TokenType:    accessTokenType(dpopJKT),
+		ExpiresIn:    a.accessTokenExpiry(r.Context()),
 		ExpiresAt:    expiresAt,
 		RefreshToken: refreshToken.Token,
 		User:         user,
@@ -0,0 +1,202 @@
+package api
+
+import (
+	"context"
+	"net/http"
+	"slices"
+	"strings"
+	"time"
+
+	"github.com/gofrs/uuid"
+	"github.com/golang-jwt/jwt/v5"
+	"github.com/supabase/auth/internal/api/apierrors"
+	"github.com/supabase/auth/internal/api/oauthserver"
+	"github.com/supabase/auth/internal/models"
+)
+
+// Token type identifiers defined by RFC 8693
+const (
+	accessTokenTokenType = "urn:ietf:params:oauth:token-type:access_token"
+	jwtTokenType         = "urn:ietf:params:oauth:token-type:jwt"
+)
+
+// ActorClaim is the RFC 8693 act claim identifying the client acting on
+// behalf of the user. Previous actors are nested when a token is exchanged
+// more than once.
+type ActorClaim struct {
+	Subject string      `json:"sub"`
+	Actor   *ActorClaim `json:"act,omitempty"`
+}
+
+// TokenExchangeClaims are the claims of access tokens issued through token
+// exchange
+type TokenExchangeClaims struct {
+	AccessTokenClaims
+	ClientID string      `json:"client_id,omitempty"`
+	Actor    *ActorClaim `json:"act,omitempty"`
+}
+
+// TokenExchangeResponse is the RFC 8693 token exchange response
+type TokenExchangeResponse struct {
+	Token           string `json:"access_token"`
+	IssuedTokenType string `json:"issued_token_type"`
+	TokenType       string `json:"token_type"`
+	ExpiresIn       int    `json:"expires_in"`
+	ExpiresAt       int64  `json:"expires_at"`
+	Scope           string `json:"scope,omitempty"`
+}
+
+// TokenExchangeGrant implements RFC 8693 token exchange. Trusted OAuth server
+// clients can exchange a user's access token for a token with fewer scopes
+// that is aimed at one of the audiences allowed for the client. The client is
+// recorded as the actor in the act claim of the issued token.
+func (a *API) TokenExchangeGrant(ctx context.Context, w http.ResponseWriter, r *http.Request) error {
+	db := a.db.WithContext(ctx)
+	config := a.config
+
+	client, err := requireOAuthServerClient(ctx, oauthserver.TokenExchangeGrantType)
+	if err != nil {
+		return err
+	}
+
+	subjectToken := r.FormValue("subject_token")
+	subjectTokenType := r.FormValue("subject_token_type")
+	if subjectToken == "" || subjectTokenType == "" {
+		return apierrors.NewOAuthError("invalid_request", "subject_token and subject_token_type are required")
+	}
+
+	if subjectTokenType != accessTokenTokenType && subjectTokenType != jwtTokenType {
+		return apierrors.NewOAuthError("invalid_request", "subject_token_type must be '"+accessTokenTokenType+"' or '"+jwtTokenType+"'")
+	}
+
+	if requestedTokenType := r.FormValue("requested_token_type"); requestedTokenType != "" && requestedTokenType != accessTokenTokenType {
+		return apierrors.NewOAuthError("invalid_request", "Only access tokens can be requested")
+	}
+
+	if r.FormValue("actor_token") != "" {
+		// the authenticated client is always the actor
+		return apierrors.NewOAuthError("invalid_request", "actor_token is not supported")
+	}
+
+	allowedAudiences := client.GetTokenExchangeAudiences()
+	audience := r.FormValue("audience")
+	if audience == "" && len(allowedAudiences) == 1 {
+		audience = allowedAudiences[0]
+	}
+
+	if audience == "" || !slices.Contains(allowedAudiences, audience) {
+		return apierrors.NewOAuthError("invalid_target", "The client is not allowed to exchange tokens for this audience")
+	}
+
+	subject := &TokenExchangeClaims{}
+	p := jwt.NewParser(jwt.WithValidMethods(config.JWT.ValidMethods))
+	if _, err := p.ParseWithClaims(subjectToken, subject, a.jwtKeyFunc); err != nil {
+		return apierrors.NewOAuthError("invalid_grant", "Invalid subject_token")
+	}
+
+	sessionID, err := uuid.FromString(subject.SessionId)
+	if err != nil || sessionID == uuid.Nil {
+		return apierrors.NewOAuthError("invalid_grant", "subject_token must be issued to a user")
+	}
+
+	session, err := models.FindSessionByID(db, sessionID, false)
+	if err != nil {
+		if models.IsNotFoundError(err) {
+			return apierrors.NewOAuthError("invalid_grant", "Invalid subject_token")
+		}
+		return apierrors.NewInternalServerError("Database error finding session").WithInternalError(err)
+	}
+
+	user, err := models.FindUserByID(db, session.UserID)
+	if err != nil {
+		if models.IsNotFoundError(err) {
+			return apierrors.NewOAuthError("invalid_grant", "Invalid subject_token")
+		}
+		return apierrors.NewInternalServerError("Database error finding user").WithInternalError(err)
+	}
+
+	if user.ID.String() != subject.Subject || !a.isActiveSession(session, user, nil) {
+		return apierrors.NewOAuthError("invalid_grant", "Invalid subject_token")
+	}
+
+	// subject tokens bound to a DPoP key can only be exchanged with a proof
+	// signed by that key, and the exchanged token stays bound to it
+	var dpopJKT *string
+	if subject.Confirmation != nil && subject.Confirmation.JKT != "" {
+		jkt := getDPoPJKT(ctx)
+		if jkt == "" {
+			return invalidDPoPProof("the subject_token is bound to a DPoP key")
+		}
+
+		if jkt != subject.Confirmation.JKT {
+			return invalidDPoPProof("the proof was not signed by the key the subject_token is bound to")
+		}
+
+		dpopJKT = &jkt
+	}
+
+	// exchanged tokens can never have more scopes than the subject token
+	// or the client is allowed
+	availableScopes := client.AllowedScopes()
+	if subject.Scope != "" {
+		subjectScopes := models.ParseOAuthScopes(subject.Scope)
+		availableScopes = slices.DeleteFunc(slices.Clone(availableScopes), func(scope string) bool {
+			return !slices.Contains(subjectScopes, scope)
+		})
+	}
+
+	scopes := models.ParseOAuthScopes(r.FormValue("scope"))
+	if len(scopes) == 0 {
+		scopes = availableScopes
+	}
+	for _, scope := range scopes {
+		if !slices.Contains(availableScopes, scope) {
+			return apierrors.NewOAuthError("invalid_scope", "Scope "+scope+" can't be requested for this subject_token")
+		}
+	}
+
+	issuedAt := time.Now().UTC()
+	expiresAt := issuedAt.Add(time.Second * time.Duration(config.JWT.Exp))
+	if subject.ExpiresAt != nil && subject.ExpiresAt.Before(expiresAt) {
+		// exchanged tokens never outlive the subject token
+		expiresAt = subject.ExpiresAt.Time
+	}
+
+	claims := &TokenExchangeClaims{
+		AccessTokenClaims: subject.AccessTokenClaims,
+		ClientID:          client.ClientID,
+		Actor: &ActorClaim{
+			Subject: client.ClientID,
+			Actor:   subject.Actor,
+		},
+	}
+	claims.Audience = jwt.ClaimStrings{audience}
+	claims.IssuedAt = jwt.NewNumericDate(issuedAt)
+	claims.ExpiresAt = jwt.NewNumericDate(expiresAt)
+	claims.Issuer = config.JWT.Issuer
+	claims.Scope = strings.Join(scopes, " ")
+
+	signed, err := signJwt(&config.JWT, claims)
+	if err != nil {
+		return apierrors.NewInternalServerError("Error signing access token").WithInternalError(err)
+	}
+
+	if err := models.NewAuditLogEntry(config.AuditLog, r, db, user, models.TokenExchangedAction, "", map[string]interface{}{
+		"client_id":  client.ClientID,
+		"audience":   audience,
+		"scope":      claims.Scope,
+		"session_id": session.ID,
+	}); err != nil {
+		return apierrors.NewInternalServerError("Error recording audit log entry").WithInternalError(err)
+	}
+
+	w.Header().Set("Cache-Control", "no-store")
+	return sendJSON(w, http.StatusOK, &TokenExchangeResponse{
+		Token:           signed,
+		IssuedTokenType: accessTokenTokenType,
+		TokenType:       accessTokenType(dpopJKT),
+		ExpiresIn:       int(time.Until(expiresAt).Seconds()),
+		ExpiresAt:       expiresAt.Unix(),
+		Scope:           claims.Scope,
+	})
+}
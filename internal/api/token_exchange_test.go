@@ -0,0 +1,142 @@
+package api
+
+import (
+	"encoding/json"
+	"net/http"
+	"net/http/httptest"
+	"net/url"
+	"strings"
+
+	"github.com/golang-jwt/jwt/v5"
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+	"github.com/supabase/auth/internal/api/oauthserver"
+	"github.com/supabase/auth/internal/models"
+)
+
+func (ts *TokenTestSuite) TestTokenExchangeGrant() {
+	client, secret := ts.createOAuthServerClient(oauthserver.TokenExchangeGrantType)
+	client.Scopes = "orders:read orders:write"
+	client.TokenExchangeAudiences = "https://orders.internal https://billing.internal"
+	require.NoError(ts.T(), ts.API.db.UpdateOnly(client, "scopes", "token_exchange_audiences"))
+
+	session, err := models.NewSession(ts.User.ID, nil)
+	require.NoError(ts.T(), err)
+	require.NoError(ts.T(), ts.API.db.Create(session))
+
+	req := httptest.NewRequest(http.MethodPost, "/token?grant_type=password", nil)
+	subjectToken, _, err := ts.API.generateAccessToken(req, ts.API.db, ts.User, &session.ID, models.PasswordGrant)
+	require.NoError(ts.T(), err)
+
+	form := url.Values{
+		"grant_type":         {oauthserver.TokenExchangeGrantType},
+		"subject_token":      {subjectToken},
+		"subject_token_type": {accessTokenTokenType},
+		"audience":           {"https://orders.internal"},
+		"scope":              {"orders:read"},
+	}
+
+	w := ts.oauthServerTokenRequest(client, secret, form)
+	require.Equal(ts.T(), http.StatusOK, w.Code, w.Body.String())
+
+	var token TokenExchangeResponse
+	require.NoError(ts.T(), json.NewDecoder(w.Body).Decode(&token))
+	assert.Equal(ts.T(), accessTokenTokenType, token.IssuedTokenType)
+	assert.Equal(ts.T(), "orders:read", token.Scope)
+
+	claims := &TokenExchangeClaims{}
+	_, err = jwt.ParseWithClaims(token.Token, claims, func(t *jwt.Token) (interface{}, error) {
+		return []byte(ts.Config.JWT.Secret), nil
+	})
+	require.NoError(ts.T(), err)
+	assert.Equal(ts.T(), ts.User.ID.String(), claims.Subject)
+	assert.Equal(ts.T(), jwt.ClaimStrings{"https://orders.internal"}, claims.Audience)
+	assert.Equal(ts.T(), session.ID.String(), claims.SessionId)
+	assert.Equal(ts.T(), "orders:read", claims.Scope)
+	require.NotNil(ts.T(), claims.Actor)
+	assert.Equal(ts.T(), client.ClientID, claims.Actor.Subject)
+
+	// the exchanged token can't be exchanged for more scopes
+	w = ts.oauthServerTokenRequest(client, secret, url.Values{
+		"grant_type":         {oauthserver.TokenExchangeGrantType},
+		"subject_token":      {token.Token},
+		"subject_token_type": {accessTokenTokenType},
+		"audience":           {"https://billing.internal"},
+		"scope":              {"orders:write"},
+	})
+	require.Equal(ts.T(), http.StatusBadRequest, w.Code)
+	assert.Contains(ts.T(), w.Body.String(), "invalid_scope")
+
+	// audiences that aren't allowed for the client are rejected
+	form.Set("audience", "https://admin.internal")
+	w = ts.oauthServerTokenRequest(client, secret, form)
+	require.Equal(ts.T(), http.StatusBadRequest, w.Code)
+	assert.Contains(ts.T(), w.Body.String(), "invalid_target")
+
+	// tokens of sessions that have ended can't be exchanged
+	require.NoError(ts.T(), models.LogoutSession(ts.API.db, session.ID))
+	form.Set("audience", "https://orders.internal")
+	w = ts.oauthServerTokenRequest(client, secret, form)
+	require.Equal(ts.T(), http.StatusBadRequest, w.Code)
+	assert.Contains(ts.T(), w.Body.String(), "invalid_grant")
+}
+
+func (ts *TokenTestSuite) TestTokenExchangeGrantDPoPBoundSubject() {
+	client, secret := ts.createOAuthServerClient(oauthserver.TokenExchangeGrantType)
+	client.TokenExchangeAudiences = "https://orders.internal"
+	require.NoError(ts.T(), ts.API.db.UpdateOnly(client, "token_exchange_audiences"))
+
+	key := ts.newDPoPKey()
+	w := ts.dpopTokenRequest("password", map[string]interface{}{
+		"email":    "test@example.com",
+		"password": "password",
+	}, ts.dpopProof(key))
+	require.Equal(ts.T(), http.StatusOK, w.Code, w.Body.String())
+
+	var response AccessTokenResponse
+	require.NoError(ts.T(), json.NewDecoder(w.Body).Decode(&response))
+
+	exchange := func(proof string) *httptest.ResponseRecorder {
+		form := url.Values{
+			"grant_type":         {oauthserver.TokenExchangeGrantType},
+			"subject_token":      {response.Token},
+			"subject_token_type": {accessTokenTokenType},
+		}
+
+		req := httptest.NewRequest(http.MethodPost, "http://localhost/token", strings.NewReader(form.Encode()))
+		req.Header.Set("Content-Type", "application/x-www-form-urlencoded")
+		req.SetBasicAuth(client.ClientID, secret)
+		if proof != "" {
+			req.Header.Set(dpopHeader, proof)
+		}
+
+		w := httptest.NewRecorder()
+		ts.API.handler.ServeHTTP(w, req)
+		return w
+	}
+
+	// bound subject tokens require a proof
+	w = exchange("")
+	assert.Equal(ts.T(), http.StatusBadRequest, w.Code)
+	assert.Contains(ts.T(), w.Body.String(), "invalid_dpop_proof")
+
+	// the proof must be signed by the bound key
+	w = exchange(ts.dpopProof(ts.newDPoPKey()))
+	assert.Equal(ts.T(), http.StatusBadRequest, w.Code)
+	assert.Contains(ts.T(), w.Body.String(), "invalid_dpop_proof")
+
+	w = exchange(ts.dpopProof(key))
+	require.Equal(ts.T(), http.StatusOK, w.Code, w.Body.String())
+
+	var token TokenExchangeResponse
+	require.NoError(ts.T(), json.NewDecoder(w.Body).Decode(&token))
+	assert.Equal(ts.T(), "DPoP", token.TokenType)
+
+	claims := &TokenExchangeClaims{}
+	_, err := jwt.ParseWithClaims(token.Token, claims, func(t *jwt.Token) (interface{}, error) {
+		return []byte(ts.Config.JWT.Secret), nil
+	})
+	require.NoError(ts.T(), err)
+	require.NotNil(ts.T(), claims.Confirmation)
+	assert.Equal(ts.T(), client.ClientID, claims.Actor.Subject)
+}
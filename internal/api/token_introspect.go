@@ -0,0 +1,285 @@
+package api
+
+import (
+	"net/http"
+	"slices"
+	"time"
+
+	"github.com/gofrs/uuid"
+	"github.com/golang-jwt/jwt/v5"
+	"github.com/supabase/auth/internal/api/apierrors"
+	"github.com/supabase/auth/internal/models"
+	"github.com/supabase/auth/internal/storage"
+)
+
+// IntrospectionResponse is the RFC 7662 token introspection response. Only
+// Active is set for tokens that are not active.
+type IntrospectionResponse struct {
+	Active    bool             `json:"active"`
+	Scope     string           `json:"scope,omitempty"`
+	ClientID  string           `json:"client_id,omitempty"`
+	TokenType string           `json:"token_type,omitempty"`
+	Exp       int64            `json:"exp,omitempty"`
+	Iat       int64            `json:"iat,omitempty"`
+	Sub       string           `json:"sub,omitempty"`
+	Aud       jwt.ClaimStrings `json:"aud,omitempty"`
+	Iss       string           `json:"iss,omitempty"`
+	SessionID string           `json:"session_id,omitempty"`
+}
+
+// introspectionClaims are the claims read from introspected access tokens,
+// covering both user and client_credentials tokens.
+type introspectionClaims struct {
+	AccessTokenClaims
+	ClientID string `json:"client_id,omitempty"`
+}
+
+// OAuthIntrospect implements RFC 7662 token introspection for OAuth server
+// clients, reporting whether an access or refresh token is still active.
+// Access tokens are only active while the session they were issued for
+// exists, so that signed out sessions are reported before the JWT expires.
+func (a *API) OAuthIntrospect(w http.ResponseWriter, r *http.Request) error {
+	ctx := r.Context()
+	db := a.db.WithContext(ctx)
+
+	client, err := requireOAuthServerClientAuth(ctx)
+	if err != nil {
+		return err
+	}
+
+	token := r.FormValue("token")
+	if token == "" {
+		return apierrors.NewOAuthError("invalid_request", "token is required")
+	}
+
+	var response *IntrospectionResponse
+	switch r.FormValue("token_type_hint") {
+	case "refresh_token":
+		response, err = a.introspectRefreshToken(db, client, token)
+		if err == nil && !response.Active {
+			response, err = a.introspectAccessToken(db, client, token)
+		}
+	default:
+		response, err = a.introspectAccessToken(db, client, token)
+		if err == nil && !response.Active {
+			response, err = a.introspectRefreshToken(db, client, token)
+		}
+	}
+	if err != nil {
+		return apierrors.NewInternalServerError("Error introspecting token").WithInternalError(err)
+	}
+
+	w.Header().Set("Cache-Control", "no-store")
+	return sendJSON(w, http.StatusOK, response)
+}
+
+// introspectAccessToken verifies an access token and checks that its
+// session, user or client is still valid. Access tokens are only disclosed to
+// the client they were issued to or to clients named in their audience.
+func (a *API) introspectAccessToken(db *storage.Connection, client *models.OAuthServerClient, token string) (*IntrospectionResponse, error) {
+	config := a.config
+	inactive := &IntrospectionResponse{Active: false}
+
+	claims := &introspectionClaims{}
+	p := jwt.NewParser(jwt.WithValidMethods(config.JWT.ValidMethods))
+	if _, err := p.ParseWithClaims(token, claims, a.jwtKeyFunc); err != nil {
+		return inactive, nil
+	}
+
+	response := &IntrospectionResponse{
+		Active:    true,
+		Scope:     claims.Scope,
+		ClientID:  claims.ClientID,
+		TokenType: "Bearer",
+		Sub:       claims.Subject,
+		Aud:       claims.Audience,
+		Iss:       claims.Issuer,
+		SessionID: claims.SessionId,
+	}
+	if claims.ExpiresAt != nil {
+		response.Exp = claims.ExpiresAt.Unix()
+	}
+	if claims.IssuedAt != nil {
+		response.Iat = claims.IssuedAt.Unix()
+	}
+
+	if claims.SessionId == "" || claims.SessionId == uuid.Nil.String() {
+		// issued through the client_credentials grant
+		if claims.ClientID == "" || claims.ClientID != claims.Subject {
+			return inactive, nil
+		}
+		if _, err := models.FindOAuthServerClientByClientID(db, claims.ClientID); err != nil {
+			if models.IsNotFoundError(err) {
+				return inactive, nil
+			}
+			return nil, err
+		}
+		if !isIntrospectableBy(response, client) {
+			return inactive, nil
+		}
+		return response, nil
+	}
+
+	sessionID, err := uuid.FromString(claims.SessionId)
+	if err != nil {
+		return inactive, nil
+	}
+
+	session, err := models.FindSessionByID(db, sessionID, false)
+	if err != nil {
+		if models.IsNotFoundError(err) {
+			return inactive, nil
+		}
+		return nil, err
+	}
+
+	user, err := models.FindUserByID(db, session.UserID)
+	if err != nil {
+		if models.IsNotFoundError(err) {
+			return inactive, nil
+		}
+		return nil, err
+	}
+
+	if !a.isActiveSession(session, user, nil) || user.ID.String() != claims.Subject {
+		return inactive, nil
+	}
+
+	if response.ClientID == "" && session.OAuthClientID != nil {
+		sessionClient, err := models.FindOAuthServerClientByID(db, *session.OAuthClientID)
+		if err != nil {
+			if models.IsNotFoundError(err) {
+				return inactive, nil
+			}
+			return nil, err
+		}
+		response.ClientID = sessionClient.ClientID
+	}
+
+	if !isIntrospectableBy(response, client) {
+		return inactive, nil
+	}
+
+	return response, nil
+}
+
+// isIntrospectableBy returns whether the introspected access token was issued
+// to the client or names it in its audience.
+func isIntrospectableBy(response *IntrospectionResponse, client *models.OAuthServerClient) bool {
+	return response.ClientID == client.ClientID || slices.Contains(response.Aud, client.ClientID)
+}
+
+// introspectRefreshToken checks that a refresh token issued to the client
+// has not been revoked and that its session is still valid.
+func (a *API) introspectRefreshToken(db *storage.Connection, client *models.OAuthServerClient, token string) (*IntrospectionResponse, error) {
+	inactive := &IntrospectionResponse{Active: false}
+
+	user, refreshToken, session, err := models.FindUserWithRefreshToken(db, token, false)
+	if err != nil {
+		if models.IsNotFoundError(err) {
+			return inactive, nil
+		}
+		return nil, err
+	}
+
+	if refreshToken.Revoked || session == nil {
+		return inactive, nil
+	}
+
+	// refresh tokens are only disclosed to the client they were issued to
+	if session.OAuthClientID == nil || *session.OAuthClientID != client.ID {
+		return inactive, nil
+	}
+
+	if !a.isActiveSession(session, user, &refreshToken.UpdatedAt) {
+		return inactive, nil
+	}
+
+	response := &IntrospectionResponse{
+		Active:    true,
+		ClientID:  client.ClientID,
+		TokenType: "refresh_token",
+		Iat:       refreshToken.CreatedAt.Unix(),
+		Sub:       user.ID.String(),
+		Aud:       jwt.ClaimStrings{user.Aud},
+		Iss:       a.config.JWT.Issuer,
+		SessionID: session.ID.String(),
+	}
+	if session.Scopes != nil {
+		response.Scope = *session.Scopes
+	}
+
+	return response, nil
+}
+
+// isActiveSession returns whether tokens issued for the session may still be
+// used by the user.
+func (a *API) isActiveSession(session *models.Session, user *models.User, refreshTokenTime *time.Time) bool {
+	config := a.config
+
+	if user.IsBanned() {
+		return false
+	}
+
+	sessionValidityConfig := models.SessionValidityConfig{
+		Timebox:           config.Sessions.Timebox,
+		InactivityTimeout: config.Sessions.InactivityTimeout,
+		AllowLowAAL:       config.Sessions.AllowLowAAL,
+	}
+
+	return session.CheckValidity(sessionValidityConfig, time.Now(), refreshTokenTime, user.HighestPossibleAAL()) == models.SessionValid
+}
+
+// OAuthRevoke implements RFC 7009 token revocation for refresh tokens issued
+// to OAuth server clients. Revoking a refresh token signs out its session, so
+// that access tokens issued for it are no longer reported as active.
+func (a *API) OAuthRevoke(w http.ResponseWriter, r *http.Request) error {
+	ctx := r.Context()
+	db := a.db.WithContext(ctx)
+	config := a.config
+
+	client, err := requireOAuthServerClientAuth(ctx)
+	if err != nil {
+		return err
+	}
+
+	token := r.FormValue("token")
+	if token == "" {
+		return apierrors.NewOAuthError("invalid_request", "token is required")
+	}
+
+	if hint := r.FormValue("token_type_hint"); hint == "access_token" {
+		return apierrors.NewOAuthError("unsupported_token_type", "Only refresh tokens can be revoked")
+	}
+
+	err = db.Transaction(func(tx *storage.Connection) error {
+		user, _, session, terr := models.FindUserWithRefreshToken(tx, token, false)
+		if terr != nil {
+			if models.IsNotFoundError(terr) {
+				// invalid tokens don't need to be revoked
+				return nil
+			}
+			return apierrors.NewInternalServerError("Error finding refresh token").WithInternalError(terr)
+		}
+
+		if session == nil || session.OAuthClientID == nil || *session.OAuthClientID != client.ID {
+			// tokens issued to other clients are left untouched
+			return nil
+		}
+
+		if terr := models.LogoutSession(tx, session.ID); terr != nil {
+			return apierrors.NewInternalServerError("Error revoking refresh token").WithInternalError(terr)
+		}
+
+		return models.NewAuditLogEntry(config.AuditLog, r, tx, user, models.TokenRevokedAction, "", map[string]interface{}{
+			"client_id":  client.ClientID,
+			"session_id": session.ID,
+		})
+	})
+	if err != nil {
+		return err
+	}
+
+	w.WriteHeader(http.StatusOK)
+	return nil
+}
@@ -0,0 +1,112 @@
+package api
+
+import (
+	"encoding/json"
+	"net/http"
+	"net/http/httptest"
+	"net/url"
+
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+	"github.com/supabase/auth/internal/api/oauthserver"
+	"github.com/supabase/auth/internal/crypto"
+	"github.com/supabase/auth/internal/models"
+	"github.com/supabase/auth/internal/storage"
+)
+
+func (ts *TokenTestSuite) introspect(client *models.OAuthServerClient, secret string, form url.Values) *IntrospectionResponse {
+	w := ts.oauthServerRequest("/oauth/introspect", client, secret, form)
+	require.Equal(ts.T(), http.StatusOK, w.Code, w.Body.String())
+
+	var response IntrospectionResponse
+	require.NoError(ts.T(), json.NewDecoder(w.Body).Decode(&response))
+	return &response
+}
+
+func (ts *TokenTestSuite) TestOAuthIntrospectAndRevoke() {
+	client, secret := ts.createOAuthServerClient("authorization_code", "refresh_token")
+	codeVerifier := crypto.SecureAlphanumeric(64)
+	authorization := ts.createApprovedAuthorization(client, codeVerifier)
+
+	w := ts.oauthServerTokenRequest(client, secret, url.Values{
+		"grant_type":    {"authorization_code"},
+		"code":          {authorization.AuthorizationCode.String()},
+		"code_verifier": {codeVerifier},
+	})
+	require.Equal(ts.T(), http.StatusOK, w.Code, w.Body.String())
+
+	var token AccessTokenResponse
+	require.NoError(ts.T(), json.NewDecoder(w.Body).Decode(&token))
+
+	response := ts.introspect(client, secret, url.Values{"token": {token.Token}})
+	assert.True(ts.T(), response.Active)
+	assert.Equal(ts.T(), ts.User.ID.String(), response.Sub)
+	assert.Equal(ts.T(), client.ClientID, response.ClientID)
+
+	// tokens are only disclosed to the client they were issued to
+	otherClient, otherSecret := ts.createOAuthServerClient("client_credentials")
+	response = ts.introspect(otherClient, otherSecret, url.Values{"token": {token.Token}})
+	assert.False(ts.T(), response.Active)
+
+	response = ts.introspect(otherClient, otherSecret, url.Values{"token": {token.RefreshToken}, "token_type_hint": {"refresh_token"}})
+	assert.False(ts.T(), response.Active)
+
+	response = ts.introspect(client, secret, url.Values{"token": {token.RefreshToken}, "token_type_hint": {"refresh_token"}})
+	assert.True(ts.T(), response.Active)
+	assert.Equal(ts.T(), "refresh_token", response.TokenType)
+
+	w = ts.oauthServerRequest("/oauth/revoke", client, secret, url.Values{"token": {token.RefreshToken}})
+	require.Equal(ts.T(), http.StatusOK, w.Code, w.Body.String())
+
+	// revoking the refresh token signs out the session of the access token
+	response = ts.introspect(client, secret, url.Values{"token": {token.Token}})
+	assert.False(ts.T(), response.Active)
+
+	response = ts.introspect(client, secret, url.Values{"token": {"not-a-token"}})
+	assert.False(ts.T(), response.Active)
+}
+
+func (ts *TokenTestSuite) TestOAuthIntrospectAudience() {
+	resourceClient, resourceSecret := ts.createOAuthServerClient("client_credentials")
+	client, secret := ts.createOAuthServerClient(oauthserver.TokenExchangeGrantType)
+	client.TokenExchangeAudiences = storage.NullString(resourceClient.ClientID)
+	require.NoError(ts.T(), ts.API.db.UpdateOnly(client, "token_exchange_audiences"))
+
+	session, err := models.NewSession(ts.User.ID, nil)
+	require.NoError(ts.T(), err)
+	require.NoError(ts.T(), ts.API.db.Create(session))
+
+	req := httptest.NewRequest(http.MethodPost, "/token?grant_type=password", nil)
+	subjectToken, _, err := ts.API.generateAccessToken(req, ts.API.db, ts.User, &session.ID, models.PasswordGrant)
+	require.NoError(ts.T(), err)
+
+	// first-party tokens aren't disclosed to OAuth server clients
+	response := ts.introspect(resourceClient, resourceSecret, url.Values{"token": {subjectToken}})
+	assert.False(ts.T(), response.Active)
+
+	w := ts.oauthServerTokenRequest(client, secret, url.Values{
+		"grant_type":         {oauthserver.TokenExchangeGrantType},
+		"subject_token":      {subjectToken},
+		"subject_token_type": {accessTokenTokenType},
+	})
+	require.Equal(ts.T(), http.StatusOK, w.Code, w.Body.String())
+
+	var token TokenExchangeResponse
+	require.NoError(ts.T(), json.NewDecoder(w.Body).Decode(&token))
+
+	// clients named in the audience can introspect the token
+	response = ts.introspect(resourceClient, resourceSecret, url.Values{"token": {token.Token}})
+	assert.True(ts.T(), response.Active)
+	assert.Equal(ts.T(), client.ClientID, response.ClientID)
+
+	otherClient, otherSecret := ts.createOAuthServerClient("client_credentials")
+	response = ts.introspect(otherClient, otherSecret, url.Values{"token": {token.Token}})
+	assert.False(ts.T(), response.Active)
+}
+
+func (ts *TokenTestSuite) TestOAuthIntrospectRequiresClientAuthentication() {
+	client, _ := ts.createOAuthServerClient("client_credentials")
+
+	w := ts.oauthServerRequest("/oauth/introspect", client, "wrong", url.Values{"token": {"token"}})
+	assert.Equal(ts.T(), http.StatusBadRequest, w.Code)
+}
@@ -0,0 +1,432 @@
+package api
+
+import (
+	"context"
+	"net/http"
+	"slices"
+	"strings"
+	"time"
+
+	"github.com/golang-jwt/jwt/v5"
+
+	"github.com/supabase/auth/internal/api/apierrors"
+	"github.com/supabase/auth/internal/api/oauthserver"
+	"github.com/supabase/auth/internal/metering"
+	"github.com/supabase/auth/internal/models"
+	"github.com/supabase/auth/internal/storage"
+)
+
+// ClientCredentialsTokenResponse is the response to a client_credentials
+// grant. No refresh token is issued as the client can always authenticate
+// again.
+type ClientCredentialsTokenResponse struct {
+	Token     string `json:"access_token"`
+	TokenType string `json:"token_type"` // Bearer
+	ExpiresIn int    `json:"expires_in"`
+	ExpiresAt int64  `json:"expires_at"`
+	Scope     string `json:"scope,omitempty"`
+}
+
+// ClientCredentialsClaims are the claims of access tokens issued to OAuth
+// server clients acting on their own behalf.
+type ClientCredentialsClaims struct {
+	jwt.RegisteredClaims
+	Role     string `json:"role"`
+	ClientID string `json:"client_id"`
+	Scope    string `json:"scope,omitempty"`
+}
+
+// IDTokenClaims are the claims of OpenID Connect ID tokens issued to OAuth
+// server clients that requested the openid scope.
+type IDTokenClaims struct {
+	jwt.RegisteredClaims
+	OIDCUserClaims
+	Nonce     string   `json:"nonce,omitempty"`
+	AuthTime  int64    `json:"auth_time"`
+	AMR       []string `json:"amr,omitempty"`
+	ACR       string   `json:"acr,omitempty"`
+	SessionID string   `json:"sid,omitempty"`
+}
+
+// requireOAuthServerClientAuth returns the OAuth server client authenticated
+// by the oauthClientAuth middleware.
+func requireOAuthServerClientAuth(ctx context.Context) (*models.OAuthServerClient, error) {
+	client := oauthserver.GetOAuthServerClient(ctx)
+	if client == nil {
+		return nil, apierrors.NewOAuthError("invalid_client", "Client authentication is required")
+	}
+	return client, nil
+}
+
+// requireOAuthServerClient returns the OAuth server client authenticated by
+// the oauthClientAuth middleware, making sure it may use the provided grant.
+func requireOAuthServerClient(ctx context.Context, grantType string) (*models.OAuthServerClient, error) {
+	client, err := requireOAuthServerClientAuth(ctx)
+	if err != nil {
+		return nil, err
+	}
+
+	if !client.HasGrantType(grantType) {
+		return nil, apierrors.NewOAuthError("unauthorized_client", "Client is not allowed to use the "+grantType+" grant")
+	}
+
+	return client, nil
+}
+
+// narrowOAuthScope returns the scopes requested at the token endpoint, which
+// can only narrow down the scopes the user authorized. All of the authorized
+// scopes are granted when none are requested. Authorizations without scopes
+// grant the scopes the client is allowed to request.
+func narrowOAuthScope(client *models.OAuthServerClient, authorized, requested string) (string, error) {
+	authorizedScopes := models.ParseOAuthScopes(authorized)
+	if len(authorizedScopes) == 0 {
+		authorizedScopes = client.AllowedScopes()
+	}
+	requestedScopes := models.ParseOAuthScopes(requested)
+	if len(requestedScopes) == 0 {
+		return strings.Join(authorizedScopes, " "), nil
+	}
+
+	for _, scope := range requestedScopes {
+		if !slices.Contains(authorizedScopes, scope) {
+			return "", apierrors.NewOAuthError("invalid_scope", "Scope "+scope+" was not authorized by the user")
+		}
+	}
+	return strings.Join(requestedScopes, " "), nil
+}
+
+// AuthorizationCodeGrant implements the OAuth 2.1 authorization_code grant
+// for OAuth server clients, redeeming a code issued by /oauth/authorize.
+func (a *API) AuthorizationCodeGrant(ctx context.Context, w http.ResponseWriter, r *http.Request) error {
+	db := a.db.WithContext(ctx)
+	config := a.config
+
+	client, err := requireOAuthServerClient(ctx, "authorization_code")
+	if err != nil {
+		return err
+	}
+
+	code := r.FormValue("code")
+	codeVerifier := r.FormValue("code_verifier")
+	redirectURI := r.FormValue("redirect_uri")
+
+	if code == "" || codeVerifier == "" {
+		return apierrors.NewOAuthError("invalid_request", "code and code_verifier are required")
+	}
+
+	var grantParams models.GrantParams
+	grantParams.FillGrantParams(r)
+	grantParams.OAuthClientID = &client.ID
+
+	var user *models.User
+	var token *AccessTokenResponse
+	err = db.Transaction(func(tx *storage.Connection) error {
+		authorization, terr := models.FindOAuthServerAuthorizationByCode(tx, code)
+		if terr != nil {
+			if models.IsNotFoundError(terr) {
+				return apierrors.NewOAuthError("invalid_grant", "Invalid authorization code")
+			}
+			return apierrors.NewInternalServerError("Database error loading authorization code").WithInternalError(terr)
+		}
+
+		if authorization.ClientID != client.ID || authorization.UserID == nil {
+			return apierrors.NewOAuthError("invalid_grant", "Invalid authorization code")
+		}
+
+		if authorization.IsExpired() {
+			return apierrors.NewOAuthError("invalid_grant", "Authorization code has expired")
+		}
+
+		// redirect_uri is required when it was part of the authorization request
+		if (redirectURI != "" || authorization.RedirectURIProvided) && redirectURI != authorization.RedirectURI {
+			return apierrors.NewOAuthError("invalid_grant", "redirect_uri does not match the authorization request")
+		}
+
+		if terr := authorization.VerifyPKCE(codeVerifier); terr != nil {
+			return apierrors.NewOAuthError("invalid_grant", terr.Error())
+		}
+
+		scope, terr := narrowOAuthScope(client, authorization.Scope, r.FormValue("scope"))
+		if terr != nil {
+			return terr
+		}
+		grantParams.Scopes = &scope
+
+		// authorization codes can only be used once
+		if terr := authorization.MarkExpired(tx); terr != nil {
+			return apierrors.NewInternalServerError("Database error updating authorization").WithInternalError(terr)
+		}
+
+		user, terr = models.FindUserByID(tx, *authorization.UserID)
+		if terr != nil {
+			if models.IsNotFoundError(terr) {
+				return apierrors.NewOAuthError("invalid_grant", "Invalid authorization code")
+			}
+			return apierrors.NewInternalServerError("Database error finding user").WithInternalError(terr)
+		}
+
+		if user.IsBanned() {
+			return apierrors.NewBadRequestError(apierrors.ErrorCodeUserBanned, "User is banned")
+		}
+
+		if terr := models.NewAuditLogEntry(config.AuditLog, r, tx, user, models.LoginAction, "", map[string]interface{}{
+			"provider_type": "oauth_provider",
+			"client_id":     client.ClientID,
+		}); terr != nil {
+			return terr
+		}
+
+		token, terr = a.issueRefreshToken(r, tx, user, models.OAuthProviderAuthorizationCode, grantParams)
+		if terr != nil {
+			return terr
+		}
+		token.Scope = scope
+
+		if slices.Contains(models.ParseOAuthScopes(scope), "openid") {
+			token.IDToken, terr = a.generateIDToken(tx, user, client, scope, authorization.Nonce.String(), token.RefreshToken, authorization.Authentication())
+		}
+		return terr
+	})
+	if err != nil {
+		return err
+	}
+
+	metering.RecordLogin(metering.LoginTypeOAuthProvider, user.ID, &metering.LoginData{
+		Extra: map[string]interface{}{
+			"client_id": client.ClientID,
+		},
+	})
+	return sendJSON(w, http.StatusOK, token)
+}
+
+// DeviceCodeGrant implements the RFC 8628 device authorization grant, which
+// devices use to poll for tokens while the user approves the request.
+func (a *API) DeviceCodeGrant(ctx context.Context, w http.ResponseWriter, r *http.Request) error {
+	db := a.db.WithContext(ctx)
+	config := a.config
+
+	client, err := requireOAuthServerClient(ctx, oauthserver.DeviceCodeGrantType)
+	if err != nil {
+		return err
+	}
+
+	deviceCode := r.FormValue("device_code")
+	if deviceCode == "" {
+		return apierrors.NewOAuthError("invalid_request", "device_code is required")
+	}
+
+	var grantParams models.GrantParams
+	grantParams.FillGrantParams(r)
+	grantParams.OAuthClientID = &client.ID
+
+	var user *models.User
+	var token *AccessTokenResponse
+	var pollErr error
+	err = db.Transaction(func(tx *storage.Connection) error {
+		authorization, terr := models.FindOAuthServerDeviceAuthorizationByDeviceCode(tx, deviceCode)
+		if terr != nil {
+			if models.IsNotFoundError(terr) {
+				return apierrors.NewOAuthError("invalid_grant", "Invalid device code")
+			}
+			return apierrors.NewInternalServerError("Database error loading device code").WithInternalError(terr)
+		}
+
+		if authorization.ClientID != client.ID {
+			return apierrors.NewOAuthError("invalid_grant", "Invalid device code")
+		}
+
+		if authorization.IsExpired() {
+			return apierrors.NewOAuthError("expired_token", "Device code has expired")
+		}
+
+		scope, terr := narrowOAuthScope(client, authorization.Scope, r.FormValue("scope"))
+		if terr != nil {
+			return terr
+		}
+		grantParams.Scopes = &scope
+
+		switch authorization.Status {
+		case models.OAuthServerAuthorizationDenied:
+			return apierrors.NewOAuthError("access_denied", "The user denied the authorization request")
+
+		case models.OAuthServerAuthorizationPending:
+			ok, terr := authorization.RecordPoll(tx)
+			if terr != nil {
+				return apierrors.NewInternalServerError("Database error updating device code").WithInternalError(terr)
+			}
+			// the polling state must be committed, so the error is
+			// returned after the transaction
+			if ok {
+				pollErr = apierrors.NewOAuthError("authorization_pending", "The user has not yet approved the authorization request")
+			} else {
+				pollErr = apierrors.NewOAuthError("slow_down", "Polling too frequently")
+			}
+			return nil
+		}
+
+		// device codes can only be used once
+		if terr := authorization.MarkExpired(tx); terr != nil {
+			return apierrors.NewInternalServerError("Database error updating device code").WithInternalError(terr)
+		}
+
+		if authorization.UserID == nil {
+			return apierrors.NewOAuthError("invalid_grant", "Invalid device code")
+		}
+
+		user, terr = models.FindUserByID(tx, *authorization.UserID)
+		if terr != nil {
+			if models.IsNotFoundError(terr) {
+				return apierrors.NewOAuthError("invalid_grant", "Invalid device code")
+			}
+			return apierrors.NewInternalServerError("Database error finding user").WithInternalError(terr)
+		}
+
+		if user.IsBanned() {
+			return apierrors.NewBadRequestError(apierrors.ErrorCodeUserBanned, "User is banned")
+		}
+
+		if terr := models.NewAuditLogEntry(config.AuditLog, r, tx, user, models.LoginAction, "", map[string]interface{}{
+			"provider_type": "oauth_provider",
+			"client_id":     client.ClientID,
+		}); terr != nil {
+			return terr
+		}
+
+		token, terr = a.issueRefreshToken(r, tx, user, models.OAuthProviderDeviceCode, grantParams)
+		if terr != nil {
+			return terr
+		}
+		token.Scope = scope
+
+		if slices.Contains(models.ParseOAuthScopes(scope), "openid") {
+			token.IDToken, terr = a.generateIDToken(tx, user, client, scope, "", token.RefreshToken, authorization.Authentication())
+		}
+		return terr
+	})
+	if err != nil {
+		return err
+	}
+	if pollErr != nil {
+		return pollErr
+	}
+
+	metering.RecordLogin(metering.LoginTypeOAuthProvider, user.ID, &metering.LoginData{
+		Extra: map[string]interface{}{
+			"client_id": client.ClientID,
+		},
+	})
+	return sendJSON(w, http.StatusOK, token)
+}
+
+// ClientCredentialsGrant implements the OAuth 2.1 client_credentials grant,
+// issuing an access token for the authenticated OAuth server client itself.
+// The token's audience and scopes come from the client's registration.
+func (a *API) ClientCredentialsGrant(ctx context.Context, w http.ResponseWriter, r *http.Request) error {
+	config := a.config
+
+	client, err := requireOAuthServerClient(ctx, "client_credentials")
+	if err != nil {
+		return err
+	}
+
+	scopes := client.GetScopes()
+	if requested := models.ParseOAuthScopes(r.FormValue("scope")); len(requested) > 0 {
+		for _, scope := range requested {
+			if !slices.Contains(scopes, scope) {
+				return apierrors.NewOAuthError("invalid_scope", "Scope "+scope+" has not been registered for the client")
+			}
+		}
+		scopes = requested
+	}
+
+	audience := client.Audience.String()
+	if audience == "" {
+		audience = config.JWT.Aud
+	}
+
+	if resource := getOAuthResource(ctx); resource != nil {
+		if !resource.AllowsClient(client) {
+			return apierrors.NewOAuthError("invalid_target", "The client is not allowed to request tokens for this resource")
+		}
+		audience = resource.Resource
+	}
+
+	expiresIn := a.accessTokenExpiry(ctx)
+	issuedAt := time.Now().UTC()
+	expiresAt := issuedAt.Add(time.Second * time.Duration(expiresIn))
+
+	claims := &ClientCredentialsClaims{
+		RegisteredClaims: jwt.RegisteredClaims{
+			Subject:   client.ClientID,
+			Audience:  jwt.ClaimStrings{audience},
+			IssuedAt:  jwt.NewNumericDate(issuedAt),
+			ExpiresAt: jwt.NewNumericDate(expiresAt),
+			Issuer:    config.JWT.Issuer,
+		},
+		Role:     config.OAuthServer.ClientCredentialsRole,
+		ClientID: client.ClientID,
+		Scope:    strings.Join(scopes, " "),
+	}
+
+	signed, err := signJwt(&config.JWT, claims)
+	if err != nil {
+		return apierrors.NewInternalServerError("Error signing access token").WithInternalError(err)
+	}
+
+	return sendJSON(w, http.StatusOK, &ClientCredentialsTokenResponse{
+		Token:     signed,
+		TokenType: "bearer",
+		ExpiresIn: expiresIn,
+		ExpiresAt: expiresAt.Unix(),
+		Scope:     claims.Scope,
+	})
+}
+
+// generateIDToken issues an OpenID Connect ID token for the session the
+// refresh token belongs to. The audience of ID tokens is always the client.
+// The acr, amr and auth_time claims describe how the user authenticated when
+// giving consent, falling back to the new session for authorizations that
+// didn't record it.
+func (a *API) generateIDToken(tx *storage.Connection, user *models.User, client *models.OAuthServerClient, scope, nonce, refreshToken string, authentication *models.OAuthServerAuthentication) (string, error) {
+	config := a.config
+
+	_, _, session, err := models.FindUserWithRefreshToken(tx, refreshToken, false)
+	if err != nil {
+		return "", apierrors.NewInternalServerError("Database error finding session").WithInternalError(err)
+	}
+	if session == nil {
+		return "", apierrors.NewInternalServerError("Session is required to issue ID token")
+	}
+
+	if authentication == nil {
+		authentication, err = models.NewOAuthServerAuthentication(session, user)
+		if err != nil {
+			return "", apierrors.NewInternalServerError("Error calculating session AAL").WithInternalError(err)
+		}
+	}
+
+	issuedAt := time.Now().UTC()
+	expiresAt := issuedAt.Add(time.Second * time.Duration(config.JWT.Exp))
+
+	claims := &IDTokenClaims{
+		RegisteredClaims: jwt.RegisteredClaims{
+			Subject:   user.ID.String(),
+			Audience:  jwt.ClaimStrings{client.ClientID},
+			IssuedAt:  jwt.NewNumericDate(issuedAt),
+			ExpiresAt: jwt.NewNumericDate(expiresAt),
+			Issuer:    a.oauthIssuer(),
+		},
+		OIDCUserClaims: oidcUserClaims(user, models.ParseOAuthScopes(scope)),
+		Nonce:          nonce,
+		AuthTime:       authentication.AuthTime.Unix(),
+		AMR:            authentication.AMR,
+		ACR:            authentication.AAL,
+		SessionID:      session.ID.String(),
+	}
+
+	signed, err := signJwt(&config.JWT, claims)
+	if err != nil {
+		return "", apierrors.NewInternalServerError("Error signing ID token").WithInternalError(err)
+	}
+	return signed, nil
+}
@@ -0,0 +1,497 @@
+package api
+
+import (
+	"bytes"
+	"crypto/sha256"
+	"encoding/base64"
+	"encoding/json"
+	"net/http"
+	"net/http/httptest"
+	"net/url"
+	"strings"
+	"time"
+
+	"github.com/golang-jwt/jwt/v5"
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+	"github.com/supabase/auth/internal/api/apierrors"
+	"github.com/supabase/auth/internal/api/oauthserver"
+	"github.com/supabase/auth/internal/crypto"
+	"github.com/supabase/auth/internal/models"
+	"golang.org/x/crypto/bcrypt"
+)
+
+func (ts *TokenTestSuite) createOAuthServerClient(grantTypes ...string) (*models.OAuthServerClient, string) {
+	secret := crypto.SecureAlphanumeric(64)
+	hash, err := bcrypt.GenerateFromPassword([]byte(secret), bcrypt.MinCost)
+	require.NoError(ts.T(), err)
+
+	client := &models.OAuthServerClient{
+		ClientID:         crypto.SecureAlphanumeric(32),
+		ClientSecretHash: string(hash),
+		RegistrationType: "manual",
+	}
+	client.SetRedirectURIs([]string{"https://example.com/callback"})
+	client.SetGrantTypes(grantTypes)
+	require.NoError(ts.T(), models.CreateOAuthServerClient(ts.API.db, client))
+
+	return client, secret
+}
+
+func (ts *TokenTestSuite) oauthServerTokenRequest(client *models.OAuthServerClient, secret string, form url.Values) *httptest.ResponseRecorder {
+	return ts.oauthServerRequest("/token", client, secret, form)
+}
+
+func (ts *TokenTestSuite) oauthServerRequest(path string, client *models.OAuthServerClient, secret string, form url.Values) *httptest.ResponseRecorder {
+	req := httptest.NewRequest(http.MethodPost, "http://localhost"+path, strings.NewReader(form.Encode()))
+	req.Header.Set("Content-Type", "application/x-www-form-urlencoded")
+	req.SetBasicAuth(client.ClientID, secret)
+
+	w := httptest.NewRecorder()
+	ts.API.handler.ServeHTTP(w, req)
+	return w
+}
+
+func (ts *TokenTestSuite) createApprovedAuthorization(client *models.OAuthServerClient, codeVerifier string) *models.OAuthServerAuthorization {
+	hashed := sha256.Sum256([]byte(codeVerifier))
+	codeChallenge := base64.RawURLEncoding.EncodeToString(hashed[:])
+
+	authorization := models.NewOAuthServerAuthorization(client, "https://example.com/callback", "", "", codeChallenge, models.SHA256, time.Minute)
+	authorization.RedirectURIProvided = true
+	require.NoError(ts.T(), ts.API.db.Create(authorization))
+	require.NoError(ts.T(), authorization.SetUser(ts.API.db, ts.User.ID))
+	require.NoError(ts.T(), authorization.Approve(ts.API.db, nil, time.Minute))
+
+	return authorization
+}
+
+func (ts *TokenTestSuite) TestAuthorizationCodeGrant() {
+	client, secret := ts.createOAuthServerClient("authorization_code", "refresh_token")
+	codeVerifier := crypto.SecureAlphanumeric(64)
+	authorization := ts.createApprovedAuthorization(client, codeVerifier)
+
+	form := url.Values{
+		"grant_type":    {"authorization_code"},
+		"code":          {authorization.AuthorizationCode.String()},
+		"code_verifier": {codeVerifier},
+		"redirect_uri":  {"https://example.com/callback"},
+	}
+
+	w := ts.oauthServerTokenRequest(client, secret, form)
+	require.Equal(ts.T(), http.StatusOK, w.Code, w.Body.String())
+
+	var token AccessTokenResponse
+	require.NoError(ts.T(), json.NewDecoder(w.Body).Decode(&token))
+	assert.NotEmpty(ts.T(), token.Token)
+	assert.NotEmpty(ts.T(), token.RefreshToken)
+
+	sessions, err := models.FindAllSessionsForUser(ts.API.db, ts.User.ID, false)
+	require.NoError(ts.T(), err)
+
+	found := false
+	for _, session := range sessions {
+		if session.OAuthClientID != nil && *session.OAuthClientID == client.ID {
+			found = true
+		}
+	}
+	assert.True(ts.T(), found, "session should be associated with the OAuth client")
+
+	// authorization codes can only be redeemed once
+	w = ts.oauthServerTokenRequest(client, secret, form)
+	assert.Equal(ts.T(), http.StatusBadRequest, w.Code)
+}
+
+func (ts *TokenTestSuite) TestAuthorizationCodeGrantFailure() {
+	client, secret := ts.createOAuthServerClient("authorization_code", "refresh_token")
+	otherClient, otherSecret := ts.createOAuthServerClient("authorization_code", "refresh_token")
+	codeVerifier := crypto.SecureAlphanumeric(64)
+	authorization := ts.createApprovedAuthorization(client, codeVerifier)
+
+	cases := []struct {
+		desc   string
+		client *models.OAuthServerClient
+		secret string
+		form   url.Values
+	}{
+		{
+			desc:   "Wrong code verifier",
+			client: client,
+			secret: secret,
+			form: url.Values{
+				"grant_type":    {"authorization_code"},
+				"code":          {authorization.AuthorizationCode.String()},
+				"code_verifier": {crypto.SecureAlphanumeric(64)},
+			},
+		},
+		{
+			desc:   "Mismatched redirect URI",
+			client: client,
+			secret: secret,
+			form: url.Values{
+				"grant_type":    {"authorization_code"},
+				"code":          {authorization.AuthorizationCode.String()},
+				"code_verifier": {codeVerifier},
+				"redirect_uri":  {"https://example.com/other"},
+			},
+		},
+		{
+			desc:   "Missing redirect URI sent with the authorization request",
+			client: client,
+			secret: secret,
+			form: url.Values{
+				"grant_type":    {"authorization_code"},
+				"code":          {authorization.AuthorizationCode.String()},
+				"code_verifier": {codeVerifier},
+			},
+		},
+		{
+			desc:   "Code issued to another client",
+			client: otherClient,
+			secret: otherSecret,
+			form: url.Values{
+				"grant_type":    {"authorization_code"},
+				"code":          {authorization.AuthorizationCode.String()},
+				"code_verifier": {codeVerifier},
+			},
+		},
+		{
+			desc:   "Wrong client secret",
+			client: client,
+			secret: "wrong",
+			form: url.Values{
+				"grant_type":    {"authorization_code"},
+				"code":          {authorization.AuthorizationCode.String()},
+				"code_verifier": {codeVerifier},
+			},
+		},
+	}
+
+	for _, c := range cases {
+		ts.Run(c.desc, func() {
+			w := ts.oauthServerTokenRequest(c.client, c.secret, c.form)
+			assert.Equal(ts.T(), http.StatusBadRequest, w.Code)
+		})
+	}
+}
+
+func (ts *TokenTestSuite) TestClientCredentialsGrant() {
+	client, secret := ts.createOAuthServerClient("client_credentials")
+	client.Audience = "https://api.example.com"
+	client.Scopes = "read write"
+	require.NoError(ts.T(), ts.API.db.UpdateOnly(client, "audience", "scopes"))
+
+	w := ts.oauthServerTokenRequest(client, secret, url.Values{
+		"grant_type": {"client_credentials"},
+		"scope":      {"read"},
+	})
+	require.Equal(ts.T(), http.StatusOK, w.Code, w.Body.String())
+
+	var token ClientCredentialsTokenResponse
+	require.NoError(ts.T(), json.NewDecoder(w.Body).Decode(&token))
+	assert.Equal(ts.T(), "read", token.Scope)
+
+	claims := &ClientCredentialsClaims{}
+	_, err := jwt.ParseWithClaims(token.Token, claims, func(t *jwt.Token) (interface{}, error) {
+		return []byte(ts.Config.JWT.Secret), nil
+	})
+	require.NoError(ts.T(), err)
+	assert.Equal(ts.T(), client.ClientID, claims.Subject)
+	assert.Equal(ts.T(), jwt.ClaimStrings{"https://api.example.com"}, claims.Audience)
+	// tokens issued to clients must not match policies written for users
+	assert.Equal(ts.T(), "oauth_client", claims.Role)
+	assert.Equal(ts.T(), "read", claims.Scope)
+
+	// scopes that weren't registered for the client can't be requested
+	w = ts.oauthServerTokenRequest(client, secret, url.Values{
+		"grant_type": {"client_credentials"},
+		"scope":      {"admin"},
+	})
+	assert.Equal(ts.T(), http.StatusBadRequest, w.Code)
+
+	// clients registered without the grant can't use it
+	otherClient, otherSecret := ts.createOAuthServerClient("authorization_code")
+	w = ts.oauthServerTokenRequest(otherClient, otherSecret, url.Values{
+		"grant_type": {"client_credentials"},
+	})
+	assert.Equal(ts.T(), http.StatusBadRequest, w.Code)
+}
+
+func (ts *TokenTestSuite) TestAuthorizationCodeGrantOpenID() {
+	client, secret := ts.createOAuthServerClient("authorization_code", "refresh_token")
+	codeVerifier := crypto.SecureAlphanumeric(64)
+	authorization := ts.createApprovedAuthorization(client, codeVerifier)
+	authorization.Scope = "openid email"
+	authorization.Nonce = "n-0S6_WzA2Mj"
+
+	// the user signed in with MFA an hour before giving consent
+	authTime := time.Now().Add(-time.Hour).Truncate(time.Second)
+	authorization.AAL = "aal2"
+	authorization.AMR = "totp password"
+	authorization.AuthTime = &authTime
+	require.NoError(ts.T(), ts.API.db.UpdateOnly(authorization, "scope", "nonce", "aal", "amr", "auth_time"))
+	_, err := models.GrantOAuthServerConsent(ts.API.db, ts.User.ID, client.ID, []string{"openid", "email"})
+	require.NoError(ts.T(), err)
+
+	w := ts.oauthServerTokenRequest(client, secret, url.Values{
+		"grant_type":    {"authorization_code"},
+		"code":          {authorization.AuthorizationCode.String()},
+		"code_verifier": {codeVerifier},
+	})
+	require.Equal(ts.T(), http.StatusOK, w.Code, w.Body.String())
+
+	var token AccessTokenResponse
+	require.NoError(ts.T(), json.NewDecoder(w.Body).Decode(&token))
+	require.NotEmpty(ts.T(), token.IDToken)
+
+	claims := &IDTokenClaims{}
+	_, err = jwt.ParseWithClaims(token.IDToken, claims, func(t *jwt.Token) (interface{}, error) {
+		return []byte(ts.Config.JWT.Secret), nil
+	})
+	require.NoError(ts.T(), err)
+	assert.Equal(ts.T(), ts.User.ID.String(), claims.Subject)
+	assert.Equal(ts.T(), jwt.ClaimStrings{client.ClientID}, claims.Audience)
+	assert.Equal(ts.T(), "n-0S6_WzA2Mj", claims.Nonce)
+	assert.Equal(ts.T(), "aal2", claims.ACR)
+	assert.Equal(ts.T(), []string{"totp", "password"}, claims.AMR)
+	assert.Equal(ts.T(), authTime.Unix(), claims.AuthTime)
+	assert.Equal(ts.T(), ts.User.GetEmail(), claims.Email)
+	assert.Empty(ts.T(), claims.PhoneNumber)
+
+	// the userinfo endpoint only returns the claims covered by the consent
+	req := httptest.NewRequest(http.MethodGet, "http://localhost/oauth/userinfo", nil)
+	req.Header.Set("Authorization", "Bearer "+token.Token)
+	w = httptest.NewRecorder()
+	ts.API.handler.ServeHTTP(w, req)
+	require.Equal(ts.T(), http.StatusOK, w.Code, w.Body.String())
+
+	var userInfo UserInfoResponse
+	require.NoError(ts.T(), json.NewDecoder(w.Body).Decode(&userInfo))
+	assert.Equal(ts.T(), ts.User.ID.String(), userInfo.Subject)
+	assert.Equal(ts.T(), ts.User.GetEmail(), userInfo.Email)
+	assert.Empty(ts.T(), userInfo.Name)
+}
+
+func (ts *TokenTestSuite) TestAuthorizationCodeGrantFirstPartyEndpoints() {
+	client, secret := ts.createOAuthServerClient("authorization_code", "refresh_token")
+	codeVerifier := crypto.SecureAlphanumeric(64)
+	authorization := ts.createApprovedAuthorization(client, codeVerifier)
+	authorization.Scope = "openid email"
+	require.NoError(ts.T(), ts.API.db.UpdateOnly(authorization, "scope"))
+
+	w := ts.oauthServerTokenRequest(client, secret, url.Values{
+		"grant_type":    {"authorization_code"},
+		"code":          {authorization.AuthorizationCode.String()},
+		"code_verifier": {codeVerifier},
+	})
+	require.Equal(ts.T(), http.StatusOK, w.Code, w.Body.String())
+
+	var token AccessTokenResponse
+	require.NoError(ts.T(), json.NewDecoder(w.Body).Decode(&token))
+
+	// tokens issued to OAuth clients can't manage the user's account
+	body, err := json.Marshal(map[string]interface{}{
+		"password": "newpassword123",
+	})
+	require.NoError(ts.T(), err)
+
+	req := httptest.NewRequest(http.MethodPut, "http://localhost/user", bytes.NewReader(body))
+	req.Header.Set("Content-Type", "application/json")
+	req.Header.Set("Authorization", "Bearer "+token.Token)
+	w = httptest.NewRecorder()
+	ts.API.handler.ServeHTTP(w, req)
+	require.Equal(ts.T(), http.StatusForbidden, w.Code, w.Body.String())
+	assert.Contains(ts.T(), w.Body.String(), string(apierrors.ErrorCodeOAuthInsufficientScope))
+
+	req = httptest.NewRequest(http.MethodGet, "http://localhost/user/sessions", nil)
+	req.Header.Set("Authorization", "Bearer "+token.Token)
+	w = httptest.NewRecorder()
+	ts.API.handler.ServeHTTP(w, req)
+	require.Equal(ts.T(), http.StatusForbidden, w.Code, w.Body.String())
+}
+
+func (ts *TokenTestSuite) TestDeviceCodeGrant() {
+	client, secret := ts.createOAuthServerClient(oauthserver.DeviceCodeGrantType)
+
+	w := ts.oauthServerRequest("/oauth/device/code", client, secret, url.Values{
+		"scope": {"openid"},
+	})
+	require.Equal(ts.T(), http.StatusOK, w.Code, w.Body.String())
+
+	var deviceAuthorization oauthserver.DeviceAuthorizationResponse
+	require.NoError(ts.T(), json.NewDecoder(w.Body).Decode(&deviceAuthorization))
+	require.NotEmpty(ts.T(), deviceAuthorization.DeviceCode)
+	assert.Contains(ts.T(), deviceAuthorization.VerificationURIComplete, "user_code=")
+
+	form := url.Values{
+		"grant_type":  {oauthserver.DeviceCodeGrantType},
+		"device_code": {deviceAuthorization.DeviceCode},
+	}
+
+	// the device polls while the user hasn't approved the request yet
+	w = ts.oauthServerTokenRequest(client, secret, form)
+	require.Equal(ts.T(), http.StatusBadRequest, w.Code)
+	assert.Contains(ts.T(), w.Body.String(), "authorization_pending")
+
+	w = ts.oauthServerTokenRequest(client, secret, form)
+	require.Equal(ts.T(), http.StatusBadRequest, w.Code)
+	assert.Contains(ts.T(), w.Body.String(), "slow_down")
+
+	authorization, err := models.FindOAuthServerDeviceAuthorizationByUserCode(ts.API.db, deviceAuthorization.UserCode)
+	require.NoError(ts.T(), err)
+	require.NoError(ts.T(), authorization.Approve(ts.API.db, ts.User.ID, nil))
+
+	w = ts.oauthServerTokenRequest(client, secret, form)
+	require.Equal(ts.T(), http.StatusOK, w.Code, w.Body.String())
+
+	var token AccessTokenResponse
+	require.NoError(ts.T(), json.NewDecoder(w.Body).Decode(&token))
+	assert.NotEmpty(ts.T(), token.RefreshToken)
+	assert.NotEmpty(ts.T(), token.IDToken)
+
+	// device codes can only be used once
+	w = ts.oauthServerTokenRequest(client, secret, form)
+	require.Equal(ts.T(), http.StatusBadRequest, w.Code)
+	assert.Contains(ts.T(), w.Body.String(), "expired_token")
+}
+
+func (ts *TokenTestSuite) TestAuthorizationCodeGrantScope() {
+	client, secret := ts.createOAuthServerClient("authorization_code", "refresh_token")
+	client.Scopes = "read write"
+	require.NoError(ts.T(), ts.API.db.UpdateOnly(client, "scopes"))
+
+	codeVerifier := crypto.SecureAlphanumeric(64)
+	authorization := ts.createApprovedAuthorization(client, codeVerifier)
+	authorization.Scope = "read write"
+	require.NoError(ts.T(), ts.API.db.UpdateOnly(authorization, "scope"))
+
+	form := url.Values{
+		"grant_type":    {"authorization_code"},
+		"code":          {authorization.AuthorizationCode.String()},
+		"code_verifier": {codeVerifier},
+	}
+
+	// the token request can't ask for scopes the user didn't authorize
+	form.Set("scope", "read admin")
+	w := ts.oauthServerTokenRequest(client, secret, form)
+	require.Equal(ts.T(), http.StatusBadRequest, w.Code)
+	assert.Contains(ts.T(), w.Body.String(), "invalid_scope")
+
+	authorization = ts.createApprovedAuthorization(client, codeVerifier)
+	authorization.Scope = "read write"
+	require.NoError(ts.T(), ts.API.db.UpdateOnly(authorization, "scope"))
+
+	form.Set("code", authorization.AuthorizationCode.String())
+	form.Set("scope", "read")
+	w = ts.oauthServerTokenRequest(client, secret, form)
+	require.Equal(ts.T(), http.StatusOK, w.Code, w.Body.String())
+
+	var token AccessTokenResponse
+	require.NoError(ts.T(), json.NewDecoder(w.Body).Decode(&token))
+	assert.Equal(ts.T(), "read", token.Scope)
+
+	claims := &AccessTokenClaims{}
+	_, err := jwt.ParseWithClaims(token.Token, claims, func(t *jwt.Token) (interface{}, error) {
+		return []byte(ts.Config.JWT.Secret), nil
+	})
+	require.NoError(ts.T(), err)
+	assert.Equal(ts.T(), "read", claims.Scope)
+
+	// refreshed access tokens keep the scopes granted to the session
+	w = ts.oauthServerTokenRequest(client, secret, url.Values{
+		"grant_type":    {"refresh_token"},
+		"refresh_token": {token.RefreshToken},
+	})
+	require.Equal(ts.T(), http.StatusOK, w.Code, w.Body.String())
+
+	require.NoError(ts.T(), json.NewDecoder(w.Body).Decode(&token))
+	claims = &AccessTokenClaims{}
+	_, err = jwt.ParseWithClaims(token.Token, claims, func(t *jwt.Token) (interface{}, error) {
+		return []byte(ts.Config.JWT.Secret), nil
+	})
+	require.NoError(ts.T(), err)
+	assert.Equal(ts.T(), "read", claims.Scope)
+}
+
+func (ts *TokenTestSuite) TestAuthorizationCodeGrantWithoutScope() {
+	client, secret := ts.createOAuthServerClient("authorization_code", "refresh_token")
+
+	// the authorization request didn't ask for any scopes
+	codeVerifier := crypto.SecureAlphanumeric(64)
+	authorization := ts.createApprovedAuthorization(client, codeVerifier)
+
+	w := ts.oauthServerTokenRequest(client, secret, url.Values{
+		"grant_type":    {"authorization_code"},
+		"code":          {authorization.AuthorizationCode.String()},
+		"code_verifier": {codeVerifier},
+		"redirect_uri":  {"https://example.com/callback"},
+	})
+	require.Equal(ts.T(), http.StatusOK, w.Code, w.Body.String())
+
+	var token AccessTokenResponse
+	require.NoError(ts.T(), json.NewDecoder(w.Body).Decode(&token))
+	assert.Equal(ts.T(), strings.Join(client.AllowedScopes(), " "), token.Scope)
+
+	claims := &AccessTokenClaims{}
+	_, err := jwt.ParseWithClaims(token.Token, claims, func(t *jwt.Token) (interface{}, error) {
+		return []byte(ts.Config.JWT.Secret), nil
+	})
+	require.NoError(ts.T(), err)
+	assert.Equal(ts.T(), token.Scope, claims.Scope)
+	assert.Equal(ts.T(), client.ClientID, claims.ClientID)
+
+	// refreshed access tokens keep the scope and client_id claims
+	w = ts.oauthServerTokenRequest(client, secret, url.Values{
+		"grant_type":    {"refresh_token"},
+		"refresh_token": {token.RefreshToken},
+	})
+	require.Equal(ts.T(), http.StatusOK, w.Code, w.Body.String())
+
+	require.NoError(ts.T(), json.NewDecoder(w.Body).Decode(&token))
+	claims = &AccessTokenClaims{}
+	_, err = jwt.ParseWithClaims(token.Token, claims, func(t *jwt.Token) (interface{}, error) {
+		return []byte(ts.Config.JWT.Secret), nil
+	})
+	require.NoError(ts.T(), err)
+	assert.Equal(ts.T(), strings.Join(client.AllowedScopes(), " "), claims.Scope)
+	assert.Equal(ts.T(), client.ClientID, claims.ClientID)
+}
+
+func (ts *TokenTestSuite) TestAuthorizationCodeGrantRefreshClient() {
+	client, secret := ts.createOAuthServerClient("authorization_code", "refresh_token")
+	otherClient, otherSecret := ts.createOAuthServerClient("authorization_code", "refresh_token")
+
+	codeVerifier := crypto.SecureAlphanumeric(64)
+	authorization := ts.createApprovedAuthorization(client, codeVerifier)
+
+	w := ts.oauthServerTokenRequest(client, secret, url.Values{
+		"grant_type":    {"authorization_code"},
+		"code":          {authorization.AuthorizationCode.String()},
+		"code_verifier": {codeVerifier},
+		"redirect_uri":  {"https://example.com/callback"},
+	})
+	require.Equal(ts.T(), http.StatusOK, w.Code, w.Body.String())
+
+	var token AccessTokenResponse
+	require.NoError(ts.T(), json.NewDecoder(w.Body).Decode(&token))
+
+	form := url.Values{
+		"grant_type":    {"refresh_token"},
+		"refresh_token": {token.RefreshToken},
+	}
+
+	// the refresh token can't be used without client authentication
+	req := httptest.NewRequest(http.MethodPost, "http://localhost/token", strings.NewReader(form.Encode()))
+	req.Header.Set("Content-Type", "application/x-www-form-urlencoded")
+	w = httptest.NewRecorder()
+	ts.API.handler.ServeHTTP(w, req)
+	require.Equal(ts.T(), http.StatusBadRequest, w.Code)
+	assert.Contains(ts.T(), w.Body.String(), "invalid_grant")
+
+	// nor by another client
+	w = ts.oauthServerTokenRequest(otherClient, otherSecret, form)
+	require.Equal(ts.T(), http.StatusBadRequest, w.Code)
+	assert.Contains(ts.T(), w.Body.String(), "invalid_grant")
+
+	w = ts.oauthServerTokenRequest(client, secret, form)
+	require.Equal(ts.T(), http.StatusOK, w.Code, w.Body.String())
+}
@@ -258,6 +258,7 @@ func (a *API) IdTokenGrant(ctx context.Context, w http.ResponseWriter, r *http.R
 	var grantParams models.GrantParams
 
 	grantParams.FillGrantParams(r)
+	grantParams.Provider = providerType
 
 	if err := a.triggerBeforeUserCreatedExternal(r, db, userData, providerType); err != nil {
 		return err
@@ -7,10 +7,10 @@ import (
 	"time"
 
 	"github.com/supabase/auth/internal/api/apierrors"
+	"github.com/supabase/auth/internal/api/oauthserver"
 	"github.com/supabase/auth/internal/metering"
 	"github.com/supabase/auth/internal/models"
 	"github.com/supabase/auth/internal/storage"
-	"github.com/supabase/auth/internal/utilities"
 )
 
 const retryLoopDuration = 5.0
@@ -65,6 +65,33 @@ func (a *API) RefreshTokenGrant(ctx context.Context, w http.ResponseWriter, r *h
 			return apierrors.NewBadRequestError(apierrors.ErrorCodeSessionNotFound, "Invalid Refresh Token: No Valid Session Found")
 		}
 
+		if err := requireDPoPBinding(ctx, session); err != nil {
+			return err
+		}
+
+		// refresh tokens issued to OAuth server clients can only be used
+		// by the client they were issued to
+		if session.OAuthClientID != nil {
+			client := oauthserver.GetOAuthServerClient(ctx)
+			if client == nil || client.ID != *session.OAuthClientID {
+				return apierrors.NewOAuthError("invalid_grant", "Invalid Refresh Token: Refresh Token was not issued to this client")
+			}
+		}
+
+		// sessions granted for a resource stay restricted to it when
+		// refreshed without requesting one
+		if session.Resource != nil && getOAuthResource(ctx) == nil {
+			resource, err := models.FindOAuthServerResourceByResource(db, *session.Resource)
+			if err != nil {
+				if models.IsNotFoundError(err) {
+					return apierrors.NewOAuthError("invalid_target", "The resource granted to this session is no longer registered")
+				}
+				return apierrors.NewInternalServerError("Error loading OAuth resource").WithInternalError(err)
+			}
+			ctx = withOAuthResource(ctx, resource)
+			r = r.WithContext(ctx)
+		}
+
 		sessionValidityConfig := models.SessionValidityConfig{
 			Timebox:           config.Sessions.Timebox,
 			InactivityTimeout: config.Sessions.InactivityTimeout,
@@ -236,19 +263,10 @@ func (a *API) RefreshTokenGrant(ctx context.Context, w http.ResponseWriter, r *h
 			refreshedAt := a.Now()
 			session.RefreshedAt = &refreshedAt
 
-			userAgent := r.Header.Get("User-Agent")
-			if userAgent != "" {
-				session.UserAgent = &userAgent
-			} else {
-				session.UserAgent = nil
-			}
-
-			ipAddress := utilities.GetIPAddress(r)
-			if ipAddress != "" {
-				session.IP = &ipAddress
-			} else {
-				session.IP = nil
-			}
+			var clientInfo models.GrantParams
+			clientInfo.FillGrantParams(r)
+			a.fillGrantLocation(&clientInfo)
+			session.SetClientInfo(&clientInfo)
 
 			if terr := session.UpdateOnlyRefreshInfo(tx); terr != nil {
 				return apierrors.NewInternalServerError("failed to update session information").WithInternalError(terr)
@@ -256,12 +274,15 @@ func (a *API) RefreshTokenGrant(ctx context.Context, w http.ResponseWriter, r *h
 
 			newTokenResponse = &AccessTokenResponse{
 				Token:        tokenString,
-				TokenType:    "bearer",
-				ExpiresIn:    config.JWT.Exp,
+				TokenType:    accessTokenType(session.DPoPJKT),
+				ExpiresIn:    a.accessTokenExpiry(ctx),
 				ExpiresAt:    expiresAt,
 				RefreshToken: issuedToken.Token,
 				User:         user,
 			}
+			if session.Scopes != nil {
+				newTokenResponse.Scope = *session.Scopes
+			}
 
 			return nil
 		})
@@ -0,0 +1,111 @@
+package api
+
+import (
+	"context"
+	"encoding/json"
+	"net/http"
+
+	"github.com/supabase/auth/internal/api/apierrors"
+	"github.com/supabase/auth/internal/api/oauthserver"
+	"github.com/supabase/auth/internal/models"
+	"github.com/supabase/auth/internal/storage"
+	"github.com/supabase/auth/internal/utilities"
+)
+
+// requestedResources returns the RFC 8707 resource indicators of a token
+// request. They are sent as form parameters to the OAuth server grants and
+// as a JSON body parameter to the first-party grants.
+func requestedResources(r *http.Request) ([]string, error) {
+	if err := r.ParseForm(); err != nil {
+		return nil, apierrors.NewOAuthError("invalid_request", "Invalid form body")
+	}
+
+	if resources, ok := r.Form["resource"]; ok {
+		return resources, nil
+	}
+
+	body, err := utilities.GetBodyBytes(r)
+	if err != nil {
+		return nil, apierrors.NewInternalServerError("Could not read body into byte slice").WithInternalError(err)
+	}
+
+	var params map[string]json.RawMessage
+	if len(body) == 0 || json.Unmarshal(body, &params) != nil {
+		// malformed bodies are reported by the grant handlers
+		return nil, nil
+	}
+
+	raw, ok := params["resource"]
+	if !ok {
+		return nil, nil
+	}
+
+	var resource string
+	if err := json.Unmarshal(raw, &resource); err != nil || resource == "" {
+		return nil, apierrors.NewOAuthError("invalid_target", "resource must be a single absolute URI")
+	}
+
+	return []string{resource}, nil
+}
+
+// loadRequestedResource returns the registered resource the token request
+// asked for, if any. Only one resource can be requested at a time, as the
+// issued access token is restricted to it.
+func (a *API) loadRequestedResource(r *http.Request) (*models.OAuthServerResource, error) {
+	resources, err := requestedResources(r)
+	if err != nil || len(resources) == 0 {
+		return nil, err
+	}
+
+	if len(resources) > 1 {
+		return nil, apierrors.NewOAuthError("invalid_target", "Only one resource can be requested")
+	}
+
+	if !oauthserver.ValidateResourceURI(resources[0]) {
+		return nil, apierrors.NewOAuthError("invalid_target", "resource must be an absolute URI without a fragment")
+	}
+
+	resource, err := models.FindOAuthServerResourceByResource(a.db.WithContext(r.Context()), resources[0])
+	if err != nil {
+		if models.IsNotFoundError(err) {
+			return nil, apierrors.NewOAuthError("invalid_target", "The requested resource is not registered")
+		}
+		return nil, apierrors.NewInternalServerError("Error loading OAuth resource").WithInternalError(err)
+	}
+
+	return resource, nil
+}
+
+// checkResourceAllowed checks that the resource requested for the token, if
+// any, can be used by the OAuth server client the session was issued to.
+// Sessions can only be refreshed for the resource they were granted for
+// (RFC 8707 section 2.2).
+func checkResourceAllowed(tx *storage.Connection, resource *models.OAuthServerResource, session *models.Session) error {
+	if session.Resource == nil || *session.Resource != resource.Resource {
+		return apierrors.NewOAuthError("invalid_target", "The resource was not granted to this session")
+	}
+
+	var client *models.OAuthServerClient
+	if session.OAuthClientID != nil {
+		var err error
+		client, err = models.FindOAuthServerClientByID(tx, *session.OAuthClientID)
+		if err != nil && !models.IsNotFoundError(err) {
+			return apierrors.NewInternalServerError("Error loading OAuth client").WithInternalError(err)
+		}
+	}
+
+	if !resource.AllowsClient(client) {
+		return apierrors.NewOAuthError("invalid_target", "The client is not allowed to request tokens for this resource")
+	}
+
+	return nil
+}
+
+// accessTokenExpiry returns the lifetime in seconds of the access tokens
+// issued for the request
+func (a *API) accessTokenExpiry(ctx context.Context) int {
+	if resource := getOAuthResource(ctx); resource != nil {
+		return resource.GetAccessTokenExpiry(a.config.JWT.Exp)
+	}
+	return a.config.JWT.Exp
+}
@@ -0,0 +1,105 @@
+package api
+
+import (
+	"encoding/json"
+	"net/http"
+
+	"github.com/golang-jwt/jwt/v5"
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+	"github.com/supabase/auth/internal/models"
+)
+
+func (ts *TokenTestSuite) TestResourceIndicators() {
+	expiry := 300
+	resource := models.NewOAuthServerResource("https://api.example.com")
+	resource.AccessTokenExpiry = &expiry
+	require.NoError(ts.T(), ts.API.db.Create(resource))
+
+	restricted := models.NewOAuthServerResource("https://billing.example.com")
+	restricted.SetAllowedClientIDs([]string{"some-client"})
+	require.NoError(ts.T(), ts.API.db.Create(restricted))
+
+	login := map[string]interface{}{
+		"email":    "test@example.com",
+		"password": "password",
+		"resource": resource.Resource,
+	}
+
+	w := ts.dpopTokenRequest("password", login, "")
+	require.Equal(ts.T(), http.StatusOK, w.Code, w.Body.String())
+
+	var response AccessTokenResponse
+	require.NoError(ts.T(), json.NewDecoder(w.Body).Decode(&response))
+	assert.Equal(ts.T(), expiry, response.ExpiresIn)
+
+	claims := &AccessTokenClaims{}
+	_, err := jwt.NewParser().ParseWithClaims(response.Token, claims, ts.API.jwtKeyFunc)
+	require.NoError(ts.T(), err)
+	assert.Equal(ts.T(), jwt.ClaimStrings{resource.Resource}, claims.Audience)
+
+	// refreshing without a resource issues a token for the granted resource
+	w = ts.dpopTokenRequest("refresh_token", map[string]interface{}{
+		"refresh_token": response.RefreshToken,
+	}, "")
+	require.Equal(ts.T(), http.StatusOK, w.Code, w.Body.String())
+
+	var refreshed AccessTokenResponse
+	require.NoError(ts.T(), json.NewDecoder(w.Body).Decode(&refreshed))
+	assert.Equal(ts.T(), expiry, refreshed.ExpiresIn)
+
+	claims = &AccessTokenClaims{}
+	_, err = jwt.NewParser().ParseWithClaims(refreshed.Token, claims, ts.API.jwtKeyFunc)
+	require.NoError(ts.T(), err)
+	assert.Equal(ts.T(), jwt.ClaimStrings{resource.Resource}, claims.Audience)
+
+	// refreshing can only request the resource the session was granted for
+	other := models.NewOAuthServerResource("https://other.example.com")
+	require.NoError(ts.T(), ts.API.db.Create(other))
+
+	w = ts.dpopTokenRequest("refresh_token", map[string]interface{}{
+		"refresh_token": refreshed.RefreshToken,
+		"resource":      other.Resource,
+	}, "")
+	assert.Equal(ts.T(), http.StatusBadRequest, w.Code)
+	assert.Contains(ts.T(), w.Body.String(), "invalid_target")
+
+	w = ts.dpopTokenRequest("refresh_token", map[string]interface{}{
+		"refresh_token": refreshed.RefreshToken,
+		"resource":      resource.Resource,
+	}, "")
+	require.Equal(ts.T(), http.StatusOK, w.Code, w.Body.String())
+
+	require.NoError(ts.T(), json.NewDecoder(w.Body).Decode(&refreshed))
+	claims = &AccessTokenClaims{}
+	_, err = jwt.NewParser().ParseWithClaims(refreshed.Token, claims, ts.API.jwtKeyFunc)
+	require.NoError(ts.T(), err)
+	assert.Equal(ts.T(), jwt.ClaimStrings{resource.Resource}, claims.Audience)
+
+	// sessions granted without a resource can't be refreshed for one
+	w = ts.dpopTokenRequest("refresh_token", map[string]interface{}{
+		"refresh_token": ts.RefreshToken.Token,
+		"resource":      resource.Resource,
+	}, "")
+	assert.Equal(ts.T(), http.StatusBadRequest, w.Code)
+	assert.Contains(ts.T(), w.Body.String(), "invalid_target")
+
+	// malformed resources are rejected rather than ignored
+	login["resource"] = []string{resource.Resource, other.Resource}
+	w = ts.dpopTokenRequest("password", login, "")
+	assert.Equal(ts.T(), http.StatusBadRequest, w.Code)
+	assert.Contains(ts.T(), w.Body.String(), "invalid_target")
+
+	// unregistered resources are rejected
+	login["resource"] = "https://unknown.example.com"
+	w = ts.dpopTokenRequest("password", login, "")
+	assert.Equal(ts.T(), http.StatusBadRequest, w.Code)
+	assert.Contains(ts.T(), w.Body.String(), "invalid_target")
+
+	// resources restricted to specific clients can't be requested by
+	// first-party sessions
+	login["resource"] = restricted.Resource
+	w = ts.dpopTokenRequest("password", login, "")
+	assert.Equal(ts.T(), http.StatusBadRequest, w.Code)
+	assert.Contains(ts.T(), w.Body.String(), "invalid_target")
+}
@@ -0,0 +1,261 @@
+package api
+
+import (
+	"net/http"
+
+	"github.com/go-chi/chi/v5"
+	"github.com/gofrs/uuid"
+	"github.com/supabase/auth/internal/api/apierrors"
+	"github.com/supabase/auth/internal/metering"
+	"github.com/supabase/auth/internal/models"
+	"github.com/supabase/auth/internal/storage"
+	"github.com/supabase/auth/internal/utilities"
+)
+
+// TrustedDeviceVerifyParams are the parameters of the trusted device step-up
+type TrustedDeviceVerifyParams struct {
+	Token string `json:"token"`
+}
+
+// TrustedDeviceListResponse is the response of the trusted device listing
+// endpoints
+type TrustedDeviceListResponse struct {
+	TrustedDevices []*models.TrustedDevice `json:"trusted_devices"`
+}
+
+// trustDevice remembers the device the factor was just verified on and
+// returns the token the device presents to skip MFA later. It has to run in
+// the transaction verifying the factor.
+func (a *API) trustDevice(r *http.Request, tx *storage.Connection, user *models.User, factor *models.Factor) (string, error) {
+	config := a.config
+
+	device, token := models.NewTrustedDevice(user.ID, factor.ID, r.UserAgent(), utilities.GetIPAddress(r), config.MFA.TrustedDevices.ExpiryDuration)
+	if err := tx.Create(device); err != nil {
+		return "", apierrors.NewInternalServerError("Database error trusting device").WithInternalError(err)
+	}
+
+	if err := models.NewAuditLogEntry(config.AuditLog, r, tx, user, models.TrustedDeviceCreatedAction, r.RemoteAddr, map[string]interface{}{
+		"trusted_device_id": device.ID,
+		"factor_id":         factor.ID,
+	}); err != nil {
+		return "", err
+	}
+
+	return token, nil
+}
+
+// VerifyTrustedDevice handles POST /factors/trusted_devices/verify, raising
+// the current session to AAL2 without a new MFA challenge when the device
+// presents a token it was trusted with.
+func (a *API) VerifyTrustedDevice(w http.ResponseWriter, r *http.Request) error {
+	ctx := r.Context()
+	db := a.db.WithContext(ctx)
+	config := a.config
+	user := getUser(ctx)
+
+	if !config.MFA.TrustedDevices.Enabled {
+		return apierrors.NewUnprocessableEntityError(apierrors.ErrorCodeMFATrustedDevicesDisabled, "Trusted devices are disabled")
+	}
+
+	params := &TrustedDeviceVerifyParams{}
+	if err := retrieveRequestParams(r, params); err != nil {
+		return err
+	}
+	if params.Token == "" {
+		return apierrors.NewBadRequestError(apierrors.ErrorCodeValidationFailed, "token is required")
+	}
+
+	var token *AccessTokenResponse
+	err := db.Transaction(func(tx *storage.Connection) error {
+		device, terr := models.FindTrustedDeviceByToken(tx, user.ID, params.Token)
+		if terr != nil {
+			if models.IsNotFoundError(terr) {
+				return apierrors.NewUnprocessableEntityError(apierrors.ErrorCodeMFAVerificationFailed, "Device is not trusted")
+			}
+			return apierrors.NewInternalServerError("Database error finding trusted device").WithInternalError(terr)
+		}
+		if device.IsExpired() {
+			return apierrors.NewUnprocessableEntityError(apierrors.ErrorCodeMFAVerificationFailed, "Device is not trusted")
+		}
+
+		factor, terr := models.FindFactorByFactorID(tx, device.FactorID)
+		if terr != nil {
+			if models.IsNotFoundError(terr) {
+				return apierrors.NewUnprocessableEntityError(apierrors.ErrorCodeMFAVerificationFailed, "Device is not trusted")
+			}
+			return apierrors.NewInternalServerError("Database error finding factor").WithInternalError(terr)
+		}
+		if !factor.IsVerified() || factor.FactorType == models.RecoveryCode {
+			return apierrors.NewUnprocessableEntityError(apierrors.ErrorCodeMFAVerificationFailed, "Device is not trusted")
+		}
+
+		if terr := device.RecordUse(tx); terr != nil {
+			return apierrors.NewInternalServerError("Database error updating trusted device").WithInternalError(terr)
+		}
+
+		if terr := models.NewAuditLogEntry(config.AuditLog, r, tx, user, models.TrustedDeviceUsedAction, r.RemoteAddr, map[string]interface{}{
+			"trusted_device_id": device.ID,
+			"factor_id":         factor.ID,
+		}); terr != nil {
+			return terr
+		}
+
+		token, terr = a.updateMFASessionAndClaims(r, tx, user, models.MFATrustedDevice, models.GrantParams{
+			FactorID: &factor.ID,
+		})
+		if terr != nil {
+			return terr
+		}
+		if terr = models.InvalidateSessionsWithAALLessThan(tx, user.ID, models.AAL2.String()); terr != nil {
+			return apierrors.NewInternalServerError("Failed to update sessions").WithInternalError(terr)
+		}
+		return nil
+	})
+	if err != nil {
+		return err
+	}
+
+	metering.RecordLogin(metering.LoginTypeMFA, user.ID, &metering.LoginData{
+		Provider: metering.ProviderMFATrustedDevice,
+	})
+
+	return sendJSON(w, http.StatusOK, token)
+}
+
+// findUserTrustedDevice finds one of the user's trusted devices by the
+// trusted_device_id URL parameter
+func findUserTrustedDevice(tx *storage.Connection, r *http.Request, user *models.User) (*models.TrustedDevice, error) {
+	deviceID, err := uuid.FromString(chi.URLParam(r, "trusted_device_id"))
+	if err != nil {
+		return nil, apierrors.NewNotFoundError(apierrors.ErrorCodeTrustedDeviceNotFound, "Trusted device not found")
+	}
+
+	device, err := models.FindTrustedDeviceByUserIDAndID(tx, user.ID, deviceID)
+	if err != nil {
+		if models.IsNotFoundError(err) {
+			return nil, apierrors.NewNotFoundError(apierrors.ErrorCodeTrustedDeviceNotFound, "Trusted device not found")
+		}
+		return nil, apierrors.NewInternalServerError("Database error finding trusted device").WithInternalError(err)
+	}
+
+	return device, nil
+}
+
+// listTrustedDevices returns the user's trusted devices
+func listTrustedDevices(tx *storage.Connection, user *models.User) (*TrustedDeviceListResponse, error) {
+	devices, err := models.FindTrustedDevicesByUserID(tx, user.ID)
+	if err != nil {
+		return nil, apierrors.NewInternalServerError("Database error loading trusted devices").WithInternalError(err)
+	}
+
+	return &TrustedDeviceListResponse{
+		TrustedDevices: devices,
+	}, nil
+}
+
+// revokeTrustedDevice deletes one of the user's trusted devices on behalf of
+// the actor, which is the user or an admin
+func (a *API) revokeTrustedDevice(r *http.Request, user, actor *models.User) error {
+	db := a.db.WithContext(r.Context())
+	config := a.config
+
+	return db.Transaction(func(tx *storage.Connection) error {
+		device, terr := findUserTrustedDevice(tx, r, user)
+		if terr != nil {
+			return terr
+		}
+
+		if terr := models.NewAuditLogEntry(config.AuditLog, r, tx, actor, models.TrustedDeviceRevokedAction, "", map[string]interface{}{
+			"user_id":           user.ID,
+			"trusted_device_id": device.ID,
+		}); terr != nil {
+			return apierrors.NewInternalServerError("Error recording audit log entry").WithInternalError(terr)
+		}
+
+		if terr := tx.Destroy(device); terr != nil {
+			return apierrors.NewInternalServerError("Database error revoking trusted device").WithInternalError(terr)
+		}
+		return nil
+	})
+}
+
+// UserTrustedDeviceList handles GET /user/trusted_devices, listing the
+// devices the user skips MFA on
+func (a *API) UserTrustedDeviceList(w http.ResponseWriter, r *http.Request) error {
+	ctx := r.Context()
+	db := a.db.WithContext(ctx)
+
+	response, err := listTrustedDevices(db, getUser(ctx))
+	if err != nil {
+		return err
+	}
+
+	return sendJSON(w, http.StatusOK, response)
+}
+
+// UserTrustedDeviceDelete handles DELETE /user/trusted_devices/{trusted_device_id},
+// making the device go through MFA again
+func (a *API) UserTrustedDeviceDelete(w http.ResponseWriter, r *http.Request) error {
+	user := getUser(r.Context())
+
+	if err := a.revokeTrustedDevice(r, user, user); err != nil {
+		return err
+	}
+
+	w.WriteHeader(http.StatusNoContent)
+	return nil
+}
+
+// adminUserGetTrustedDevices handles GET /admin/users/{user_id}/trusted_devices
+func (a *API) adminUserGetTrustedDevices(w http.ResponseWriter, r *http.Request) error {
+	ctx := r.Context()
+	db := a.db.WithContext(ctx)
+
+	response, err := listTrustedDevices(db, getUser(ctx))
+	if err != nil {
+		return err
+	}
+
+	return sendJSON(w, http.StatusOK, response)
+}
+
+// adminUserDeleteTrustedDevice handles DELETE /admin/users/{user_id}/trusted_devices/{trusted_device_id}
+func (a *API) adminUserDeleteTrustedDevice(w http.ResponseWriter, r *http.Request) error {
+	ctx := r.Context()
+
+	if err := a.revokeTrustedDevice(r, getUser(ctx), getAdminUser(ctx)); err != nil {
+		return err
+	}
+
+	w.WriteHeader(http.StatusNoContent)
+	return nil
+}
+
+// adminUserDeleteTrustedDevices handles DELETE /admin/users/{user_id}/trusted_devices,
+// making all of the user's devices go through MFA again
+func (a *API) adminUserDeleteTrustedDevices(w http.ResponseWriter, r *http.Request) error {
+	ctx := r.Context()
+	db := a.db.WithContext(ctx)
+	config := a.config
+	user := getUser(ctx)
+	adminUser := getAdminUser(ctx)
+
+	err := db.Transaction(func(tx *storage.Connection) error {
+		if terr := models.NewAuditLogEntry(config.AuditLog, r, tx, adminUser, models.TrustedDeviceRevokedAction, "", map[string]interface{}{
+			"user_id": user.ID,
+		}); terr != nil {
+			return apierrors.NewInternalServerError("Error recording audit log entry").WithInternalError(terr)
+		}
+
+		if terr := models.RevokeTrustedDevices(tx, user.ID); terr != nil {
+			return apierrors.NewInternalServerError("Database error revoking trusted devices").WithInternalError(terr)
+		}
+		return nil
+	})
+	if err != nil {
+		return err
+	}
+
+	w.WriteHeader(http.StatusNoContent)
+	return nil
+}
@@ -58,6 +58,13 @@ func (a *API) validateUserUpdateParams(ctx context.Context, p *UserUpdateParams)
 	return nil
 }
 
+// UserResponse is a user along with how the MFA enforcement policy applies
+// to them
+type UserResponse struct {
+	*models.User
+	MFAEnforcement *MFAEnforcementState `json:"mfa_enforcement,omitempty"`
+}
+
 // UserGet returns a user
 func (a *API) UserGet(w http.ResponseWriter, r *http.Request) error {
 	ctx := r.Context()
@@ -73,7 +80,19 @@ func (a *API) UserGet(w http.ResponseWriter, r *http.Request) error {
 	}
 
 	user := getUser(ctx)
-	return sendJSON(w, http.StatusOK, user)
+	if !a.config.MFA.Enforcement.Enabled {
+		return sendJSON(w, http.StatusOK, user)
+	}
+
+	db := a.db.WithContext(ctx)
+	enforcement, err := a.mfaEnforcementState(db, user)
+	if err != nil {
+		return err
+	}
+	return sendJSON(w, http.StatusOK, &UserResponse{
+		User:           user,
+		MFAEnforcement: enforcement,
+	})
 }
 
 // UserUpdate updates fields on a user
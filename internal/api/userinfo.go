@@ -0,0 +1,101 @@
+package api
+
+import (
+	"net/http"
+	"slices"
+
+	"github.com/supabase/auth/internal/api/apierrors"
+	"github.com/supabase/auth/internal/models"
+)
+
+// oidcScopesSupported are the OpenID Connect scopes that control which
+// standard claims are disclosed about the user.
+var oidcScopesSupported = []string{"openid", "email", "phone", "profile"}
+
+// OIDCUserClaims are the OpenID Connect standard claims about a user. Which
+// claims are set depends on the scopes granted to the client.
+type OIDCUserClaims struct {
+	Email               string `json:"email,omitempty"`
+	EmailVerified       *bool  `json:"email_verified,omitempty"`
+	PhoneNumber         string `json:"phone_number,omitempty"`
+	PhoneNumberVerified *bool  `json:"phone_number_verified,omitempty"`
+	Name                string `json:"name,omitempty"`
+	UpdatedAt           int64  `json:"updated_at,omitempty"`
+}
+
+// UserInfoResponse is the response of the OpenID Connect UserInfo endpoint
+type UserInfoResponse struct {
+	Subject string `json:"sub"`
+	OIDCUserClaims
+}
+
+// oidcUserClaims returns the standard claims about the user that the scopes
+// give access to.
+func oidcUserClaims(user *models.User, scopes []string) OIDCUserClaims {
+	claims := OIDCUserClaims{}
+
+	if slices.Contains(scopes, "email") && user.GetEmail() != "" {
+		verified := user.IsConfirmed()
+		claims.Email = user.GetEmail()
+		claims.EmailVerified = &verified
+	}
+
+	if slices.Contains(scopes, "phone") && user.GetPhone() != "" {
+		verified := user.IsPhoneConfirmed()
+		claims.PhoneNumber = user.GetPhone()
+		claims.PhoneNumberVerified = &verified
+	}
+
+	if slices.Contains(scopes, "profile") {
+		for _, key := range []string{"name", "full_name"} {
+			if name, ok := user.UserMetaData[key].(string); ok && name != "" {
+				claims.Name = name
+				break
+			}
+		}
+		claims.UpdatedAt = user.UpdatedAt.Unix()
+	}
+
+	return claims
+}
+
+// OAuthUserInfo implements the OpenID Connect UserInfo endpoint. Access
+// tokens issued to OAuth server clients only disclose the claims covered by
+// the scopes the user consented to, while first-party access tokens disclose
+// all of them.
+func (a *API) OAuthUserInfo(w http.ResponseWriter, r *http.Request) error {
+	ctx := r.Context()
+	db := a.db.WithContext(ctx)
+	user := getUser(ctx)
+	session := getSession(ctx)
+
+	if user == nil || session == nil {
+		return apierrors.NewForbiddenError(apierrors.ErrorCodeBadJWT, "A user session is required")
+	}
+
+	scopes := oidcScopesSupported
+	if session.OAuthClientID != nil {
+		consent, err := models.FindActiveOAuthServerConsent(db, user.ID, *session.OAuthClientID)
+		if err != nil {
+			if models.IsNotFoundError(err) {
+				return apierrors.NewForbiddenError(apierrors.ErrorCodeOAuthConsentNotFound, "The user has not authorized this client")
+			}
+			return apierrors.NewInternalServerError("Error loading OAuth grant").WithInternalError(err)
+		}
+
+		scopes = consent.GetScopes()
+		if session.Scopes != nil {
+			// the client may have been granted fewer scopes than consented to
+			scopes = models.ParseOAuthScopes(*session.Scopes)
+		}
+		if !slices.Contains(scopes, "openid") {
+			return apierrors.NewForbiddenError(apierrors.ErrorCodeOAuthInsufficientScope, "The openid scope is required")
+		}
+	}
+
+	w.Header().Set("Cache-Control", "no-store")
+	return sendJSON(w, http.StatusOK, &UserInfoResponse{
+		Subject:        user.ID.String(),
+		OIDCUserClaims: oidcUserClaims(user, scopes),
+	})
+}
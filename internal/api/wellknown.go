@@ -0,0 +1,139 @@
+package api
+
+import (
+	"net/http"
+	"slices"
+	"strings"
+
+	"github.com/supabase/auth/internal/api/oauthserver"
+	"github.com/supabase/auth/internal/conf"
+	"github.com/supabase/auth/internal/models"
+)
+
+// oauthGrantTypesSupported are the standard OAuth grant types handled by
+// API.Token.
+var oauthGrantTypesSupported = []string{
+	"authorization_code",
+	"refresh_token",
+	"client_credentials",
+	oauthserver.DeviceCodeGrantType,
+	oauthserver.TokenExchangeGrantType,
+	"password",
+}
+
+// AuthorizationServerMetadata is the RFC 8414 authorization server metadata
+// document. The OpenID Connect discovery document extends it with the
+// fields marked omitempty.
+type AuthorizationServerMetadata struct {
+	Issuer                                     string   `json:"issuer"`
+	AuthorizationEndpoint                      string   `json:"authorization_endpoint"`
+	TokenEndpoint                              string   `json:"token_endpoint"`
+	JwksURI                                    string   `json:"jwks_uri"`
+	RegistrationEndpoint                       string   `json:"registration_endpoint,omitempty"`
+	DeviceAuthorizationEndpoint                string   `json:"device_authorization_endpoint"`
+	PushedAuthorizationRequestEndpoint         string   `json:"pushed_authorization_request_endpoint"`
+	RequirePushedAuthorizationRequests         bool     `json:"require_pushed_authorization_requests"`
+	IntrospectionEndpoint                      string   `json:"introspection_endpoint"`
+	RevocationEndpoint                         string   `json:"revocation_endpoint"`
+	ResponseTypesSupported                     []string `json:"response_types_supported"`
+	ResponseModesSupported                     []string `json:"response_modes_supported"`
+	GrantTypesSupported                        []string `json:"grant_types_supported"`
+	TokenEndpointAuthMethodsSupported          []string `json:"token_endpoint_auth_methods_supported"`
+	TokenEndpointAuthSigningAlgValuesSupported []string `json:"token_endpoint_auth_signing_alg_values_supported"`
+	IntrospectionEndpointAuthMethodsSupported  []string `json:"introspection_endpoint_auth_methods_supported"`
+	RevocationEndpointAuthMethodsSupported     []string `json:"revocation_endpoint_auth_methods_supported"`
+	CodeChallengeMethodsSupported              []string `json:"code_challenge_methods_supported"`
+	DPoPSigningAlgValuesSupported              []string `json:"dpop_signing_alg_values_supported"`
+
+	// OpenID Connect discovery fields
+	UserInfoEndpoint                 string   `json:"userinfo_endpoint,omitempty"`
+	ScopesSupported                  []string `json:"scopes_supported,omitempty"`
+	ClaimsSupported                  []string `json:"claims_supported,omitempty"`
+	SubjectTypesSupported            []string `json:"subject_types_supported,omitempty"`
+	IDTokenSigningAlgValuesSupported []string `json:"id_token_signing_alg_values_supported,omitempty"`
+}
+
+// OAuthAuthorizationServerMetadata handles GET /.well-known/oauth-authorization-server
+func (a *API) OAuthAuthorizationServerMetadata(w http.ResponseWriter, r *http.Request) error {
+	w.Header().Set("Cache-Control", "public, max-age=600")
+	return sendJSON(w, http.StatusOK, a.authorizationServerMetadata())
+}
+
+// OpenIDConfiguration handles GET /.well-known/openid-configuration
+func (a *API) OpenIDConfiguration(w http.ResponseWriter, r *http.Request) error {
+	metadata := a.authorizationServerMetadata()
+	metadata.UserInfoEndpoint = strings.TrimSuffix(a.config.API.ExternalURL, "/") + "/oauth/userinfo"
+	metadata.ScopesSupported = oidcScopesSupported
+	metadata.ClaimsSupported = []string{
+		"sub", "iss", "aud", "exp", "iat", "auth_time", "nonce", "amr", "acr", "sid",
+		"email", "email_verified", "phone_number", "phone_number_verified", "name", "updated_at",
+	}
+	metadata.SubjectTypesSupported = []string{"public"}
+	metadata.IDTokenSigningAlgValuesSupported = jwtSigningAlgorithms(&a.config.JWT)
+
+	w.Header().Set("Cache-Control", "public, max-age=600")
+	return sendJSON(w, http.StatusOK, metadata)
+}
+
+func (a *API) authorizationServerMetadata() *AuthorizationServerMetadata {
+	config := a.config
+	baseURL := strings.TrimSuffix(config.API.ExternalURL, "/")
+
+	clientAuthMethods := []string{"client_secret_basic", "client_secret_post", models.PrivateKeyJWTAuthMethod}
+
+	metadata := &AuthorizationServerMetadata{
+		Issuer:                                     a.oauthIssuer(),
+		AuthorizationEndpoint:                      baseURL + "/oauth/authorize",
+		TokenEndpoint:                              baseURL + "/token",
+		JwksURI:                                    baseURL + "/.well-known/jwks.json",
+		IntrospectionEndpoint:                      baseURL + "/oauth/introspect",
+		RevocationEndpoint:                         baseURL + "/oauth/revoke",
+		DeviceAuthorizationEndpoint:                baseURL + "/oauth/device/code",
+		PushedAuthorizationRequestEndpoint:         baseURL + "/oauth/par",
+		ResponseTypesSupported:                     []string{"code"},
+		ResponseModesSupported:                     []string{"query"},
+		GrantTypesSupported:                        oauthGrantTypesSupported,
+		TokenEndpointAuthMethodsSupported:          clientAuthMethods,
+		TokenEndpointAuthSigningAlgValuesSupported: oauthserver.ClientAssertionSigningMethods,
+		IntrospectionEndpointAuthMethodsSupported:  clientAuthMethods,
+		RevocationEndpointAuthMethodsSupported:     clientAuthMethods,
+		CodeChallengeMethodsSupported:              []string{"S256"},
+		DPoPSigningAlgValuesSupported:              dpopSigningAlgorithms,
+	}
+
+	if config.OAuthServer.AllowDynamicRegistration {
+		metadata.RegistrationEndpoint = baseURL + "/oauth/clients/register"
+	}
+
+	return metadata
+}
+
+// oauthIssuer returns the issuer identifier of the authorization server
+func (a *API) oauthIssuer() string {
+	if a.config.JWT.Issuer != "" {
+		return a.config.JWT.Issuer
+	}
+	return strings.TrimSuffix(a.config.API.ExternalURL, "/")
+}
+
+// jwtSigningAlgorithms returns the algorithms of the configured signing keys
+func jwtSigningAlgorithms(config *conf.JWTConfiguration) []string {
+	algorithms := []string{}
+	for _, key := range config.Keys {
+		if key.PrivateKey == nil {
+			// only keys that can sign are used to issue tokens
+			continue
+		}
+
+		alg := conf.GetSigningAlg(key.PrivateKey).Alg()
+		if !slices.Contains(algorithms, alg) {
+			algorithms = append(algorithms, alg)
+		}
+	}
+	slices.Sort(algorithms)
+
+	if len(algorithms) == 0 {
+		algorithms = append(algorithms, conf.GetSigningAlg(nil).Alg())
+	}
+	return algorithms
+}
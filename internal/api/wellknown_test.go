@@ -0,0 +1,68 @@
+package api
+
+import (
+	"crypto/rand"
+	"crypto/rsa"
+	"encoding/json"
+	"net/http"
+	"net/http/httptest"
+	"testing"
+
+	"github.com/lestrrat-go/jwx/v2/jwa"
+	"github.com/lestrrat-go/jwx/v2/jwk"
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+	"github.com/supabase/auth/internal/conf"
+)
+
+func TestOAuthAuthorizationServerMetadata(t *testing.T) {
+	mockAPI, config, err := setupAPIForTest()
+	require.NoError(t, err)
+
+	for _, allowDynamicRegistration := range []bool{true, false} {
+		config.OAuthServer.AllowDynamicRegistration = allowDynamicRegistration
+
+		req := httptest.NewRequest(http.MethodGet, "/.well-known/oauth-authorization-server", nil)
+		w := httptest.NewRecorder()
+		mockAPI.handler.ServeHTTP(w, req)
+		require.Equal(t, http.StatusOK, w.Code)
+
+		var metadata AuthorizationServerMetadata
+		require.NoError(t, json.NewDecoder(w.Body).Decode(&metadata))
+		assert.NotEmpty(t, metadata.Issuer)
+		assert.Contains(t, metadata.GrantTypesSupported, "authorization_code")
+		assert.Contains(t, metadata.CodeChallengeMethodsSupported, "S256")
+		assert.Equal(t, dpopSigningAlgorithms, metadata.DPoPSigningAlgValuesSupported)
+		assert.Equal(t, allowDynamicRegistration, metadata.RegistrationEndpoint != "")
+		assert.Empty(t, metadata.IDTokenSigningAlgValuesSupported)
+	}
+}
+
+func TestOpenIDConfiguration(t *testing.T) {
+	rsaPrivateKey, err := rsa.GenerateKey(rand.Reader, 2048)
+	require.NoError(t, err)
+	rsaJwkPrivate, err := jwk.FromRaw(rsaPrivateKey)
+	require.NoError(t, err)
+	require.NoError(t, rsaJwkPrivate.Set(jwk.AlgorithmKey, jwa.RS256))
+	rsaJwkPublic, err := rsaJwkPrivate.PublicKey()
+	require.NoError(t, err)
+
+	mockAPI, _, err := setupAPIForTest()
+	require.NoError(t, err)
+	mockAPI.config.JWT.Keys = conf.JwtKeysDecoder{
+		"rsa": conf.JwkInfo{
+			PublicKey:  rsaJwkPublic,
+			PrivateKey: rsaJwkPrivate,
+		},
+	}
+
+	req := httptest.NewRequest(http.MethodGet, "/.well-known/openid-configuration", nil)
+	w := httptest.NewRecorder()
+	mockAPI.handler.ServeHTTP(w, req)
+	require.Equal(t, http.StatusOK, w.Code)
+
+	var metadata AuthorizationServerMetadata
+	require.NoError(t, json.NewDecoder(w.Body).Decode(&metadata))
+	assert.Equal(t, []string{"RS256"}, metadata.IDTokenSigningAlgValuesSupported)
+	assert.Equal(t, []string{"public"}, metadata.SubjectTypesSupported)
+}
@@ -74,6 +74,38 @@ type OAuthProviderConfiguration struct {
 // OAuthServerConfiguration holds OAuth server configuration
 type OAuthServerConfiguration struct {
 	AllowDynamicRegistration bool `json:"allow_dynamic_registration" split_words:"true"`
+
+	// AuthorizationPath is the path on the Site URL where users are sent
+	// to review and approve authorization requests from OAuth clients.
+	AuthorizationPath string `json:"authorization_path" split_words:"true" default:"/oauth/consent"`
+
+	// AuthorizationTTL is how long an authorization request (and the
+	// authorization code issued for it) remains valid.
+	AuthorizationTTL time.Duration `json:"authorization_ttl" split_words:"true" default:"10m"`
+
+	// PushedAuthorizationRequestTTL is how long the request_uri returned for
+	// a pushed authorization request remains valid.
+	PushedAuthorizationRequestTTL time.Duration `json:"pushed_authorization_request_ttl" split_words:"true" default:"60s"`
+
+	// ClientSecretRotationOverlap is how long the previous secret of an OAuth
+	// client remains valid after the secret has been rotated.
+	ClientSecretRotationOverlap time.Duration `json:"client_secret_rotation_overlap" split_words:"true" default:"24h"`
+
+	// DeviceVerificationPath is the path on the Site URL where users enter
+	// the user code shown by a device using the device authorization grant.
+	DeviceVerificationPath string `json:"device_verification_path" split_words:"true" default:"/oauth/device"`
+
+	// DeviceCodeTTL is how long a device code remains valid.
+	DeviceCodeTTL time.Duration `json:"device_code_ttl" split_words:"true" default:"10m"`
+
+	// DevicePollingInterval is the minimum time devices must wait between
+	// polling the token endpoint.
+	DevicePollingInterval time.Duration `json:"device_polling_interval" split_words:"true" default:"5s"`
+
+	// ClientCredentialsRole is the role of access tokens issued to clients
+	// through the client_credentials grant. It differs from the role of user
+	// sessions so that policies written for users don't apply to clients.
+	ClientCredentialsRole string `json:"client_credentials_role" split_words:"true" default:"oauth_client"`
 }
 
 type AnonymousProviderConfiguration struct {
@@ -140,16 +172,114 @@ type PhoneFactorTypeConfiguration struct {
 	Template     string             `json:"template"`
 }
 
+type EmailFactorTypeConfiguration struct {
+	// Default to false in order to ensure Email MFA is opt-in
+	MFAFactorTypeConfiguration
+	OtpLength    int           `json:"otp_length" split_words:"true"`
+	MaxFrequency time.Duration `json:"max_frequency" split_words:"true"`
+}
+
+type RecoveryCodesFactorTypeConfiguration struct {
+	MFAFactorTypeConfiguration
+	// Count is the number of codes generated at once
+	Count int `json:"count" default:"10"`
+}
+
+// TrustedDeviceConfiguration holds the configuration of devices users can
+// trust to skip MFA challenges on
+type TrustedDeviceConfiguration struct {
+	Enabled        bool          `json:"enabled" default:"false"`
+	ExpiryDuration time.Duration `json:"expiry_duration" split_words:"true" default:"720h"`
+}
+
+// MFAEnforcementConfiguration holds the policy that requires matching users
+// to have a verified MFA factor. Users whose app_metadata.role is one of Roles
+// or whose email domain is one of EmailDomains must enroll a factor within
+// GracePeriod of their sign up, or of StartsAt if that is later.
+type MFAEnforcementConfiguration struct {
+	Enabled      bool          `json:"enabled" default:"false"`
+	Roles        []string      `json:"roles"`
+	EmailDomains []string      `json:"email_domains" split_words:"true"`
+	GracePeriod  time.Duration `json:"grace_period" split_words:"true" default:"168h"`
+	StartsAt     time.Time     `json:"starts_at" split_words:"true"`
+}
+
+func (c *MFAEnforcementConfiguration) Validate() error {
+	if !c.Enabled {
+		return nil
+	}
+
+	if len(c.Roles) == 0 && len(c.EmailDomains) == 0 {
+		return errors.New("conf: MFA enforcement requires at least one role or email domain")
+	}
+
+	if c.GracePeriod < 0 {
+		return errors.New("conf: MFA enforcement grace period must not be negative")
+	}
+
+	for i, domain := range c.EmailDomains {
+		c.EmailDomains[i] = strings.ToLower(strings.TrimPrefix(strings.TrimSpace(domain), "@"))
+	}
+
+	return nil
+}
+
 // MFAConfiguration holds all the MFA related Configuration
 type MFAConfiguration struct {
-	ChallengeExpiryDuration     float64                      `json:"challenge_expiry_duration" default:"300" split_words:"true"`
-	FactorExpiryDuration        time.Duration                `json:"factor_expiry_duration" default:"300s" split_words:"true"`
-	RateLimitChallengeAndVerify float64                      `split_words:"true" default:"15"`
-	MaxEnrolledFactors          float64                      `split_words:"true" default:"10"`
-	MaxVerifiedFactors          int                          `split_words:"true" default:"10"`
-	Phone                       PhoneFactorTypeConfiguration `split_words:"true"`
-	TOTP                        TOTPFactorTypeConfiguration  `split_words:"true"`
-	WebAuthn                    MFAFactorTypeConfiguration   `split_words:"true"`
+	ChallengeExpiryDuration     float64                              `json:"challenge_expiry_duration" default:"300" split_words:"true"`
+	FactorExpiryDuration        time.Duration                        `json:"factor_expiry_duration" default:"300s" split_words:"true"`
+	RateLimitChallengeAndVerify float64                              `split_words:"true" default:"15"`
+	MaxEnrolledFactors          float64                              `split_words:"true" default:"10"`
+	MaxVerifiedFactors          int                                  `split_words:"true" default:"10"`
+	Phone                       PhoneFactorTypeConfiguration         `split_words:"true"`
+	TOTP                        TOTPFactorTypeConfiguration          `split_words:"true"`
+	WebAuthn                    MFAFactorTypeConfiguration           `split_words:"true"`
+	RecoveryCodes               RecoveryCodesFactorTypeConfiguration `split_words:"true"`
+	Email                       EmailFactorTypeConfiguration         `split_words:"true"`
+	TrustedDevices              TrustedDeviceConfiguration           `split_words:"true"`
+	Enforcement                 MFAEnforcementConfiguration
+}
+
+// PasskeyConfiguration holds the configuration of passwordless sign-in with
+// discoverable WebAuthn credentials.
+type PasskeyConfiguration struct {
+	Enabled                 bool          `json:"enabled" default:"false"`
+	RPID                    string        `json:"rp_id" envconfig:"RP_ID"`
+	RPDisplayName           string        `json:"rp_display_name" envconfig:"RP_DISPLAY_NAME"`
+	RPOrigins               []string      `json:"rp_origins" envconfig:"RP_ORIGINS"`
+	ChallengeExpiryDuration time.Duration `json:"challenge_expiry_duration" split_words:"true" default:"5m"`
+	MaxPerUser              int           `json:"max_per_user" split_words:"true" default:"10"`
+}
+
+func (c *PasskeyConfiguration) Validate() error {
+	if !c.Enabled {
+		return nil
+	}
+
+	if c.RPID == "" {
+		return errors.New("conf: passkey RP ID must be set when passkeys are enabled")
+	}
+
+	if len(c.RPOrigins) == 0 {
+		return errors.New("conf: passkey RP origins must be set when passkeys are enabled")
+	}
+
+	for _, origin := range c.RPOrigins {
+		u, err := url.Parse(origin)
+		if err != nil || u.Host == "" || (u.Scheme != "https" && !(u.Scheme == "http" && u.Hostname() == "localhost")) {
+			return fmt.Errorf("conf: passkey RP origin %q must be an https URL", origin)
+		}
+	}
+
+	if c.ChallengeExpiryDuration <= 0 {
+		return fmt.Errorf("conf: passkey challenge expiry duration must be positive, was %v", c.ChallengeExpiryDuration.String())
+	}
+
+	if c.MaxPerUser < 1 {
+		return fmt.Errorf("conf: passkey max per user must be at least 1, was %v", c.MaxPerUser)
+	}
+
+	return nil
 }
 
 type APIConfiguration struct {
@@ -177,7 +307,27 @@ type SessionsConfiguration struct {
 
 	SinglePerUser bool     `json:"single_per_user" split_words:"true"`
 	Tags          []string `json:"tags,omitempty"`
-}
+
+	// MaxPerUser limits the number of concurrent sessions of a user when
+	// positive. MaxPerUserEviction decides what happens to new sign-ins once
+	// the limit is reached, and MaxPerUserPerTag applies the limit to the
+	// sessions of each tag separately.
+	MaxPerUser         int    `json:"max_per_user,omitempty" split_words:"true"`
+	MaxPerUserEviction string `json:"max_per_user_eviction,omitempty" split_words:"true" default:"oldest_created"`
+	MaxPerUserPerTag   bool   `json:"max_per_user_per_tag,omitempty" split_words:"true"`
+
+	// GeoIPCountryDatabase and GeoIPASNDatabase are paths to MaxMind DB
+	// files used to record the country and autonomous system of sessions.
+	GeoIPCountryDatabase string `json:"geoip_country_database,omitempty" split_words:"true"`
+	GeoIPASNDatabase     string `json:"geoip_asn_database,omitempty" split_words:"true"`
+}
+
+// Eviction strategies applied when a user reaches Sessions.MaxPerUser
+const (
+	SessionEvictionOldestCreated          = "oldest_created"
+	SessionEvictionLeastRecentlyRefreshed = "least_recently_refreshed"
+	SessionEvictionReject                 = "reject"
+)
 
 func (c *SessionsConfiguration) Validate() error {
 	if c.Timebox != nil && *c.Timebox <= time.Duration(0) {
@@ -192,6 +342,16 @@ func (c *SessionsConfiguration) Validate() error {
 		return fmt.Errorf("conf: session allow low AAL duration must be positive when set, was %v", (*c.AllowLowAAL).String())
 	}
 
+	if c.MaxPerUser < 0 {
+		return fmt.Errorf("conf: session max per user must not be negative, was %v", c.MaxPerUser)
+	}
+
+	switch c.MaxPerUserEviction {
+	case "", SessionEvictionOldestCreated, SessionEvictionLeastRecentlyRefreshed, SessionEvictionReject:
+	default:
+		return fmt.Errorf("conf: session max per user eviction must be one of %q, %q or %q, was %q", SessionEvictionOldestCreated, SessionEvictionLeastRecentlyRefreshed, SessionEvictionReject, c.MaxPerUserEviction)
+	}
+
 	return nil
 }
 
@@ -277,7 +437,9 @@ type GlobalConfiguration struct {
 	RateLimitAnonymousUsers             float64 `split_words:"true" default:"30"`
 	RateLimitOtp                        float64 `split_words:"true" default:"30"`
 	RateLimitWeb3                       float64 `split_words:"true" default:"30"`
+	RateLimitPasskey                    float64 `split_words:"true" default:"30"`
 	RateLimitOAuthDynamicClientRegister float64 `split_words:"true" default:"10"`
+	RateLimitOAuthDeviceVerification    float64 `split_words:"true" default:"30"`
 
 	SiteURL         string   `json:"site_url" split_words:"true" required:"true"`
 	URIAllowList    []string `json:"uri_allow_list" split_words:"true"`
@@ -291,6 +453,7 @@ type GlobalConfiguration struct {
 	Security        SecurityConfiguration    `json:"security"`
 	Sessions        SessionsConfiguration    `json:"sessions"`
 	MFA             MFAConfiguration         `json:"MFA"`
+	Passkey         PasskeyConfiguration     `json:"passkey"`
 	SAML            SAMLConfiguration        `json:"saml"`
 	CORS            CORSConfiguration        `json:"cors"`
 }
@@ -327,6 +490,9 @@ type EmailContentConfiguration struct {
 	EmailChange      string `json:"email_change" split_words:"true"`
 	MagicLink        string `json:"magic_link" split_words:"true"`
 	Reauthentication string `json:"reauthentication"`
+
+	NewSignInNotification string `json:"new_sign_in_notification" split_words:"true"`
+	MFAEmail              string `json:"mfa_email" split_words:"true"`
 }
 
 type ProviderConfiguration struct {
@@ -430,6 +596,10 @@ type MailerConfiguration struct {
 
 	SecureEmailChangeEnabled bool `json:"secure_email_change_enabled" split_words:"true" default:"true"`
 
+	// NewSignInNotificationEnabled notifies users by email when they sign
+	// in from a device or location that wasn't seen before.
+	NewSignInNotificationEnabled bool `json:"new_sign_in_notification_enabled" split_words:"true" default:"false"`
+
 	OtpExp    uint `json:"otp_exp" split_words:"true"`
 	OtpLength int  `json:"otp_length" split_words:"true"`
 
@@ -626,6 +796,10 @@ type SecurityConfiguration struct {
 	UpdatePasswordRequireReauthentication bool                 `json:"update_password_require_reauthentication" split_words:"true"`
 	ManualLinkingEnabled                  bool                 `json:"manual_linking_enabled" split_words:"true" default:"false"`
 
+	// DPoPProofMaxAge is how far the iat of a DPoP proof may be from the
+	// current time for the proof to be accepted.
+	DPoPProofMaxAge time.Duration `json:"dpop_proof_max_age" split_words:"true" default:"60s"`
+
 	DBEncryption DatabaseEncryptionConfiguration `json:"database_encryption" split_words:"true"`
 }
 
@@ -1012,6 +1186,10 @@ func (config *GlobalConfiguration) ApplyDefaults() error {
 		config.Mailer.URLPaths.EmailChange = "/verify"
 	}
 
+	if config.Mailer.URLPaths.NewSignInNotification == "" {
+		config.Mailer.URLPaths.NewSignInNotification = "/sessions/report"
+	}
+
 	if config.Mailer.OtpExp == 0 {
 		config.Mailer.OtpExp = 86400 // 1 day
 	}
@@ -1084,6 +1262,19 @@ func (config *GlobalConfiguration) ApplyDefaults() error {
 		config.MFA.Phone.OtpLength = 6
 	}
 
+	if config.MFA.Email.MaxFrequency == 0 {
+		config.MFA.Email.MaxFrequency = 1 * time.Minute
+	}
+
+	if config.MFA.Email.OtpLength < 6 || config.MFA.Email.OtpLength > 10 {
+		// 6-digit otp by default
+		config.MFA.Email.OtpLength = 6
+	}
+
+	if config.MFA.RecoveryCodes.Count < 1 || config.MFA.RecoveryCodes.Count > 100 {
+		config.MFA.RecoveryCodes.Count = 10
+	}
+
 	if config.External.FlowStateExpiryDuration < defaultFlowStateExpiryDuration {
 		config.External.FlowStateExpiryDuration = defaultFlowStateExpiryDuration
 	}
@@ -1148,6 +1339,8 @@ func (c *GlobalConfiguration) Validate() error {
 		&c.SAML,
 		&c.Security,
 		&c.Sessions,
+		&c.Passkey,
+		&c.MFA.Enforcement,
 		&c.Hook,
 		&c.JWT.Keys,
 	}
@@ -900,6 +900,31 @@ func TestValidate(t *testing.T) {
 				require.NoError(t, err)
 			},
 		},
+		{
+			val: &MFAEnforcementConfiguration{},
+		},
+		{
+			val: &MFAEnforcementConfiguration{Enabled: true},
+			err: `conf: MFA enforcement requires at least one role or email domain`,
+		},
+		{
+			val: &MFAEnforcementConfiguration{
+				Enabled:     true,
+				Roles:       []string{"admin"},
+				GracePeriod: -time.Hour,
+			},
+			err: `conf: MFA enforcement grace period must not be negative`,
+		},
+		{
+			val: &MFAEnforcementConfiguration{
+				Enabled:      true,
+				EmailDomains: []string{" @Example.COM "},
+			},
+			check: func(t *testing.T, v any) {
+				cfg := v.(*MFAEnforcementConfiguration)
+				require.Equal(t, []string{"example.com"}, cfg.EmailDomains)
+			},
+		},
 	}
 
 	for idx, tc := range cases {
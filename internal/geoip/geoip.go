@@ -0,0 +1,104 @@
+// Package geoip looks up the country and autonomous system of IP addresses
+// in locally stored MaxMind DB files, such as the GeoLite2 Country and ASN
+// databases.
+package geoip
+
+import (
+	"net"
+
+	"github.com/oschwald/maxminddb-golang"
+)
+
+// Location is the network location of an IP address. Fields are empty when
+// they couldn't be determined.
+type Location struct {
+	// Country is the ISO 3166-1 alpha-2 code of the country
+	Country string
+
+	// ASN is the number of the autonomous system announcing the address
+	ASN             int64
+	ASNOrganization string
+}
+
+// countryRecord is the subset of the GeoLite2 Country record that is used
+type countryRecord struct {
+	Country struct {
+		ISOCode string `maxminddb:"iso_code"`
+	} `maxminddb:"country"`
+	RegisteredCountry struct {
+		ISOCode string `maxminddb:"iso_code"`
+	} `maxminddb:"registered_country"`
+}
+
+// asnRecord is the GeoLite2 ASN record
+type asnRecord struct {
+	Number       uint32 `maxminddb:"autonomous_system_number"`
+	Organization string `maxminddb:"autonomous_system_organization"`
+}
+
+// Database combines a country and an ASN database, either of which may be
+// missing.
+type Database struct {
+	Country *maxminddb.Reader
+	ASN     *maxminddb.Reader
+}
+
+// OpenDatabase opens the country and ASN databases at the paths. Empty paths
+// are skipped.
+func OpenDatabase(countryPath, asnPath string) (*Database, error) {
+	db := &Database{}
+
+	if countryPath != "" {
+		reader, err := maxminddb.Open(countryPath)
+		if err != nil {
+			return nil, err
+		}
+		db.Country = reader
+	}
+
+	if asnPath != "" {
+		reader, err := maxminddb.Open(asnPath)
+		if err != nil {
+			if db.Country != nil {
+				_ = db.Country.Close()
+			}
+			return nil, err
+		}
+		db.ASN = reader
+	}
+
+	return db, nil
+}
+
+// Lookup returns the location of the IP address. It is safe to call on a nil
+// database, and lookup errors result in an empty location.
+func (db *Database) Lookup(address string) Location {
+	var location Location
+
+	ip := net.ParseIP(address)
+	if db == nil || ip == nil {
+		return location
+	}
+
+	if db.Country != nil {
+		var record countryRecord
+		if err := db.Country.Lookup(ip, &record); err == nil {
+			// the registered country is used when the address is
+			// not in any specific country, e.g. anycast addresses
+			location.Country = record.Country.ISOCode
+			if location.Country == "" {
+				location.Country = record.RegisteredCountry.ISOCode
+			}
+		}
+	}
+
+	if db.ASN != nil {
+		var record asnRecord
+		if err := db.ASN.Lookup(ip, &record); err == nil {
+			location.ASN = int64(record.Number)
+			location.ASNOrganization = record.Organization
+		}
+	}
+
+	return location
+}
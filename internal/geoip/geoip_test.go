@@ -0,0 +1,35 @@
+package geoip
+
+import (
+	"os"
+	"path/filepath"
+	"testing"
+
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+)
+
+func TestLookupWithoutDatabases(t *testing.T) {
+	db, err := OpenDatabase("", "")
+	require.NoError(t, err)
+
+	assert.Equal(t, Location{}, db.Lookup("1.2.3.4"))
+	assert.Equal(t, Location{}, db.Lookup("not an ip"))
+
+	var missing *Database
+	assert.Equal(t, Location{}, missing.Lookup("1.2.3.4"))
+}
+
+func TestOpenDatabaseInvalid(t *testing.T) {
+	path := filepath.Join(t.TempDir(), "invalid.mmdb")
+	require.NoError(t, os.WriteFile(path, []byte("not a database"), 0600))
+
+	_, err := OpenDatabase(path, "")
+	require.Error(t, err)
+
+	_, err = OpenDatabase("", path)
+	require.Error(t, err)
+
+	_, err = OpenDatabase(filepath.Join(t.TempDir(), "missing.mmdb"), "")
+	require.Error(t, err)
+}
@@ -109,6 +109,16 @@ type AccessTokenClaims struct {
 	AuthenticationMethodReference []models.AMREntry      `json:"amr,omitempty"`
 	SessionId                     string                 `json:"session_id,omitempty"`
 	IsAnonymous                   bool                   `json:"is_anonymous"`
+	Scope                         string                 `json:"scope,omitempty"`
+	ClientID                      string                 `json:"client_id,omitempty"`
+	Confirmation                  *ConfirmationClaim     `json:"cnf,omitempty"`
+	MFARequired                   bool                   `json:"mfa_required,omitempty"`
+}
+
+// ConfirmationClaim is the RFC 7800 cnf claim of access tokens bound to a
+// DPoP key (RFC 9449).
+type ConfirmationClaim struct {
+	JKT string `json:"jkt"`
 }
 
 type MFAVerificationAttemptInput struct {
@@ -18,6 +18,8 @@ type Mailer interface {
 	MagicLinkMail(r *http.Request, user *models.User, otp, referrerURL string, externalURL *url.URL) error
 	EmailChangeMail(r *http.Request, user *models.User, otpNew, otpCurrent, referrerURL string, externalURL *url.URL) error
 	ReauthenticateMail(r *http.Request, user *models.User, otp string) error
+	NewSignInNotificationMail(r *http.Request, user *models.User, session *models.Session, reportURL string) error
+	MFAEmailMail(r *http.Request, user *models.User, email, otp string) error
 	GetEmailActionLink(user *models.User, actionType, referrerURL string, externalURL *url.URL) (string, error)
 }
 
@@ -35,6 +37,12 @@ type EmailData struct {
 	SiteURL         string `json:"site_url"`
 	TokenNew        string `json:"token_new"`
 	TokenHashNew    string `json:"token_hash_new"`
+
+	// Session is the session a sign-in notification is sent for
+	Session *models.Session `json:"session,omitempty"`
+
+	// FactorEmail is the address of the email MFA factor a code is sent to
+	FactorEmail string `json:"factor_email,omitempty"`
 }
 
 // NewMailer returns a new gotrue mailer
@@ -6,6 +6,7 @@ import (
 	"net/http"
 	"net/url"
 	"strings"
+	"time"
 
 	"github.com/supabase/auth/internal/conf"
 	"github.com/supabase/auth/internal/models"
@@ -65,6 +66,14 @@ const (
 	ReauthenticationVerification   = "reauthentication"
 )
 
+// NewSignInNotification is the email action type of the notifications sent
+// when a user signs in from a new device or location
+const NewSignInNotification = "new_sign_in"
+
+// MFAEmailVerification is the email action type of the codes sent to email
+// MFA factors
+const MFAEmailVerification = "mfa_email"
+
 const defaultInviteMail = `<h2>You have been invited</h2>
 
 <p>You have been invited to create a user on {{ .SiteURL }}. Follow this link to accept the invite:</p>
@@ -100,6 +109,23 @@ const defaultReauthenticateMail = `<h2>Confirm reauthentication</h2>
 
 <p>Enter the code: {{ .Token }}</p>`
 
+const defaultNewSignInNotificationMail = `<h2>New sign-in to your account</h2>
+
+<p>Your account {{ .Email }} was signed in to from a new device or location.</p>
+<ul>
+{{ if .Browser }}<li>Browser: {{ .Browser }}</li>{{ end }}
+{{ if .OS }}<li>Operating system: {{ .OS }}</li>{{ end }}
+{{ if .IP }}<li>IP address: {{ .IP }}</li>{{ end }}
+{{ if .Country }}<li>Country: {{ .Country }}</li>{{ end }}
+<li>Time: {{ .SignedInAt }}</li>
+</ul>
+<p>If this was you, you can ignore this email.</p>
+<p>If this wasn't you, <a href="{{ .ReportURL }}">sign out this session and reset your password</a>.</p>`
+
+const defaultMFAEmailMail = `<h2>Your verification code</h2>
+
+<p>Enter the code to finish signing in: {{ .Token }}</p>`
+
 func (m *TemplateMailer) Headers(messageType string) map[string][]string {
 	originalHeaders := m.Config.SMTP.NormalizedHeaders()
 
@@ -223,6 +249,65 @@ func (m *TemplateMailer) ReauthenticateMail(r *http.Request, user *models.User,
 	)
 }
 
+// MFAEmailMail sends the code of an email MFA factor challenge to the
+// factor's address, which may differ from the user's email.
+func (m *TemplateMailer) MFAEmailMail(r *http.Request, user *models.User, email, otp string) error {
+	data := map[string]interface{}{
+		"SiteURL":     m.Config.SiteURL,
+		"Email":       user.Email,
+		"FactorEmail": email,
+		"Token":       otp,
+		"Data":        user.UserMetaData,
+	}
+
+	return m.Mailer.Mail(
+		r.Context(),
+		email,
+		withDefault(m.Config.Mailer.Subjects.MFAEmail, "Your verification code"),
+		m.Config.Mailer.Templates.MFAEmail,
+		defaultMFAEmailMail,
+		data,
+		m.Headers(MFAEmailVerification),
+		MFAEmailVerification,
+	)
+}
+
+// NewSignInNotificationMail notifies a user of a sign-in from a new device or
+// location. The report URL revokes the session and starts password recovery.
+func (m *TemplateMailer) NewSignInNotificationMail(r *http.Request, user *models.User, session *models.Session, reportURL string) error {
+	value := func(s *string) string {
+		if s == nil {
+			return ""
+		}
+		return *s
+	}
+
+	data := map[string]interface{}{
+		"SiteURL":    m.Config.SiteURL,
+		"Email":      user.Email,
+		"Data":       user.UserMetaData,
+		"ReportURL":  reportURL,
+		"UserAgent":  value(session.UserAgent),
+		"DeviceType": value(session.DeviceType),
+		"OS":         value(session.OS),
+		"Browser":    value(session.Browser),
+		"IP":         value(session.IP),
+		"Country":    value(session.Country),
+		"SignedInAt": session.CreatedAt.UTC().Format(time.RFC1123),
+	}
+
+	return m.Mailer.Mail(
+		r.Context(),
+		user.GetEmail(),
+		withDefault(m.Config.Mailer.Subjects.NewSignInNotification, "New sign-in to your account"),
+		m.Config.Mailer.Templates.NewSignInNotification,
+		defaultNewSignInNotificationMail,
+		data,
+		m.Headers(NewSignInNotification),
+		NewSignInNotification,
+	)
+}
+
 // EmailChangeMail sends an email change confirmation mail to a user
 func (m *TemplateMailer) EmailChangeMail(r *http.Request, user *models.User, otpNew, otpCurrent, referrerURL string, externalURL *url.URL) error {
 	type Email struct {
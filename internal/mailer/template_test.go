@@ -1,10 +1,15 @@
 package mailer
 
 import (
+	"context"
+	"net/http"
+	"net/http/httptest"
 	"testing"
+	"time"
 
 	"github.com/stretchr/testify/require"
 	"github.com/supabase/auth/internal/conf"
+	"github.com/supabase/auth/internal/models"
 )
 
 func TestTemplateHeaders(t *testing.T) {
@@ -63,3 +68,71 @@ func TestTemplateHeaders(t *testing.T) {
 		require.Equal(t, hdrs, tc.exp)
 	}
 }
+
+type recordingMailClient struct {
+	to           string
+	subject      string
+	typ          string
+	templateData map[string]interface{}
+}
+
+func (c *recordingMailClient) Mail(ctx context.Context, to, subjectTemplate, templateURL, defaultTemplate string, templateData map[string]interface{}, headers map[string][]string, typ string) error {
+	c.to = to
+	c.subject = subjectTemplate
+	c.typ = typ
+	c.templateData = templateData
+	return nil
+}
+
+func TestNewSignInNotificationMail(t *testing.T) {
+	client := &recordingMailClient{}
+	mailer := TemplateMailer{
+		Config: &conf.GlobalConfiguration{},
+		Mailer: client,
+	}
+
+	user, err := models.NewUser("", "test@example.com", "", "authenticated", nil)
+	require.NoError(t, err)
+
+	browser := "Firefox"
+	country := "NL"
+	session := &models.Session{
+		UserID:    user.ID,
+		CreatedAt: time.Date(2025, 8, 18, 12, 0, 0, 0, time.UTC),
+		Browser:   &browser,
+		Country:   &country,
+	}
+
+	req := httptest.NewRequest(http.MethodPost, "/token", nil)
+	require.NoError(t, mailer.NewSignInNotificationMail(req, user, session, "https://auth.example.com/sessions/report?token=abc"))
+
+	require.Equal(t, "test@example.com", client.to)
+	require.Equal(t, "New sign-in to your account", client.subject)
+	require.Equal(t, NewSignInNotification, client.typ)
+	require.Equal(t, "https://auth.example.com/sessions/report?token=abc", client.templateData["ReportURL"])
+	require.Equal(t, "Firefox", client.templateData["Browser"])
+	require.Equal(t, "NL", client.templateData["Country"])
+	require.Equal(t, "", client.templateData["OS"])
+	require.Equal(t, "Mon, 18 Aug 2025 12:00:00 UTC", client.templateData["SignedInAt"])
+}
+
+func TestMFAEmailMail(t *testing.T) {
+	client := &recordingMailClient{}
+	mailer := TemplateMailer{
+		Config: &conf.GlobalConfiguration{},
+		Mailer: client,
+	}
+
+	user, err := models.NewUser("", "test@example.com", "", "authenticated", nil)
+	require.NoError(t, err)
+
+	req := httptest.NewRequest(http.MethodPost, "/factors", nil)
+	require.NoError(t, mailer.MFAEmailMail(req, user, "work@example.org", "123456"))
+
+	// the code is sent to the factor's address, not the user's email
+	require.Equal(t, "work@example.org", client.to)
+	require.Equal(t, "Your verification code", client.subject)
+	require.Equal(t, MFAEmailVerification, client.typ)
+	require.Equal(t, "123456", client.templateData["Token"])
+	require.Equal(t, "work@example.org", client.templateData["FactorEmail"])
+}
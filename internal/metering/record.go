@@ -14,6 +14,7 @@ const (
 	LoginTypeSSO       LoginType = "sso"
 	LoginTypeOAuth     LoginType = "oauth"
 	LoginTypeWeb3      LoginType = "web3"
+	LoginTypePasskey   LoginType = "passkey"
 	LoginTypeImplicit  LoginType = "implicit"
 	LoginTypeOIDC      LoginType = "oidc"
 	LoginTypeOTP       LoginType = "otp"
@@ -21,6 +22,8 @@ const (
 	LoginTypePKCE      LoginType = "pkce"
 	LoginTypeToken     LoginType = "token" // for refresh token flows, to be backward-compatible with existing data
 	LoginTypeMFA       LoginType = "mfa"   // for MFA verifications
+
+	LoginTypeOAuthProvider LoginType = "oauth_provider" // for tokens issued to OAuth server clients
 )
 
 // Provider constants for consistent login analytics
@@ -30,9 +33,12 @@ const (
 	ProviderPhone = "phone"
 
 	// MFA providers
-	ProviderMFATOTP     = "totp"
-	ProviderMFAPhone    = "phone"
-	ProviderMFAWebAuthn = "webauthn"
+	ProviderMFATOTP          = "totp"
+	ProviderMFAPhone         = "phone"
+	ProviderMFAWebAuthn      = "webauthn"
+	ProviderMFARecoveryCode  = "recovery_code"
+	ProviderMFAEmail         = "email"
+	ProviderMFATrustedDevice = "trusted_device"
 
 	// SSO providers
 	ProviderSAML = "saml"
@@ -23,6 +23,8 @@ type auditLogType string
 const (
 	LoginAction                     AuditAction = "login"
 	LogoutAction                    AuditAction = "logout"
+	SessionReportedAction           AuditAction = "session_reported"
+	SessionEvictedAction            AuditAction = "session_evicted"
 	InviteAcceptedAction            AuditAction = "invite_accepted"
 	UserSignedUpAction              AuditAction = "user_signedup"
 	UserInvitedAction               AuditAction = "user_invited"
@@ -35,6 +37,7 @@ const (
 	UserUpdatePasswordAction        AuditAction = "user_updated_password"
 	TokenRevokedAction              AuditAction = "token_revoked"
 	TokenRefreshedAction            AuditAction = "token_refreshed"
+	TokenExchangedAction            AuditAction = "token_exchanged"
 	GenerateRecoveryCodesAction     AuditAction = "generate_recovery_codes"
 	EnrollFactorAction              AuditAction = "factor_in_progress"
 	UnenrollFactorAction            AuditAction = "factor_unenrolled"
@@ -45,6 +48,14 @@ const (
 	UpdateFactorAction              AuditAction = "factor_updated"
 	MFACodeLoginAction              AuditAction = "mfa_code_login"
 	IdentityUnlinkAction            AuditAction = "identity_unlinked"
+	OAuthConsentGrantedAction       AuditAction = "oauth_consent_granted"
+	OAuthConsentRevokedAction       AuditAction = "oauth_consent_revoked"
+	PasskeyRegisteredAction         AuditAction = "passkey_registered"
+	PasskeyUpdatedAction            AuditAction = "passkey_updated"
+	PasskeyDeletedAction            AuditAction = "passkey_deleted"
+	TrustedDeviceCreatedAction      AuditAction = "trusted_device_created"
+	TrustedDeviceUsedAction         AuditAction = "trusted_device_used"
+	TrustedDeviceRevokedAction      AuditAction = "trusted_device_revoked"
 
 	account       auditLogType = "account"
 	team          auditLogType = "team"
@@ -52,17 +63,22 @@ const (
 	user          auditLogType = "user"
 	factor        auditLogType = "factor"
 	recoveryCodes auditLogType = "recovery_codes"
+	oauthConsent  auditLogType = "oauth_consent"
+	passkeys      auditLogType = "passkey"
 )
 
 var ActionLogTypeMap = map[AuditAction]auditLogType{
 	LoginAction:                     account,
 	LogoutAction:                    account,
+	SessionReportedAction:           account,
+	SessionEvictedAction:            account,
 	InviteAcceptedAction:            account,
 	UserSignedUpAction:              team,
 	UserInvitedAction:               team,
 	UserDeletedAction:               team,
 	TokenRevokedAction:              token,
 	TokenRefreshedAction:            token,
+	TokenExchangedAction:            token,
 	UserModifiedAction:              user,
 	UserRecoveryRequestedAction:     user,
 	UserConfirmationRequestedAction: user,
@@ -77,6 +93,14 @@ var ActionLogTypeMap = map[AuditAction]auditLogType{
 	UpdateFactorAction:              factor,
 	MFACodeLoginAction:              factor,
 	DeleteRecoveryCodesAction:       recoveryCodes,
+	OAuthConsentGrantedAction:       oauthConsent,
+	OAuthConsentRevokedAction:       oauthConsent,
+	PasskeyRegisteredAction:         passkeys,
+	PasskeyUpdatedAction:            passkeys,
+	PasskeyDeletedAction:            passkeys,
+	TrustedDeviceCreatedAction:      factor,
+	TrustedDeviceUsedAction:         factor,
+	TrustedDeviceRevokedAction:      factor,
 }
 
 // AuditLogEntry is the database model for audit log entries.
@@ -40,6 +40,14 @@ func NewCleanup(config *conf.GlobalConfiguration) *Cleanup {
 	tableFlowStates := FlowState{}.TableName()
 	tableMFAChallenges := Challenge{}.TableName()
 	tableMFAFactors := Factor{}.TableName()
+	tableOAuthAuthorizations := OAuthServerAuthorization{}.TableName()
+	tableOAuthDeviceAuthorizations := OAuthServerDeviceAuthorization{}.TableName()
+	tableOAuthClientAssertions := OAuthServerClientAssertion{}.TableName()
+	tableOAuthPushedAuthorizationRequests := OAuthServerPushedAuthorizationRequest{}.TableName()
+	tableDPoPProofs := DPoPProof{}.TableName()
+	tableSignInNotifications := SignInNotification{}.TableName()
+	tablePasskeyChallenges := PasskeyChallenge{}.TableName()
+	tableTrustedDevices := TrustedDevice{}.TableName()
 
 	c := &Cleanup{}
 
@@ -58,6 +66,14 @@ func NewCleanup(config *conf.GlobalConfiguration) *Cleanup {
 		fmt.Sprintf("delete from %q where id in (select id from %q where created_at < now() - interval '24 hours' limit 100 for update skip locked);", tableFlowStates, tableFlowStates),
 		fmt.Sprintf("delete from %q where id in (select id from %q where created_at < now() - interval '24 hours' limit 100 for update skip locked);", tableMFAChallenges, tableMFAChallenges),
 		fmt.Sprintf("delete from %q where id in (select id from %q where created_at < now() - interval '24 hours' and status = 'unverified' limit 100 for update skip locked);", tableMFAFactors, tableMFAFactors),
+		fmt.Sprintf("delete from %q where id in (select id from %q where expires_at < now() - interval '24 hours' limit 100 for update skip locked);", tableOAuthAuthorizations, tableOAuthAuthorizations),
+		fmt.Sprintf("delete from %q where id in (select id from %q where expires_at < now() - interval '24 hours' limit 100 for update skip locked);", tableOAuthDeviceAuthorizations, tableOAuthDeviceAuthorizations),
+		fmt.Sprintf("delete from %q where id in (select id from %q where expires_at < now() limit 100 for update skip locked);", tableOAuthClientAssertions, tableOAuthClientAssertions),
+		fmt.Sprintf("delete from %q where id in (select id from %q where expires_at < now() limit 100 for update skip locked);", tableOAuthPushedAuthorizationRequests, tableOAuthPushedAuthorizationRequests),
+		fmt.Sprintf("delete from %q where id in (select id from %q where expires_at < now() limit 100 for update skip locked);", tableDPoPProofs, tableDPoPProofs),
+		fmt.Sprintf("delete from %q where id in (select id from %q where expires_at < now() limit 100 for update skip locked);", tableSignInNotifications, tableSignInNotifications),
+		fmt.Sprintf("delete from %q where id in (select id from %q where expires_at < now() limit 100 for update skip locked);", tablePasskeyChallenges, tablePasskeyChallenges),
+		fmt.Sprintf("delete from %q where id in (select id from %q where expires_at < now() limit 100 for update skip locked);", tableTrustedDevices, tableTrustedDevices),
 	)
 
 	if config.External.AnonymousUsers.Enabled {
@@ -42,6 +42,10 @@ func TruncateAll(conn *storage.Connection) error {
 			(&pop.Model{Value: Session{}}).TableName(),
 			(&pop.Model{Value: Factor{}}).TableName(),
 			(&pop.Model{Value: Challenge{}}).TableName(),
+			(&pop.Model{Value: FactorRecoveryCode{}}).TableName(),
+			(&pop.Model{Value: Passkey{}}).TableName(),
+			(&pop.Model{Value: PasskeyChallenge{}}).TableName(),
+			(&pop.Model{Value: TrustedDevice{}}).TableName(),
 			(&pop.Model{Value: AMRClaim{}}).TableName(),
 			(&pop.Model{Value: SSOProvider{}}).TableName(),
 			(&pop.Model{Value: SSODomain{}}).TableName(),
@@ -49,6 +53,15 @@ func TruncateAll(conn *storage.Connection) error {
 			(&pop.Model{Value: SAMLRelayState{}}).TableName(),
 			(&pop.Model{Value: FlowState{}}).TableName(),
 			(&pop.Model{Value: OneTimeToken{}}).TableName(),
+			(&pop.Model{Value: OAuthServerAuthorization{}}).TableName(),
+			(&pop.Model{Value: OAuthServerConsent{}}).TableName(),
+			(&pop.Model{Value: OAuthServerDeviceAuthorization{}}).TableName(),
+			(&pop.Model{Value: OAuthServerClientAssertion{}}).TableName(),
+			(&pop.Model{Value: OAuthServerPushedAuthorizationRequest{}}).TableName(),
+			(&pop.Model{Value: DPoPProof{}}).TableName(),
+			(&pop.Model{Value: OAuthServerResource{}}).TableName(),
+			(&pop.Model{Value: KnownDevice{}}).TableName(),
+			(&pop.Model{Value: SignInNotification{}}).TableName(),
 			(&pop.Model{Value: OAuthServerClient{}}).TableName(),
 		}
 
@@ -0,0 +1,37 @@
+package models
+
+import (
+	"fmt"
+	"time"
+
+	"github.com/gofrs/uuid"
+	"github.com/pkg/errors"
+	"github.com/supabase/auth/internal/storage"
+)
+
+// DPoPProof records the jti of a DPoP proof (RFC 9449) so that it can't be
+// replayed while its iat is still accepted.
+type DPoPProof struct {
+	ID        uuid.UUID `json:"-" db:"id"`
+	JKT       string    `json:"-" db:"jkt"`
+	JTI       string    `json:"-" db:"jti"`
+	CreatedAt time.Time `json:"-" db:"created_at"`
+	ExpiresAt time.Time `json:"-" db:"expires_at"`
+}
+
+// TableName returns the table name for the DPoPProof model
+func (DPoPProof) TableName() string {
+	return "dpop_proofs"
+}
+
+// RecordDPoPProof records that a proof with the jti was signed by the key
+// with the thumbprint jkt. It returns false if the jti has been used before.
+func RecordDPoPProof(tx *storage.Connection, jkt, jti string, expiresAt time.Time) (bool, error) {
+	query := fmt.Sprintf("insert into %q (id, jkt, jti, created_at, expires_at) values (?, ?, ?, now(), ?) on conflict (jkt, jti) do nothing", DPoPProof{}.TableName())
+
+	count, err := tx.RawQuery(query, uuid.Must(uuid.NewV4()), jkt, jti, expiresAt).ExecWithCount()
+	if err != nil {
+		return false, errors.Wrap(err, "error recording DPoP proof")
+	}
+	return count > 0, nil
+}
@@ -29,6 +29,26 @@ func IsNotFoundError(err error) bool {
 		return true
 	case OAuthServerClientNotFoundError, *OAuthServerClientNotFoundError:
 		return true
+	case OAuthServerAuthorizationNotFoundError, *OAuthServerAuthorizationNotFoundError:
+		return true
+	case OAuthServerConsentNotFoundError, *OAuthServerConsentNotFoundError:
+		return true
+	case OAuthServerDeviceAuthorizationNotFoundError, *OAuthServerDeviceAuthorizationNotFoundError:
+		return true
+	case OAuthServerPushedAuthorizationRequestNotFoundError, *OAuthServerPushedAuthorizationRequestNotFoundError:
+		return true
+	case OAuthServerResourceNotFoundError, *OAuthServerResourceNotFoundError:
+		return true
+	case SignInNotificationNotFoundError, *SignInNotificationNotFoundError:
+		return true
+	case RecoveryCodeNotFoundError, *RecoveryCodeNotFoundError:
+		return true
+	case PasskeyNotFoundError, *PasskeyNotFoundError:
+		return true
+	case PasskeyChallengeNotFoundError, *PasskeyChallengeNotFoundError:
+		return true
+	case TrustedDeviceNotFoundError, *TrustedDeviceNotFoundError:
+		return true
 	}
 	return false
 }
@@ -125,3 +145,82 @@ type UserEmailUniqueConflictError struct{}
 func (e UserEmailUniqueConflictError) Error() string {
 	return "User email unique constraint violated"
 }
+
+// OAuthServerAuthorizationNotFoundError represents an error when an OAuth
+// authorization request can't be found.
+type OAuthServerAuthorizationNotFoundError struct{}
+
+func (e OAuthServerAuthorizationNotFoundError) Error() string {
+	return "OAuth authorization not found"
+}
+
+// OAuthServerConsentNotFoundError represents an error when an OAuth consent
+// can't be found.
+type OAuthServerConsentNotFoundError struct{}
+
+func (e OAuthServerConsentNotFoundError) Error() string {
+	return "OAuth consent not found"
+}
+
+// OAuthServerDeviceAuthorizationNotFoundError represents an error when an
+// OAuth device authorization request can't be found.
+type OAuthServerDeviceAuthorizationNotFoundError struct{}
+
+func (e OAuthServerDeviceAuthorizationNotFoundError) Error() string {
+	return "OAuth device authorization not found"
+}
+
+// OAuthServerPushedAuthorizationRequestNotFoundError represents an error when
+// a pushed authorization request can't be found.
+type OAuthServerPushedAuthorizationRequestNotFoundError struct{}
+
+func (e OAuthServerPushedAuthorizationRequestNotFoundError) Error() string {
+	return "OAuth pushed authorization request not found"
+}
+
+// OAuthServerResourceNotFoundError represents an error when an OAuth resource
+// can't be found.
+type OAuthServerResourceNotFoundError struct{}
+
+func (e OAuthServerResourceNotFoundError) Error() string {
+	return "OAuth resource not found"
+}
+
+// SignInNotificationNotFoundError represents an error when a sign-in
+// notification can't be found.
+type SignInNotificationNotFoundError struct{}
+
+func (e SignInNotificationNotFoundError) Error() string {
+	return "Sign-in notification not found"
+}
+
+// RecoveryCodeNotFoundError represents an error when an unused recovery code
+// can't be found.
+type RecoveryCodeNotFoundError struct{}
+
+func (e RecoveryCodeNotFoundError) Error() string {
+	return "Recovery code not found"
+}
+
+// PasskeyNotFoundError represents an error when a passkey can't be found.
+type PasskeyNotFoundError struct{}
+
+func (e PasskeyNotFoundError) Error() string {
+	return "Passkey not found"
+}
+
+// PasskeyChallengeNotFoundError represents an error when a passkey challenge
+// can't be found.
+type PasskeyChallengeNotFoundError struct{}
+
+func (e PasskeyChallengeNotFoundError) Error() string {
+	return "Passkey challenge not found"
+}
+
+// TrustedDeviceNotFoundError represents an error when a trusted device can't
+// be found.
+type TrustedDeviceNotFoundError struct{}
+
+func (e TrustedDeviceNotFoundError) Error() string {
+	return "Trusted device not found"
+}
@@ -36,6 +36,8 @@ func (factorState FactorState) String() string {
 const TOTP = "totp"
 const Phone = "phone"
 const WebAuthn = "webauthn"
+const RecoveryCode = "recovery_code"
+const Email = "email"
 
 type AuthenticationMethod int
 
@@ -55,6 +57,12 @@ const (
 	TokenRefresh
 	Anonymous
 	Web3
+	OAuthProviderAuthorizationCode
+	OAuthProviderDeviceCode
+	MFARecoveryCode
+	PasskeySignIn
+	MFAEmail
+	MFATrustedDevice
 )
 
 func (authMethod AuthenticationMethod) String() string {
@@ -89,6 +97,18 @@ func (authMethod AuthenticationMethod) String() string {
 		return "mfa/webauthn"
 	case Web3:
 		return "web3"
+	case OAuthProviderAuthorizationCode:
+		return "oauth_provider/authorization_code"
+	case OAuthProviderDeviceCode:
+		return "oauth_provider/device_code"
+	case MFARecoveryCode:
+		return "mfa/recovery_code"
+	case PasskeySignIn:
+		return "passkey"
+	case MFAEmail:
+		return "mfa/email"
+	case MFATrustedDevice:
+		return "mfa/trusted_device"
 	}
 	return ""
 }
@@ -126,6 +146,18 @@ func ParseAuthenticationMethod(authMethod string) (AuthenticationMethod, error)
 		return MFAWebAuthn, nil
 	case "web3":
 		return Web3, nil
+	case "oauth_provider/authorization_code":
+		return OAuthProviderAuthorizationCode, nil
+	case "oauth_provider/device_code":
+		return OAuthProviderDeviceCode, nil
+	case "mfa/recovery_code":
+		return MFARecoveryCode, nil
+	case "passkey":
+		return PasskeySignIn, nil
+	case "mfa/email":
+		return MFAEmail, nil
+	case "mfa/trusted_device":
+		return MFATrustedDevice, nil
 
 	}
 	return 0, fmt.Errorf("unsupported authentication method %q", authMethod)
@@ -147,6 +179,7 @@ type Factor struct {
 	LastChallengedAt   *time.Time          `json:"last_challenged_at" db:"last_challenged_at"`
 	WebAuthnCredential *WebAuthnCredential `json:"-" db:"web_authn_credential"`
 	WebAuthnAAGUID     *uuid.UUID          `json:"web_authn_aaguid,omitempty" db:"web_authn_aaguid"`
+	Email              storage.NullString  `json:"email,omitempty" db:"email"`
 }
 
 type WebAuthnCredential struct {
@@ -210,6 +243,14 @@ func NewPhoneFactor(user *User, phone, friendlyName string) *Factor {
 	return factor
 }
 
+// NewEmailFactor creates an email factor sending its codes to email, which
+// doesn't have to be the user's own email.
+func NewEmailFactor(user *User, email, friendlyName string) *Factor {
+	factor := NewFactor(user, friendlyName, Email, FactorStateUnverified)
+	factor.Email = storage.NullString(email)
+	return factor
+}
+
 func NewWebAuthnFactor(user *User, friendlyName string) *Factor {
 	factor := NewFactor(user, friendlyName, WebAuthn, FactorStateUnverified)
 	return factor
@@ -312,6 +353,16 @@ func (f *Factor) CreatePhoneChallenge(ipAddress string, otpCode string, encrypt
 	return phoneChallenge, nil
 }
 
+// CreateEmailChallenge creates a challenge holding the code sent to the
+// email factor's address.
+func (f *Factor) CreateEmailChallenge(ipAddress string, otpCode string, encrypt bool, encryptionKeyID, encryptionKey string) (*Challenge, error) {
+	emailChallenge := f.CreateChallenge(ipAddress)
+	if err := emailChallenge.SetOtpCode(otpCode, encrypt, encryptionKeyID, encryptionKey); err != nil {
+		return nil, err
+	}
+	return emailChallenge, nil
+}
+
 // UpdateFriendlyName changes the friendly name
 func (f *Factor) UpdateFriendlyName(tx *storage.Connection, friendlyName string) error {
 	f.FriendlyName = friendlyName
@@ -323,6 +374,11 @@ func (f *Factor) UpdatePhone(tx *storage.Connection, phone string) error {
 	return tx.UpdateOnly(f, "phone", "updated_at")
 }
 
+func (f *Factor) UpdateEmail(tx *storage.Connection, email string) error {
+	f.Email = storage.NullString(email)
+	return tx.UpdateOnly(f, "email", "updated_at")
+}
+
 // UpdateStatus modifies the factor status
 func (f *Factor) UpdateStatus(tx *storage.Connection, state FactorState) error {
 	f.Status = state.String()
@@ -354,6 +410,10 @@ func (f *Factor) IsPhoneFactor() bool {
 	return f.FactorType == Phone
 }
 
+func (f *Factor) IsEmailFactor() bool {
+	return f.FactorType == Email
+}
+
 func (f *Factor) FindChallengeByID(conn *storage.Connection, challengeID uuid.UUID) (*Challenge, error) {
 	var challenge Challenge
 	err := conn.Q().Where("id = ? and factor_id = ?", challengeID, f.ID).First(&challenge)
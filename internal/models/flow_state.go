@@ -134,15 +134,21 @@ func FindFlowStateByUserID(tx *storage.Connection, id string, authenticationMeth
 }
 
 func (f *FlowState) VerifyPKCE(codeVerifier string) error {
-	switch f.CodeChallengeMethod {
+	return verifyPKCE(f.CodeChallengeMethod, f.CodeChallenge, codeVerifier)
+}
+
+// verifyPKCE checks a code verifier against a previously saved code challenge
+// as described in RFC 7636.
+func verifyPKCE(codeChallengeMethod, codeChallenge, codeVerifier string) error {
+	switch codeChallengeMethod {
 	case SHA256.String():
 		hashedCodeVerifier := sha256.Sum256([]byte(codeVerifier))
 		encodedCodeVerifier := base64.RawURLEncoding.EncodeToString(hashedCodeVerifier[:])
-		if subtle.ConstantTimeCompare([]byte(f.CodeChallenge), []byte(encodedCodeVerifier)) != 1 {
+		if subtle.ConstantTimeCompare([]byte(codeChallenge), []byte(encodedCodeVerifier)) != 1 {
 			return errors.New(InvalidCodeChallengeError)
 		}
 	case Plain.String():
-		if subtle.ConstantTimeCompare([]byte(f.CodeChallenge), []byte(codeVerifier)) != 1 {
+		if subtle.ConstantTimeCompare([]byte(codeChallenge), []byte(codeVerifier)) != 1 {
 			return errors.New(InvalidCodeChallengeError)
 		}
 	default:
@@ -0,0 +1,79 @@
+package models
+
+import (
+	"fmt"
+	"time"
+
+	"github.com/gofrs/uuid"
+	"github.com/pkg/errors"
+	"github.com/supabase/auth/internal/storage"
+)
+
+// KnownDevice records a device and country a user has signed in from, so
+// that sign-ins from new devices or locations can be detected.
+type KnownDevice struct {
+	ID          uuid.UUID `json:"id" db:"id"`
+	UserID      uuid.UUID `json:"user_id" db:"user_id"`
+	Fingerprint string    `json:"fingerprint" db:"fingerprint"`
+
+	// Country is empty when the location of the sign-in wasn't known
+	Country string `json:"country" db:"country"`
+
+	CreatedAt  time.Time `json:"created_at" db:"created_at"`
+	LastSeenAt time.Time `json:"last_seen_at" db:"last_seen_at"`
+}
+
+// TableName returns the table name for the KnownDevice model
+func (KnownDevice) TableName() string {
+	return "known_devices"
+}
+
+// DeviceFingerprint identifies the kind of device the session was created
+// on, e.g. desktop/macOS/Safari.
+func (s *Session) DeviceFingerprint() string {
+	value := func(s *string) string {
+		if s == nil {
+			return ""
+		}
+		return *s
+	}
+
+	return value(s.DeviceType) + "/" + value(s.OS) + "/" + value(s.Browser)
+}
+
+// RecordKnownDevice records that the user signed in from the device and
+// country, and returns whether either of them wasn't seen for the user
+// before. Neither is reported as new for the first sign-in of a user, as
+// there is nothing to compare with.
+func RecordKnownDevice(tx *storage.Connection, userID uuid.UUID, fingerprint, country string) (newDevice bool, newCountry bool, err error) {
+	var devices []*KnownDevice
+	if err := tx.Q().Where("user_id = ?", userID).All(&devices); err != nil {
+		return false, false, errors.Wrap(err, "error finding known devices")
+	}
+
+	seenDevice := false
+	seenCountry := country == ""
+	for _, device := range devices {
+		if device.Fingerprint == fingerprint {
+			seenDevice = true
+		}
+		if device.Country == country {
+			seenCountry = true
+		}
+	}
+
+	if err := tx.RawQuery(fmt.Sprintf(`
+		insert into %q (id, user_id, fingerprint, country, created_at, last_seen_at)
+		values (?, ?, ?, ?, now(), now())
+		on conflict (user_id, fingerprint, country) do update set last_seen_at = now()`,
+		KnownDevice{}.TableName()),
+		uuid.Must(uuid.NewV4()), userID, fingerprint, country).Exec(); err != nil {
+		return false, false, errors.Wrap(err, "error recording known device")
+	}
+
+	if len(devices) == 0 {
+		return false, false, nil
+	}
+
+	return !seenDevice, !seenCountry, nil
+}
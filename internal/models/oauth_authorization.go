@@ -0,0 +1,230 @@
+package models
+
+import (
+	"database/sql"
+	"fmt"
+	"strings"
+	"time"
+
+	"github.com/gofrs/uuid"
+	"github.com/pkg/errors"
+	"github.com/supabase/auth/internal/crypto"
+	"github.com/supabase/auth/internal/storage"
+)
+
+// OAuthServerAuthorizationStatus represents the state of an OAuth authorization request
+type OAuthServerAuthorizationStatus string
+
+const (
+	OAuthServerAuthorizationPending  OAuthServerAuthorizationStatus = "pending"
+	OAuthServerAuthorizationApproved OAuthServerAuthorizationStatus = "approved"
+	OAuthServerAuthorizationDenied   OAuthServerAuthorizationStatus = "denied"
+	OAuthServerAuthorizationExpired  OAuthServerAuthorizationStatus = "expired"
+)
+
+// OAuthServerAuthorization represents an authorization request made by an
+// OAuth server client on behalf of a user, as well as the authorization code
+// issued once the user has given consent.
+type OAuthServerAuthorization struct {
+	ID              uuid.UUID  `json:"-" db:"id"`
+	AuthorizationID string     `json:"authorization_id" db:"authorization_id"`
+	ClientID        uuid.UUID  `json:"-" db:"client_id"`
+	UserID          *uuid.UUID `json:"-" db:"user_id"`
+
+	RedirectURI         string             `json:"redirect_uri" db:"redirect_uri"`
+	RedirectURIProvided bool               `json:"-" db:"redirect_uri_provided"`
+	Scope               string             `json:"scope" db:"scope"`
+	State               storage.NullString `json:"-" db:"state"`
+	Nonce               storage.NullString `json:"-" db:"nonce"`
+	CodeChallenge       string             `json:"-" db:"code_challenge"`
+	CodeChallengeMethod string             `json:"-" db:"code_challenge_method"`
+	ResponseType        string             `json:"response_type" db:"response_type"`
+
+	Status            OAuthServerAuthorizationStatus `json:"status" db:"status"`
+	AuthorizationCode storage.NullString             `json:"-" db:"authorization_code"`
+
+	// AAL, AMR and AuthTime record how the user authenticated in the
+	// session consent was given from
+	AAL      storage.NullString `json:"-" db:"aal"`
+	AMR      storage.NullString `json:"-" db:"amr"`
+	AuthTime *time.Time         `json:"-" db:"auth_time"`
+
+	CreatedAt  time.Time  `json:"created_at" db:"created_at"`
+	ExpiresAt  time.Time  `json:"expires_at" db:"expires_at"`
+	ApprovedAt *time.Time `json:"approved_at,omitempty" db:"approved_at"`
+}
+
+// OAuthServerAuthentication describes how the user authenticated in the
+// session they gave consent from, as reported in ID tokens.
+type OAuthServerAuthentication struct {
+	AAL      string
+	AMR      []string
+	AuthTime time.Time
+}
+
+// NewOAuthServerAuthentication returns how the user authenticated in the
+// session. The authentication time is that of the most recent
+// authentication method used in the session.
+func NewOAuthServerAuthentication(session *Session, user *User) (*OAuthServerAuthentication, error) {
+	aal, amr, err := session.CalculateAALAndAMR(user)
+	if err != nil {
+		return nil, err
+	}
+
+	authentication := &OAuthServerAuthentication{
+		AAL:      aal.String(),
+		AMR:      make([]string, 0, len(amr)),
+		AuthTime: session.CreatedAt,
+	}
+	for _, entry := range amr {
+		authentication.AMR = append(authentication.AMR, entry.Method)
+	}
+	if len(amr) > 0 {
+		// AMR entries are ordered most-recent first
+		authentication.AuthTime = time.Unix(amr[0].Timestamp, 0)
+	}
+
+	return authentication, nil
+}
+
+func newOAuthServerAuthentication(aal, amr storage.NullString, authTime *time.Time) *OAuthServerAuthentication {
+	if authTime == nil {
+		return nil
+	}
+
+	return &OAuthServerAuthentication{
+		AAL:      aal.String(),
+		AMR:      strings.Fields(amr.String()),
+		AuthTime: *authTime,
+	}
+}
+
+// TableName returns the table name for the OAuthServerAuthorization model
+func (OAuthServerAuthorization) TableName() string {
+	return "oauth_authorizations"
+}
+
+// NewOAuthServerAuthorization creates a new pending authorization request for
+// the provided client which expires after the provided duration.
+func NewOAuthServerAuthorization(client *OAuthServerClient, redirectURI, scope, state, codeChallenge string, codeChallengeMethod CodeChallengeMethod, expiresIn time.Duration) *OAuthServerAuthorization {
+	now := time.Now()
+
+	return &OAuthServerAuthorization{
+		ID:                  uuid.Must(uuid.NewV4()),
+		AuthorizationID:     crypto.SecureAlphanumeric(32),
+		ClientID:            client.ID,
+		RedirectURI:         redirectURI,
+		Scope:               scope,
+		State:               storage.NullString(state),
+		CodeChallenge:       codeChallenge,
+		CodeChallengeMethod: codeChallengeMethod.String(),
+		ResponseType:        "code",
+		Status:              OAuthServerAuthorizationPending,
+		CreatedAt:           now,
+		ExpiresAt:           now.Add(expiresIn),
+	}
+}
+
+// HasScope returns whether the scope was requested by the client
+func (a *OAuthServerAuthorization) HasScope(scope string) bool {
+	for _, s := range ParseOAuthScopes(a.Scope) {
+		if s == scope {
+			return true
+		}
+	}
+	return false
+}
+
+// IsExpired returns whether the authorization request (or the authorization
+// code issued for it) can no longer be used.
+func (a *OAuthServerAuthorization) IsExpired() bool {
+	return a.Status == OAuthServerAuthorizationExpired || time.Now().After(a.ExpiresAt)
+}
+
+// IsPending returns whether the authorization request is still awaiting the
+// user's decision.
+func (a *OAuthServerAuthorization) IsPending() bool {
+	return a.Status == OAuthServerAuthorizationPending
+}
+
+// VerifyPKCE checks the code verifier presented at the token endpoint against
+// the code challenge sent with the authorization request.
+func (a *OAuthServerAuthorization) VerifyPKCE(codeVerifier string) error {
+	// OAuth 2.1 only allows the S256 method
+	if a.CodeChallengeMethod != SHA256.String() {
+		return errors.New("code_challenge_method must be S256")
+	}
+	return verifyPKCE(a.CodeChallengeMethod, a.CodeChallenge, codeVerifier)
+}
+
+// SetUser binds the authorization request to the user giving consent.
+func (a *OAuthServerAuthorization) SetUser(tx *storage.Connection, userID uuid.UUID) error {
+	a.UserID = &userID
+	return tx.UpdateOnly(a, "user_id")
+}
+
+// Authentication returns how the user authenticated when approving the
+// request, which is nil for requests approved before it was recorded.
+func (a *OAuthServerAuthorization) Authentication() *OAuthServerAuthentication {
+	return newOAuthServerAuthentication(a.AAL, a.AMR, a.AuthTime)
+}
+
+// Approve marks the authorization request as approved and issues a new
+// authorization code valid until the provided expiry. The authentication of
+// the session consent was given from is recorded, if known.
+func (a *OAuthServerAuthorization) Approve(tx *storage.Connection, authentication *OAuthServerAuthentication, codeExpiresIn time.Duration) error {
+	now := time.Now()
+
+	if authentication != nil {
+		a.AAL = storage.NullString(authentication.AAL)
+		a.AMR = storage.NullString(strings.Join(authentication.AMR, " "))
+		a.AuthTime = &authentication.AuthTime
+	}
+
+	a.Status = OAuthServerAuthorizationApproved
+	a.AuthorizationCode = storage.NullString(crypto.SecureAlphanumeric(48))
+	a.ApprovedAt = &now
+	a.ExpiresAt = now.Add(codeExpiresIn)
+
+	return tx.UpdateOnly(a, "status", "authorization_code", "approved_at", "expires_at", "aal", "amr", "auth_time")
+}
+
+// Deny marks the authorization request as denied by the user.
+func (a *OAuthServerAuthorization) Deny(tx *storage.Connection) error {
+	a.Status = OAuthServerAuthorizationDenied
+	return tx.UpdateOnly(a, "status")
+}
+
+// MarkExpired invalidates the authorization request and any authorization
+// code issued for it, so that codes can only ever be redeemed once.
+func (a *OAuthServerAuthorization) MarkExpired(tx *storage.Connection) error {
+	a.Status = OAuthServerAuthorizationExpired
+	a.AuthorizationCode = ""
+	return tx.UpdateOnly(a, "status", "authorization_code")
+}
+
+// FindOAuthServerAuthorizationByAuthorizationID finds an authorization request by its public authorization_id
+func FindOAuthServerAuthorizationByAuthorizationID(tx *storage.Connection, authorizationID string) (*OAuthServerAuthorization, error) {
+	authorization := &OAuthServerAuthorization{}
+	if err := tx.Q().Where("authorization_id = ?", authorizationID).First(authorization); err != nil {
+		if errors.Cause(err) == sql.ErrNoRows {
+			return nil, OAuthServerAuthorizationNotFoundError{}
+		}
+		return nil, errors.Wrap(err, "error finding OAuth authorization")
+	}
+	return authorization, nil
+}
+
+// FindOAuthServerAuthorizationByCode finds an approved authorization by the
+// authorization code issued for it, locking it for the rest of the
+// transaction so that the code can't be redeemed concurrently.
+func FindOAuthServerAuthorizationByCode(tx *storage.Connection, code string) (*OAuthServerAuthorization, error) {
+	authorization := &OAuthServerAuthorization{}
+	if err := tx.RawQuery(fmt.Sprintf("SELECT * FROM %q WHERE authorization_code = ? AND status = ? LIMIT 1 FOR UPDATE", authorization.TableName()), code, OAuthServerAuthorizationApproved).First(authorization); err != nil {
+		if errors.Cause(err) == sql.ErrNoRows {
+			return nil, OAuthServerAuthorizationNotFoundError{}
+		}
+		return nil, errors.Wrap(err, "error finding OAuth authorization")
+	}
+	return authorization, nil
+}
@@ -4,6 +4,7 @@ import (
 	"database/sql"
 	"fmt"
 	"net/url"
+	"slices"
 	"strings"
 	"time"
 
@@ -13,6 +14,14 @@ import (
 	"github.com/supabase/auth/internal/storage"
 )
 
+// PrivateKeyJWTAuthMethod is the RFC 7523 client authentication method using
+// JWTs signed with the client's private key.
+const PrivateKeyJWTAuthMethod = "private_key_jwt"
+
+// DefaultOAuthScopes are the scopes that can be requested by OAuth server
+// clients that have no scopes registered.
+var DefaultOAuthScopes = []string{"openid", "email", "phone", "profile"}
+
 // OAuthServerClient represents an OAuth client application registered with this OAuth server
 type OAuthServerClient struct {
 	ID               uuid.UUID `json:"-" db:"id"`
@@ -20,11 +29,37 @@ type OAuthServerClient struct {
 	ClientSecretHash string    `json:"-" db:"client_secret_hash"`
 	RegistrationType string    `json:"registration_type" db:"registration_type"`
 
+	// PreviousClientSecretHash is the hash of the secret that was replaced by
+	// the last secret rotation, which remains valid until it expires.
+	PreviousClientSecretHash      storage.NullString `json:"-" db:"previous_client_secret_hash"`
+	PreviousClientSecretExpiresAt *time.Time         `json:"-" db:"previous_client_secret_expires_at"`
+
+	// RegistrationAccessTokenHash is the hash of the RFC 7592 registration
+	// access token issued to dynamically registered clients.
+	RegistrationAccessTokenHash storage.NullString `json:"-" db:"registration_access_token_hash"`
+
+	// TokenEndpointAuthMethod is set to private_key_jwt for clients that
+	// authenticate with JWTs signed by a key from JWKS or JWKSURI instead of
+	// a client secret.
+	TokenEndpointAuthMethod storage.NullString `json:"token_endpoint_auth_method" db:"token_endpoint_auth_method"`
+	JWKS                    storage.NullString `json:"-" db:"jwks"`
+	JWKSURI                 storage.NullString `json:"jwks_uri" db:"jwks_uri"`
+
+	// TokenExchangeAudiences are the space separated audiences the client may
+	// exchange user access tokens for.
+	TokenExchangeAudiences storage.NullString `json:"token_exchange_audiences" db:"token_exchange_audiences"`
+
+	// RequirePushedAuthorizationRequests requires the client to push its
+	// authorization parameters to /oauth/par (RFC 9126).
+	RequirePushedAuthorizationRequests bool `json:"require_pushed_authorization_requests" db:"require_pushed_authorization_requests"`
+
 	RedirectURIs string             `json:"-" db:"redirect_uris"`
 	GrantTypes   string             `json:"grant_types" db:"grant_types"`
 	ClientName   storage.NullString `json:"client_name" db:"client_name"`
 	ClientURI    storage.NullString `json:"client_uri" db:"client_uri"`
 	LogoURI      storage.NullString `json:"logo_uri" db:"logo_uri"`
+	Audience     storage.NullString `json:"audience" db:"audience"`
+	Scopes       storage.NullString `json:"scopes" db:"scopes"`
 	CreatedAt    time.Time          `json:"created_at" db:"created_at"`
 	UpdatedAt    time.Time          `json:"updated_at" db:"updated_at"`
 	DeletedAt    *time.Time         `json:"deleted_at,omitempty" db:"deleted_at"`
@@ -51,10 +86,14 @@ func (c *OAuthServerClient) Validate() error {
 		return fmt.Errorf("registration_type must be 'dynamic' or 'manual'")
 	}
 
-	if c.RedirectURIs == "" {
+	if c.RedirectURIs == "" && c.HasGrantType("authorization_code") {
 		return fmt.Errorf("at least one redirect_uri is required")
 	}
 
+	if c.UsesPrivateKeyJWT() && c.JWKS == "" && c.JWKSURI == "" {
+		return fmt.Errorf("jwks or jwks_uri is required for private_key_jwt clients")
+	}
+
 	return nil
 }
 
@@ -84,6 +123,61 @@ func (c *OAuthServerClient) SetGrantTypes(types []string) {
 	c.GrantTypes = strings.Join(types, ",")
 }
 
+// HasGrantType returns whether the client is allowed to use the grant type
+func (c *OAuthServerClient) HasGrantType(grantType string) bool {
+	for _, t := range c.GetGrantTypes() {
+		if t == grantType {
+			return true
+		}
+	}
+	return false
+}
+
+// HasValidPreviousClientSecret returns whether the secret replaced by the last
+// rotation can still be used.
+func (c *OAuthServerClient) HasValidPreviousClientSecret() bool {
+	return c.PreviousClientSecretHash != "" && c.PreviousClientSecretExpiresAt != nil && time.Now().Before(*c.PreviousClientSecretExpiresAt)
+}
+
+// UsesPrivateKeyJWT returns whether the client authenticates with signed JWT
+// assertions rather than a client secret.
+func (c *OAuthServerClient) UsesPrivateKeyJWT() bool {
+	return c.TokenEndpointAuthMethod.String() == PrivateKeyJWTAuthMethod
+}
+
+// GetScopes returns the scopes registered for the client as a slice
+func (c *OAuthServerClient) GetScopes() []string {
+	return ParseOAuthScopes(c.Scopes.String())
+}
+
+// AllowedScopes returns the scopes the client may request on behalf of users.
+// Clients without registered scopes may only request the OpenID Connect
+// scopes.
+func (c *OAuthServerClient) AllowedScopes() []string {
+	if scopes := c.GetScopes(); len(scopes) > 0 {
+		return scopes
+	}
+	return DefaultOAuthScopes
+}
+
+// AllowsScopes returns whether all of the requested scopes are allowed for
+// the client.
+func (c *OAuthServerClient) AllowsScopes(requested []string) bool {
+	allowed := c.AllowedScopes()
+	for _, scope := range requested {
+		if !slices.Contains(allowed, scope) {
+			return false
+		}
+	}
+	return true
+}
+
+// GetTokenExchangeAudiences returns the audiences the client may exchange
+// user access tokens for
+func (c *OAuthServerClient) GetTokenExchangeAudiences() []string {
+	return strings.Fields(c.TokenExchangeAudiences.String())
+}
+
 // validateRedirectURI validates a single redirect URI according to OAuth 2.1 spec
 func validateRedirectURI(uri string) error {
 	if uri == "" {
@@ -156,6 +250,20 @@ func FindOAuthServerClientByClientID(tx *storage.Connection, clientID string) (*
 	return client, nil
 }
 
+// FindOAuthServerClientsWithActiveConsent finds the OAuth clients the user
+// has an active consent for, in a single query.
+func FindOAuthServerClientsWithActiveConsent(tx *storage.Connection, userID uuid.UUID) ([]*OAuthServerClient, error) {
+	clients := []*OAuthServerClient{}
+	consentsTable := (&pop.Model{Value: OAuthServerConsent{}}).TableName()
+	if err := tx.Q().Where("deleted_at is null and id in (select client_id from "+consentsTable+" where user_id = ? and revoked_at is null)", userID).All(&clients); err != nil {
+		if errors.Cause(err) == sql.ErrNoRows {
+			return clients, nil
+		}
+		return nil, errors.Wrap(err, "error finding OAuth clients")
+	}
+	return clients, nil
+}
+
 // CreateOAuthServerClient creates a new OAuth client in the database
 func CreateOAuthServerClient(tx *storage.Connection, client *OAuthServerClient) error {
 	if err := client.Validate(); err != nil {
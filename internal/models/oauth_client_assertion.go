@@ -0,0 +1,38 @@
+package models
+
+import (
+	"fmt"
+	"time"
+
+	"github.com/gofrs/uuid"
+	"github.com/pkg/errors"
+	"github.com/supabase/auth/internal/storage"
+)
+
+// OAuthServerClientAssertion records the jti of a client assertion (RFC 7523)
+// used to authenticate an OAuth server client, so that it can't be replayed
+// before it expires.
+type OAuthServerClientAssertion struct {
+	ID        uuid.UUID `json:"-" db:"id"`
+	ClientID  uuid.UUID `json:"-" db:"client_id"`
+	JTI       string    `json:"-" db:"jti"`
+	CreatedAt time.Time `json:"-" db:"created_at"`
+	ExpiresAt time.Time `json:"-" db:"expires_at"`
+}
+
+// TableName returns the table name for the OAuthServerClientAssertion model
+func (OAuthServerClientAssertion) TableName() string {
+	return "oauth_client_assertions"
+}
+
+// RecordOAuthServerClientAssertion records that the client used an assertion
+// with the jti. It returns false if the jti has been used before.
+func RecordOAuthServerClientAssertion(tx *storage.Connection, clientID uuid.UUID, jti string, expiresAt time.Time) (bool, error) {
+	query := fmt.Sprintf("insert into %q (id, client_id, jti, created_at, expires_at) values (?, ?, ?, now(), ?) on conflict (client_id, jti) do nothing", OAuthServerClientAssertion{}.TableName())
+
+	count, err := tx.RawQuery(query, uuid.Must(uuid.NewV4()), clientID, jti, expiresAt).ExecWithCount()
+	if err != nil {
+		return false, errors.Wrap(err, "error recording OAuth client assertion")
+	}
+	return count > 0, nil
+}
@@ -0,0 +1,120 @@
+package models
+
+import (
+	"database/sql"
+	"slices"
+	"strings"
+	"time"
+
+	"github.com/gofrs/uuid"
+	"github.com/pkg/errors"
+	"github.com/supabase/auth/internal/storage"
+)
+
+// OAuthServerConsent records that a user has authorized an OAuth server
+// client for a set of scopes. Consents remain in effect until revoked by the
+// user, so that repeat authorizations don't require asking again.
+type OAuthServerConsent struct {
+	ID        uuid.UUID  `json:"-" db:"id"`
+	UserID    uuid.UUID  `json:"-" db:"user_id"`
+	ClientID  uuid.UUID  `json:"-" db:"client_id"`
+	Scopes    string     `json:"-" db:"scopes"`
+	GrantedAt time.Time  `json:"granted_at" db:"granted_at"`
+	RevokedAt *time.Time `json:"revoked_at,omitempty" db:"revoked_at"`
+}
+
+// TableName returns the table name for the OAuthServerConsent model
+func (OAuthServerConsent) TableName() string {
+	return "oauth_consents"
+}
+
+// ParseOAuthScopes splits a space-delimited scope string as used in OAuth
+// requests into its individual scopes.
+func ParseOAuthScopes(scope string) []string {
+	return strings.Fields(scope)
+}
+
+// GetScopes returns the granted scopes as a slice
+func (c *OAuthServerConsent) GetScopes() []string {
+	return ParseOAuthScopes(c.Scopes)
+}
+
+// HasScopes returns whether the consent covers all of the requested scopes
+func (c *OAuthServerConsent) HasScopes(requested []string) bool {
+	granted := c.GetScopes()
+	for _, scope := range requested {
+		if !slices.Contains(granted, scope) {
+			return false
+		}
+	}
+	return true
+}
+
+// Revoke marks the consent as revoked
+func (c *OAuthServerConsent) Revoke(tx *storage.Connection) error {
+	now := time.Now()
+	c.RevokedAt = &now
+	return tx.UpdateOnly(c, "revoked_at")
+}
+
+// GrantOAuthServerConsent records the user's consent for the client. If the
+// user already has an active consent for the client, the newly granted scopes
+// are added to it.
+func GrantOAuthServerConsent(tx *storage.Connection, userID, clientID uuid.UUID, scopes []string) (*OAuthServerConsent, error) {
+	consent, err := FindActiveOAuthServerConsent(tx, userID, clientID)
+	if err != nil && !IsNotFoundError(err) {
+		return nil, err
+	}
+
+	if consent == nil {
+		consent = &OAuthServerConsent{
+			ID:        uuid.Must(uuid.NewV4()),
+			UserID:    userID,
+			ClientID:  clientID,
+			Scopes:    strings.Join(scopes, " "),
+			GrantedAt: time.Now(),
+		}
+		if err := tx.Create(consent); err != nil {
+			return nil, errors.Wrap(err, "error creating OAuth consent")
+		}
+		return consent, nil
+	}
+
+	merged := consent.GetScopes()
+	for _, scope := range scopes {
+		if !slices.Contains(merged, scope) {
+			merged = append(merged, scope)
+		}
+	}
+	consent.Scopes = strings.Join(merged, " ")
+	consent.GrantedAt = time.Now()
+
+	if err := tx.UpdateOnly(consent, "scopes", "granted_at"); err != nil {
+		return nil, errors.Wrap(err, "error updating OAuth consent")
+	}
+	return consent, nil
+}
+
+// FindActiveOAuthServerConsent finds the user's active consent for a client
+func FindActiveOAuthServerConsent(tx *storage.Connection, userID, clientID uuid.UUID) (*OAuthServerConsent, error) {
+	consent := &OAuthServerConsent{}
+	if err := tx.Q().Where("user_id = ? and client_id = ? and revoked_at is null", userID, clientID).First(consent); err != nil {
+		if errors.Cause(err) == sql.ErrNoRows {
+			return nil, OAuthServerConsentNotFoundError{}
+		}
+		return nil, errors.Wrap(err, "error finding OAuth consent")
+	}
+	return consent, nil
+}
+
+// FindActiveOAuthServerConsentsByUser finds all of the user's active consents
+func FindActiveOAuthServerConsentsByUser(tx *storage.Connection, userID uuid.UUID) ([]*OAuthServerConsent, error) {
+	consents := []*OAuthServerConsent{}
+	if err := tx.Q().Where("user_id = ? and revoked_at is null", userID).Order("granted_at desc").All(&consents); err != nil {
+		if errors.Cause(err) == sql.ErrNoRows {
+			return consents, nil
+		}
+		return nil, errors.Wrap(err, "error finding OAuth consents")
+	}
+	return consents, nil
+}
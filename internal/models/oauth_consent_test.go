@@ -0,0 +1,44 @@
+package models
+
+import (
+	"github.com/gofrs/uuid"
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+)
+
+func (ts *OAuthServerClientTestSuite) TestGrantOAuthServerConsent() {
+	client := &OAuthServerClient{
+		ClientID:         "test_client_consent_" + uuid.Must(uuid.NewV4()).String()[:8],
+		GrantTypes:       "authorization_code,refresh_token",
+		RegistrationType: "dynamic",
+		RedirectURIs:     "https://example.com/callback",
+	}
+	require.NoError(ts.T(), CreateOAuthServerClient(ts.db, client))
+
+	user, err := NewUser("", "consent@example.com", "password", "authenticated", nil)
+	require.NoError(ts.T(), err)
+	require.NoError(ts.T(), ts.db.Create(user))
+
+	_, err = FindActiveOAuthServerConsent(ts.db, user.ID, client.ID)
+	require.True(ts.T(), IsNotFoundError(err))
+
+	consent, err := GrantOAuthServerConsent(ts.db, user.ID, client.ID, []string{"openid", "email"})
+	require.NoError(ts.T(), err)
+	assert.True(ts.T(), consent.HasScopes([]string{"email"}))
+	assert.False(ts.T(), consent.HasScopes([]string{"phone"}))
+
+	// granting again extends the existing consent
+	consent, err = GrantOAuthServerConsent(ts.db, user.ID, client.ID, []string{"phone"})
+	require.NoError(ts.T(), err)
+	assert.True(ts.T(), consent.HasScopes([]string{"openid", "email", "phone"}))
+
+	consents, err := FindActiveOAuthServerConsentsByUser(ts.db, user.ID)
+	require.NoError(ts.T(), err)
+	require.Len(ts.T(), consents, 1)
+
+	require.NoError(ts.T(), consent.Revoke(ts.db))
+
+	consents, err = FindActiveOAuthServerConsentsByUser(ts.db, user.ID)
+	require.NoError(ts.T(), err)
+	require.Len(ts.T(), consents, 0)
+}
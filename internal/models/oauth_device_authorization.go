@@ -0,0 +1,205 @@
+package models
+
+import (
+	"crypto/rand"
+	"database/sql"
+	"fmt"
+	"math/big"
+	"strings"
+	"time"
+
+	"github.com/gofrs/uuid"
+	"github.com/pkg/errors"
+	"github.com/supabase/auth/internal/crypto"
+	"github.com/supabase/auth/internal/storage"
+)
+
+// userCodeCharset excludes vowels and easily confused characters, as
+// recommended by RFC 8628 section 6.1.
+const userCodeCharset = "BCDFGHJKLMNPQRSTVWXZ"
+
+const userCodeLength = 8
+
+// OAuthServerDeviceAuthorization represents a device authorization request
+// (RFC 8628) made by an OAuth server client on a device that can't open a
+// browser. The user approves it by entering the user code on another device,
+// while the client polls the token endpoint with the device code.
+type OAuthServerDeviceAuthorization struct {
+	ID         uuid.UUID  `json:"-" db:"id"`
+	DeviceCode string     `json:"-" db:"device_code"`
+	UserCode   string     `json:"user_code" db:"user_code"`
+	ClientID   uuid.UUID  `json:"-" db:"client_id"`
+	UserID     *uuid.UUID `json:"-" db:"user_id"`
+	Scope      string     `json:"scope" db:"scope"`
+
+	Status          OAuthServerAuthorizationStatus `json:"status" db:"status"`
+	PollingInterval int                            `json:"-" db:"polling_interval"`
+	LastPolledAt    *time.Time                     `json:"-" db:"last_polled_at"`
+
+	// AAL, AMR and AuthTime record how the user authenticated in the
+	// session consent was given from
+	AAL      storage.NullString `json:"-" db:"aal"`
+	AMR      storage.NullString `json:"-" db:"amr"`
+	AuthTime *time.Time         `json:"-" db:"auth_time"`
+
+	CreatedAt  time.Time  `json:"created_at" db:"created_at"`
+	ExpiresAt  time.Time  `json:"expires_at" db:"expires_at"`
+	ApprovedAt *time.Time `json:"approved_at,omitempty" db:"approved_at"`
+}
+
+// TableName returns the table name for the OAuthServerDeviceAuthorization model
+func (OAuthServerDeviceAuthorization) TableName() string {
+	return "oauth_device_authorizations"
+}
+
+// generateUserCode generates a user code in the XXXX-XXXX format
+func generateUserCode() string {
+	var b strings.Builder
+	max := big.NewInt(int64(len(userCodeCharset)))
+
+	for i := 0; i < userCodeLength; i++ {
+		if i == userCodeLength/2 {
+			b.WriteByte('-')
+		}
+		n, err := rand.Int(rand.Reader, max)
+		if err != nil {
+			panic(err)
+		}
+		b.WriteByte(userCodeCharset[n.Int64()])
+	}
+
+	return b.String()
+}
+
+// NormalizeUserCode converts a user code as typed in by the user into the
+// format it is stored in, ignoring case, spaces and dashes.
+func NormalizeUserCode(userCode string) string {
+	userCode = strings.ToUpper(userCode)
+	userCode = strings.NewReplacer("-", "", " ", "").Replace(userCode)
+
+	if len(userCode) != userCodeLength {
+		return userCode
+	}
+	return userCode[:userCodeLength/2] + "-" + userCode[userCodeLength/2:]
+}
+
+// NewOAuthServerDeviceAuthorization creates a new pending device
+// authorization request for the provided client.
+func NewOAuthServerDeviceAuthorization(client *OAuthServerClient, scope string, expiresIn, pollingInterval time.Duration) *OAuthServerDeviceAuthorization {
+	now := time.Now()
+
+	return &OAuthServerDeviceAuthorization{
+		ID:              uuid.Must(uuid.NewV4()),
+		DeviceCode:      crypto.SecureAlphanumeric(48),
+		UserCode:        generateUserCode(),
+		ClientID:        client.ID,
+		Scope:           scope,
+		Status:          OAuthServerAuthorizationPending,
+		PollingInterval: int(pollingInterval.Seconds()),
+		CreatedAt:       now,
+		ExpiresAt:       now.Add(expiresIn),
+	}
+}
+
+// IsExpired returns whether the device code can no longer be used.
+func (a *OAuthServerDeviceAuthorization) IsExpired() bool {
+	return a.Status == OAuthServerAuthorizationExpired || time.Now().After(a.ExpiresAt)
+}
+
+// IsPending returns whether the request is still awaiting the user's decision.
+func (a *OAuthServerDeviceAuthorization) IsPending() bool {
+	return a.Status == OAuthServerAuthorizationPending
+}
+
+// HasScope returns whether the scope was requested by the client
+func (a *OAuthServerDeviceAuthorization) HasScope(scope string) bool {
+	for _, s := range ParseOAuthScopes(a.Scope) {
+		if s == scope {
+			return true
+		}
+	}
+	return false
+}
+
+// RecordPoll records that the client polled the token endpoint. It returns
+// false if the client polled faster than the polling interval allows, in
+// which case the interval is increased by 5 seconds as required by RFC 8628.
+func (a *OAuthServerDeviceAuthorization) RecordPoll(tx *storage.Connection) (bool, error) {
+	now := time.Now()
+
+	tooFast := a.LastPolledAt != nil && now.Before(a.LastPolledAt.Add(time.Duration(a.PollingInterval)*time.Second))
+	if tooFast {
+		a.PollingInterval += 5
+	}
+	a.LastPolledAt = &now
+
+	if err := tx.UpdateOnly(a, "last_polled_at", "polling_interval"); err != nil {
+		return false, err
+	}
+	return !tooFast, nil
+}
+
+// Authentication returns how the user authenticated when approving the
+// request, which is nil for requests approved before it was recorded.
+func (a *OAuthServerDeviceAuthorization) Authentication() *OAuthServerAuthentication {
+	return newOAuthServerAuthentication(a.AAL, a.AMR, a.AuthTime)
+}
+
+// Approve marks the request as approved by the user, recording the
+// authentication of the session consent was given from, if known.
+func (a *OAuthServerDeviceAuthorization) Approve(tx *storage.Connection, userID uuid.UUID, authentication *OAuthServerAuthentication) error {
+	now := time.Now()
+
+	a.Status = OAuthServerAuthorizationApproved
+	a.UserID = &userID
+	a.ApprovedAt = &now
+
+	if authentication != nil {
+		a.AAL = storage.NullString(authentication.AAL)
+		a.AMR = storage.NullString(strings.Join(authentication.AMR, " "))
+		a.AuthTime = &authentication.AuthTime
+	}
+
+	return tx.UpdateOnly(a, "status", "user_id", "approved_at", "aal", "amr", "auth_time")
+}
+
+// Deny marks the request as denied by the user.
+func (a *OAuthServerDeviceAuthorization) Deny(tx *storage.Connection, userID uuid.UUID) error {
+	a.Status = OAuthServerAuthorizationDenied
+	a.UserID = &userID
+
+	return tx.UpdateOnly(a, "status", "user_id")
+}
+
+// MarkExpired invalidates the device code, so that it can only ever be
+// exchanged for tokens once.
+func (a *OAuthServerDeviceAuthorization) MarkExpired(tx *storage.Connection) error {
+	a.Status = OAuthServerAuthorizationExpired
+	return tx.UpdateOnly(a, "status")
+}
+
+// FindOAuthServerDeviceAuthorizationByDeviceCode finds a device authorization
+// request by its device code, locking it for the rest of the transaction.
+func FindOAuthServerDeviceAuthorizationByDeviceCode(tx *storage.Connection, deviceCode string) (*OAuthServerDeviceAuthorization, error) {
+	authorization := &OAuthServerDeviceAuthorization{}
+	if err := tx.RawQuery(fmt.Sprintf("SELECT * FROM %q WHERE device_code = ? LIMIT 1 FOR UPDATE", authorization.TableName()), deviceCode).First(authorization); err != nil {
+		if errors.Cause(err) == sql.ErrNoRows {
+			return nil, OAuthServerDeviceAuthorizationNotFoundError{}
+		}
+		return nil, errors.Wrap(err, "error finding OAuth device authorization")
+	}
+	return authorization, nil
+}
+
+// FindOAuthServerDeviceAuthorizationByUserCode finds a device authorization
+// request by the user code entered by the user.
+func FindOAuthServerDeviceAuthorizationByUserCode(tx *storage.Connection, userCode string) (*OAuthServerDeviceAuthorization, error) {
+	authorization := &OAuthServerDeviceAuthorization{}
+	if err := tx.Q().Where("user_code = ?", NormalizeUserCode(userCode)).First(authorization); err != nil {
+		if errors.Cause(err) == sql.ErrNoRows {
+			return nil, OAuthServerDeviceAuthorizationNotFoundError{}
+		}
+		return nil, errors.Wrap(err, "error finding OAuth device authorization")
+	}
+	return authorization, nil
+}
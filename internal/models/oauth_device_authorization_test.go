@@ -0,0 +1,51 @@
+package models
+
+import (
+	"time"
+
+	"github.com/gofrs/uuid"
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+)
+
+func (ts *OAuthServerClientTestSuite) TestOAuthServerDeviceAuthorization() {
+	client := &OAuthServerClient{
+		ClientID:         "test_client_device_" + uuid.Must(uuid.NewV4()).String()[:8],
+		GrantTypes:       "urn:ietf:params:oauth:grant-type:device_code",
+		RegistrationType: "manual",
+	}
+	require.NoError(ts.T(), CreateOAuthServerClient(ts.db, client))
+
+	authorization := NewOAuthServerDeviceAuthorization(client, "openid", time.Minute, 5*time.Second)
+	require.NoError(ts.T(), ts.db.Create(authorization))
+	assert.Regexp(ts.T(), "^[A-Z]{4}-[A-Z]{4}$", authorization.UserCode)
+
+	// user codes are matched ignoring case and formatting
+	found, err := FindOAuthServerDeviceAuthorizationByUserCode(ts.db, " "+authorization.UserCode[:4]+authorization.UserCode[5:])
+	require.NoError(ts.T(), err)
+	assert.Equal(ts.T(), authorization.ID, found.ID)
+
+	found, err = FindOAuthServerDeviceAuthorizationByDeviceCode(ts.db, authorization.DeviceCode)
+	require.NoError(ts.T(), err)
+	assert.True(ts.T(), found.IsPending())
+
+	ok, err := found.RecordPoll(ts.db)
+	require.NoError(ts.T(), err)
+	assert.True(ts.T(), ok)
+
+	// polling again right away must slow down the client
+	ok, err = found.RecordPoll(ts.db)
+	require.NoError(ts.T(), err)
+	assert.False(ts.T(), ok)
+	assert.Equal(ts.T(), 10, found.PollingInterval)
+
+	_, err = FindOAuthServerDeviceAuthorizationByDeviceCode(ts.db, "invalid")
+	require.True(ts.T(), IsNotFoundError(err))
+}
+
+func (ts *OAuthServerClientTestSuite) TestNormalizeUserCode() {
+	assert.Equal(ts.T(), "BCDF-GHJK", NormalizeUserCode("bcdfghjk"))
+	assert.Equal(ts.T(), "BCDF-GHJK", NormalizeUserCode("bcdf ghjk"))
+	assert.Equal(ts.T(), "BCDF-GHJK", NormalizeUserCode("BCDF-GHJK"))
+	assert.Equal(ts.T(), "BCD", NormalizeUserCode("bcd"))
+}
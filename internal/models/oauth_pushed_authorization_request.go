@@ -0,0 +1,87 @@
+package models
+
+import (
+	"database/sql"
+	"fmt"
+	"net/url"
+	"time"
+
+	"github.com/gofrs/uuid"
+	"github.com/pkg/errors"
+	"github.com/supabase/auth/internal/crypto"
+	"github.com/supabase/auth/internal/storage"
+)
+
+// PushedAuthorizationRequestURIPrefix is the prefix of the request_uri values
+// issued for pushed authorization requests, as suggested by RFC 9126.
+const PushedAuthorizationRequestURIPrefix = "urn:ietf:params:oauth:request_uri:"
+
+// OAuthServerPushedAuthorizationRequest holds the authorization parameters an
+// OAuth server client pushed to the server (RFC 9126), so that they can be
+// referenced from the authorization request with a request_uri instead of
+// being exposed in the browser's URL.
+type OAuthServerPushedAuthorizationRequest struct {
+	ID         uuid.UUID `json:"-" db:"id"`
+	RequestURI string    `json:"request_uri" db:"request_uri"`
+	ClientID   uuid.UUID `json:"-" db:"client_id"`
+
+	// Parameters are the URL encoded authorization parameters
+	Parameters string `json:"-" db:"parameters"`
+
+	CreatedAt time.Time `json:"created_at" db:"created_at"`
+	ExpiresAt time.Time `json:"expires_at" db:"expires_at"`
+}
+
+// TableName returns the table name for the OAuthServerPushedAuthorizationRequest model
+func (OAuthServerPushedAuthorizationRequest) TableName() string {
+	return "oauth_pushed_authorization_requests"
+}
+
+// NewOAuthServerPushedAuthorizationRequest creates a new pushed authorization
+// request for the client.
+func NewOAuthServerPushedAuthorizationRequest(client *OAuthServerClient, parameters url.Values, expiresIn time.Duration) *OAuthServerPushedAuthorizationRequest {
+	now := time.Now()
+
+	return &OAuthServerPushedAuthorizationRequest{
+		ID:         uuid.Must(uuid.NewV4()),
+		RequestURI: PushedAuthorizationRequestURIPrefix + crypto.SecureAlphanumeric(32),
+		ClientID:   client.ID,
+		Parameters: parameters.Encode(),
+		CreatedAt:  now,
+		ExpiresAt:  now.Add(expiresIn),
+	}
+}
+
+// IsExpired returns whether the request_uri can no longer be used.
+func (p *OAuthServerPushedAuthorizationRequest) IsExpired() bool {
+	return time.Now().After(p.ExpiresAt)
+}
+
+// GetParameters returns the pushed authorization parameters
+func (p *OAuthServerPushedAuthorizationRequest) GetParameters() (url.Values, error) {
+	return url.ParseQuery(p.Parameters)
+}
+
+// FindOAuthServerPushedAuthorizationRequestForUpdate finds the pushed
+// authorization request for the request_uri and locks it until the
+// transaction ends.
+func FindOAuthServerPushedAuthorizationRequestForUpdate(tx *storage.Connection, requestURI string) (*OAuthServerPushedAuthorizationRequest, error) {
+	request := &OAuthServerPushedAuthorizationRequest{}
+	if err := tx.RawQuery(fmt.Sprintf("SELECT * FROM %q WHERE request_uri = ? LIMIT 1 FOR UPDATE", request.TableName()), requestURI).First(request); err != nil {
+		if errors.Cause(err) == sql.ErrNoRows {
+			return nil, OAuthServerPushedAuthorizationRequestNotFoundError{}
+		}
+		return nil, errors.Wrap(err, "error finding OAuth pushed authorization request")
+	}
+
+	return request, nil
+}
+
+// Consume deletes the pushed authorization request, so that each request_uri
+// can only be used once.
+func (p *OAuthServerPushedAuthorizationRequest) Consume(tx *storage.Connection) error {
+	if err := tx.Destroy(p); err != nil {
+		return errors.Wrap(err, "error deleting OAuth pushed authorization request")
+	}
+	return nil
+}
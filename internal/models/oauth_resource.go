@@ -0,0 +1,115 @@
+package models
+
+import (
+	"database/sql"
+	"slices"
+	"strings"
+	"time"
+
+	"github.com/gofrs/uuid"
+	"github.com/pkg/errors"
+	"github.com/supabase/auth/internal/storage"
+)
+
+// OAuthServerResource is a protected resource (RFC 8707) that access tokens
+// can be requested for with the resource parameter. Tokens issued for a
+// resource use it as their only audience, so they can't be replayed against
+// other resources.
+type OAuthServerResource struct {
+	ID uuid.UUID `json:"id" db:"id"`
+
+	// Resource is the absolute URI identifying the resource
+	Resource string             `json:"resource" db:"resource"`
+	Name     storage.NullString `json:"name" db:"name"`
+
+	// AccessTokenExpiry overrides the lifetime in seconds of access tokens
+	// issued for the resource
+	AccessTokenExpiry *int `json:"access_token_expiry" db:"access_token_expiry"`
+
+	// AllowedClientIDs are the space separated client_ids of the OAuth
+	// server clients that can request tokens for the resource. When empty,
+	// all clients and first-party sessions can.
+	AllowedClientIDs storage.NullString `json:"-" db:"allowed_client_ids"`
+
+	CreatedAt time.Time `json:"created_at" db:"created_at"`
+	UpdatedAt time.Time `json:"updated_at" db:"updated_at"`
+}
+
+// TableName returns the table name for the OAuthServerResource model
+func (OAuthServerResource) TableName() string {
+	return "oauth_resources"
+}
+
+// NewOAuthServerResource creates a new resource
+func NewOAuthServerResource(resource string) *OAuthServerResource {
+	return &OAuthServerResource{
+		ID:       uuid.Must(uuid.NewV4()),
+		Resource: resource,
+	}
+}
+
+// GetAllowedClientIDs returns the client_ids allowed to request tokens for
+// the resource
+func (r *OAuthServerResource) GetAllowedClientIDs() []string {
+	return strings.Fields(r.AllowedClientIDs.String())
+}
+
+// SetAllowedClientIDs sets the client_ids allowed to request tokens for the
+// resource
+func (r *OAuthServerResource) SetAllowedClientIDs(clientIDs []string) {
+	r.AllowedClientIDs = storage.NullString(strings.Join(clientIDs, " "))
+}
+
+// AllowsClient returns whether tokens for the resource can be issued to the
+// client. A nil client stands for first-party sessions, which are only
+// allowed when the resource isn't restricted to specific clients.
+func (r *OAuthServerResource) AllowsClient(client *OAuthServerClient) bool {
+	allowed := r.GetAllowedClientIDs()
+	if len(allowed) == 0 {
+		return true
+	}
+
+	return client != nil && slices.Contains(allowed, client.ClientID)
+}
+
+// GetAccessTokenExpiry returns the lifetime in seconds of access tokens
+// issued for the resource
+func (r *OAuthServerResource) GetAccessTokenExpiry(defaultExpiry int) int {
+	if r.AccessTokenExpiry != nil {
+		return *r.AccessTokenExpiry
+	}
+	return defaultExpiry
+}
+
+// FindOAuthServerResourceByID finds a resource by ID
+func FindOAuthServerResourceByID(tx *storage.Connection, id uuid.UUID) (*OAuthServerResource, error) {
+	resource := &OAuthServerResource{}
+	if err := tx.Q().Where("id = ?", id).First(resource); err != nil {
+		if errors.Cause(err) == sql.ErrNoRows {
+			return nil, OAuthServerResourceNotFoundError{}
+		}
+		return nil, errors.Wrap(err, "error finding OAuth resource")
+	}
+	return resource, nil
+}
+
+// FindOAuthServerResourceByResource finds a resource by its URI
+func FindOAuthServerResourceByResource(tx *storage.Connection, uri string) (*OAuthServerResource, error) {
+	resource := &OAuthServerResource{}
+	if err := tx.Q().Where("resource = ?", uri).First(resource); err != nil {
+		if errors.Cause(err) == sql.ErrNoRows {
+			return nil, OAuthServerResourceNotFoundError{}
+		}
+		return nil, errors.Wrap(err, "error finding OAuth resource")
+	}
+	return resource, nil
+}
+
+// FindAllOAuthServerResources returns all registered resources
+func FindAllOAuthServerResources(tx *storage.Connection) ([]OAuthServerResource, error) {
+	resources := []OAuthServerResource{}
+	if err := tx.Q().Order("created_at asc").All(&resources); err != nil {
+		return nil, errors.Wrap(err, "error listing OAuth resources")
+	}
+	return resources, nil
+}
@@ -0,0 +1,227 @@
+package models
+
+import (
+	"database/sql"
+	"encoding/base64"
+	"fmt"
+	"time"
+
+	"github.com/go-webauthn/webauthn/webauthn"
+	"github.com/gofrs/uuid"
+	"github.com/pkg/errors"
+	"github.com/supabase/auth/internal/storage"
+)
+
+// Passkey is a discoverable WebAuthn credential a user can sign in with
+// without a password.
+type Passkey struct {
+	ID           uuid.UUID           `json:"id" db:"id"`
+	UserID       uuid.UUID           `json:"-" db:"user_id"`
+	CredentialID string              `json:"-" db:"credential_id"`
+	Credential   *WebAuthnCredential `json:"-" db:"credential"`
+	AAGUID       *uuid.UUID          `json:"aaguid,omitempty" db:"aaguid"`
+	FriendlyName string              `json:"friendly_name,omitempty" db:"friendly_name"`
+	CreatedAt    time.Time           `json:"created_at" db:"created_at"`
+	UpdatedAt    time.Time           `json:"updated_at" db:"updated_at"`
+	LastUsedAt   *time.Time          `json:"last_used_at,omitempty" db:"last_used_at"`
+}
+
+// TableName returns the table name for the Passkey model
+func (Passkey) TableName() string {
+	return "passkeys"
+}
+
+// encodeCredentialID encodes the raw ID of a WebAuthn credential the way it
+// is stored and looked up
+func encodeCredentialID(rawID []byte) string {
+	return base64.RawURLEncoding.EncodeToString(rawID)
+}
+
+func NewPasskey(userID uuid.UUID, credential *webauthn.Credential, friendlyName string) (*Passkey, error) {
+	passkey := &Passkey{
+		ID:           uuid.Must(uuid.NewV4()),
+		UserID:       userID,
+		CredentialID: encodeCredentialID(credential.ID),
+		Credential:   &WebAuthnCredential{Credential: *credential},
+		FriendlyName: friendlyName,
+	}
+
+	if len(credential.Authenticator.AAGUID) > 0 {
+		aaguid, err := uuid.FromBytes(credential.Authenticator.AAGUID)
+		if err != nil {
+			return nil, fmt.Errorf("WebAuthn authenticator AAGUID is not UUID: %w", err)
+		}
+		passkey.AAGUID = &aaguid
+	}
+
+	return passkey, nil
+}
+
+// FindPasskeysByUserID returns the user's passkeys, oldest first.
+func FindPasskeysByUserID(tx *storage.Connection, userID uuid.UUID) ([]*Passkey, error) {
+	passkeys := []*Passkey{}
+	if err := tx.Q().Where("user_id = ?", userID).Order("created_at asc").All(&passkeys); err != nil {
+		if errors.Cause(err) == sql.ErrNoRows {
+			return passkeys, nil
+		}
+		return nil, errors.Wrap(err, "error finding passkeys")
+	}
+
+	return passkeys, nil
+}
+
+// CountPasskeysByUserIDForUpdate counts the user's passkeys. The user is
+// locked until the transaction ends, so that concurrent registrations can't
+// exceed the limit of passkeys per user.
+func CountPasskeysByUserIDForUpdate(tx *storage.Connection, userID uuid.UUID) (int, error) {
+	if err := tx.RawQuery(fmt.Sprintf("SELECT id FROM %q WHERE id = ? FOR UPDATE", User{}.TableName()), userID).Exec(); err != nil {
+		return 0, errors.Wrap(err, "error locking user")
+	}
+
+	count, err := tx.Q().Where("user_id = ?", userID).Count(&Passkey{})
+	if err != nil {
+		return 0, errors.Wrap(err, "error counting passkeys")
+	}
+
+	return count, nil
+}
+
+// FindPasskeyByUserIDAndID returns the user's passkey with the ID.
+func FindPasskeyByUserIDAndID(tx *storage.Connection, userID, id uuid.UUID) (*Passkey, error) {
+	passkey := &Passkey{}
+	if err := tx.Q().Where("user_id = ? AND id = ?", userID, id).First(passkey); err != nil {
+		if errors.Cause(err) == sql.ErrNoRows {
+			return nil, PasskeyNotFoundError{}
+		}
+		return nil, errors.Wrap(err, "error finding passkey")
+	}
+
+	return passkey, nil
+}
+
+// FindPasskeyByCredentialID returns the passkey of the WebAuthn credential
+// with the raw ID.
+func FindPasskeyByCredentialID(tx *storage.Connection, rawID []byte) (*Passkey, error) {
+	passkey := &Passkey{}
+	if err := tx.Q().Where("credential_id = ?", encodeCredentialID(rawID)).First(passkey); err != nil {
+		if errors.Cause(err) == sql.ErrNoRows {
+			return nil, PasskeyNotFoundError{}
+		}
+		return nil, errors.Wrap(err, "error finding passkey")
+	}
+
+	return passkey, nil
+}
+
+// UpdateFriendlyName renames the passkey.
+func (p *Passkey) UpdateFriendlyName(tx *storage.Connection, friendlyName string) error {
+	p.FriendlyName = friendlyName
+	p.UpdatedAt = time.Now()
+	return tx.UpdateOnly(p, "friendly_name", "updated_at")
+}
+
+// RecordUse stores the credential as returned by a successful sign-in, which
+// carries the authenticator's new signature counter.
+func (p *Passkey) RecordUse(tx *storage.Connection, credential *webauthn.Credential) error {
+	now := time.Now()
+	p.Credential = &WebAuthnCredential{Credential: *credential}
+	p.LastUsedAt = &now
+	p.UpdatedAt = now
+	return tx.UpdateOnly(p, "credential", "last_used_at", "updated_at")
+}
+
+// PasskeyUser is the WebAuthn view of a user that only exposes their
+// passkeys, unlike User which exposes their WebAuthn MFA factors.
+type PasskeyUser struct {
+	*User
+
+	Passkeys []*Passkey
+
+	// Name overrides the name of users without an email, for example when
+	// signing up.
+	Name string
+}
+
+func (u *PasskeyUser) WebAuthnName() string {
+	if u.Name != "" {
+		return u.Name
+	}
+	if email := u.GetEmail(); email != "" {
+		return email
+	}
+	if phone := u.GetPhone(); phone != "" {
+		return phone
+	}
+	return u.ID.String()
+}
+
+func (u *PasskeyUser) WebAuthnDisplayName() string {
+	return u.WebAuthnName()
+}
+
+func (u *PasskeyUser) WebAuthnCredentials() []webauthn.Credential {
+	credentials := make([]webauthn.Credential, 0, len(u.Passkeys))
+	for _, passkey := range u.Passkeys {
+		credentials = append(credentials, passkey.Credential.Credential)
+	}
+	return credentials
+}
+
+// Types of passkey challenges
+const (
+	PasskeyChallengeSignIn       = "signin"
+	PasskeyChallengeSignUp       = "signup"
+	PasskeyChallengeRegistration = "registration"
+)
+
+// PasskeyChallenge holds the state of a WebAuthn ceremony between issuing
+// the options and receiving the authenticator's response.
+type PasskeyChallenge struct {
+	ID            uuid.UUID            `json:"-" db:"id"`
+	UserID        *uuid.UUID           `json:"-" db:"user_id"`
+	ChallengeType string               `json:"-" db:"challenge_type"`
+	SessionData   *WebAuthnSessionData `json:"-" db:"session_data"`
+	CreatedAt     time.Time            `json:"-" db:"created_at"`
+	ExpiresAt     time.Time            `json:"-" db:"expires_at"`
+}
+
+// TableName returns the table name for the PasskeyChallenge model
+func (PasskeyChallenge) TableName() string {
+	return "passkey_challenges"
+}
+
+func NewPasskeyChallenge(userID *uuid.UUID, challengeType string, sessionData *webauthn.SessionData, expiresIn time.Duration) *PasskeyChallenge {
+	now := time.Now()
+
+	return &PasskeyChallenge{
+		ID:            uuid.Must(uuid.NewV4()),
+		UserID:        userID,
+		ChallengeType: challengeType,
+		SessionData:   &WebAuthnSessionData{SessionData: sessionData},
+		CreatedAt:     now,
+		ExpiresAt:     now.Add(expiresIn),
+	}
+}
+
+// IsExpired returns whether the challenge can no longer be answered.
+func (c *PasskeyChallenge) IsExpired() bool {
+	return time.Now().After(c.ExpiresAt)
+}
+
+// ConsumePasskeyChallenge finds the challenge and deletes it, so that each
+// challenge can only be answered once.
+func ConsumePasskeyChallenge(tx *storage.Connection, id uuid.UUID) (*PasskeyChallenge, error) {
+	challenge := &PasskeyChallenge{}
+	if err := tx.RawQuery(fmt.Sprintf("SELECT * FROM %q WHERE id = ? LIMIT 1 FOR UPDATE", challenge.TableName()), id).First(challenge); err != nil {
+		if errors.Cause(err) == sql.ErrNoRows {
+			return nil, PasskeyChallengeNotFoundError{}
+		}
+		return nil, errors.Wrap(err, "error finding passkey challenge")
+	}
+
+	if err := tx.Destroy(challenge); err != nil {
+		return nil, errors.Wrap(err, "error deleting passkey challenge")
+	}
+
+	return challenge, nil
+}
@@ -0,0 +1,118 @@
+package models
+
+import (
+	"database/sql"
+	"fmt"
+	"strings"
+	"time"
+
+	"github.com/gofrs/uuid"
+	"github.com/pkg/errors"
+	"github.com/supabase/auth/internal/crypto"
+	"github.com/supabase/auth/internal/storage"
+)
+
+// recoveryCodeLength is the number of random characters of a recovery code,
+// which are displayed in two groups separated by a dash
+const recoveryCodeLength = 10
+
+// FactorRecoveryCode is a single-use code of a recovery_code factor, allowing
+// users who lost their other factors to reach AAL2.
+type FactorRecoveryCode struct {
+	ID        uuid.UUID  `json:"-" db:"id"`
+	FactorID  uuid.UUID  `json:"-" db:"factor_id"`
+	CodeHash  string     `json:"-" db:"code_hash"`
+	CreatedAt time.Time  `json:"created_at" db:"created_at"`
+	UsedAt    *time.Time `json:"used_at" db:"used_at"`
+}
+
+// TableName returns the table name for the FactorRecoveryCode model
+func (FactorRecoveryCode) TableName() string {
+	return "mfa_recovery_codes"
+}
+
+func NewRecoveryCodeFactor(user *User, friendlyName string) *Factor {
+	// recovery codes are usable as soon as they were shown to the user
+	return NewFactor(user, friendlyName, RecoveryCode, FactorStateVerified)
+}
+
+// normalizeRecoveryCode removes the separators and spaces users may type or
+// copy along with a recovery code
+func normalizeRecoveryCode(code string) string {
+	return strings.ToLower(strings.NewReplacer("-", "", " ", "").Replace(code))
+}
+
+// hashRecoveryCode hashes a recovery code of the factor. The codes are
+// random and can only be tried at a limited rate, so a fast hash is
+// sufficient.
+func hashRecoveryCode(factorID uuid.UUID, code string) string {
+	return crypto.GenerateTokenHash(factorID.String(), normalizeRecoveryCode(code))
+}
+
+// GenerateRecoveryCodes replaces the recovery codes of the factor with count
+// new ones, which are returned. They are only stored hashed and can't be
+// shown again.
+func (f *Factor) GenerateRecoveryCodes(tx *storage.Connection, count int) ([]string, error) {
+	if err := tx.RawQuery(fmt.Sprintf("DELETE FROM %q WHERE factor_id = ?", FactorRecoveryCode{}.TableName()), f.ID).Exec(); err != nil {
+		return nil, errors.Wrap(err, "error deleting recovery codes")
+	}
+
+	codes := make([]string, 0, count)
+	for len(codes) < count {
+		code := crypto.SecureAlphanumeric(recoveryCodeLength)
+		code = code[:recoveryCodeLength/2] + "-" + code[recoveryCodeLength/2:]
+
+		recoveryCode := &FactorRecoveryCode{
+			ID:        uuid.Must(uuid.NewV4()),
+			FactorID:  f.ID,
+			CodeHash:  hashRecoveryCode(f.ID, code),
+			CreatedAt: time.Now(),
+		}
+		if err := tx.Create(recoveryCode); err != nil {
+			return nil, errors.Wrap(err, "error creating recovery code")
+		}
+
+		codes = append(codes, code)
+	}
+
+	return codes, nil
+}
+
+// FindUnusedRecoveryCode returns the factor's recovery code matching code if
+// it wasn't used yet.
+func (f *Factor) FindUnusedRecoveryCode(tx *storage.Connection, code string) (*FactorRecoveryCode, error) {
+	recoveryCode := &FactorRecoveryCode{}
+	if err := tx.Q().Where("factor_id = ? AND code_hash = ? AND used_at IS NULL", f.ID, hashRecoveryCode(f.ID, code)).First(recoveryCode); err != nil {
+		if errors.Cause(err) == sql.ErrNoRows {
+			return nil, RecoveryCodeNotFoundError{}
+		}
+		return nil, errors.Wrap(err, "error finding recovery code")
+	}
+
+	return recoveryCode, nil
+}
+
+// Use marks the recovery code as used. It returns false if the code was used
+// concurrently by another request.
+func (c *FactorRecoveryCode) Use(tx *storage.Connection) (bool, error) {
+	count, err := tx.RawQuery(
+		fmt.Sprintf("UPDATE %q SET used_at = now() WHERE id = ? AND used_at IS NULL", c.TableName()),
+		c.ID,
+	).ExecWithCount()
+	if err != nil {
+		return false, errors.Wrap(err, "error using recovery code")
+	}
+
+	return count == 1, nil
+}
+
+// CountUnusedRecoveryCodes returns how many of the factor's recovery codes
+// can still be used.
+func (f *Factor) CountUnusedRecoveryCodes(tx *storage.Connection) (int, error) {
+	count, err := tx.Q().Where("factor_id = ? AND used_at IS NULL", f.ID).Count(&FactorRecoveryCode{})
+	if err != nil {
+		return 0, errors.Wrap(err, "error counting recovery codes")
+	}
+
+	return count, nil
+}
@@ -3,6 +3,7 @@ package models
 import (
 	"database/sql"
 	"net/http"
+	"strings"
 	"time"
 
 	"github.com/gobuffalo/pop/v6"
@@ -47,11 +48,40 @@ type GrantParams struct {
 
 	UserAgent string
 	IP        string
+
+	// ForwardedFor is the X-Forwarded-For chain of the request.
+	ForwardedFor string
+
+	// Country and ASN are looked up from the IP address when a GeoIP
+	// database is configured.
+	Country         string
+	ASN             int64
+	ASNOrganization string
+
+	// AuthenticationMethod and Provider record how the session was created.
+	AuthenticationMethod string
+	Provider             string
+
+	// OAuthClientID is set when the session is issued to an OAuth server
+	// client rather than to the first-party application.
+	OAuthClientID *uuid.UUID
+
+	// Scopes are the scopes granted to the OAuth server client.
+	Scopes *string
+
+	// DPoPJKT is the thumbprint of the key the session is bound to when the
+	// token request carried a DPoP proof.
+	DPoPJKT *string
+
+	// Resource is the resource indicator of the token request the session
+	// is created by.
+	Resource *string
 }
 
 func (g *GrantParams) FillGrantParams(r *http.Request) {
 	g.UserAgent = r.Header.Get("User-Agent")
 	g.IP = utilities.GetIPAddress(r)
+	g.ForwardedFor = strings.Join(r.Header.Values("X-Forwarded-For"), ", ")
 }
 
 // GrantAuthenticatedUser creates a refresh token for the provided user.
@@ -137,18 +167,36 @@ func createRefreshToken(tx *storage.Connection, user *User, oldToken *RefreshTok
 			session.NotAfter = params.SessionNotAfter
 		}
 
-		if params.UserAgent != "" {
-			session.UserAgent = &params.UserAgent
+		session.SetClientInfo(params)
+
+		if params.AuthenticationMethod != "" {
+			session.AuthenticationMethod = &params.AuthenticationMethod
 		}
 
-		if params.IP != "" {
-			session.IP = &params.IP
+		if params.Provider != "" {
+			session.Provider = &params.Provider
 		}
 
 		if params.SessionTag != nil && *params.SessionTag != "" {
 			session.Tag = params.SessionTag
 		}
 
+		if params.OAuthClientID != nil {
+			session.OAuthClientID = params.OAuthClientID
+		}
+
+		if params.Scopes != nil && *params.Scopes != "" {
+			session.Scopes = params.Scopes
+		}
+
+		if params.DPoPJKT != nil && *params.DPoPJKT != "" {
+			session.DPoPJKT = params.DPoPJKT
+		}
+
+		if params.Resource != nil && *params.Resource != "" {
+			session.Resource = params.Resource
+		}
+
 		if err := tx.Create(session); err != nil {
 			return nil, errors.Wrap(err, "error creating new session")
 		}
@@ -11,6 +11,7 @@ import (
 	"github.com/gofrs/uuid"
 	"github.com/pkg/errors"
 	"github.com/supabase/auth/internal/storage"
+	"github.com/supabase/auth/internal/utilities"
 )
 
 type AuthenticatorAssuranceLevel int
@@ -88,7 +89,36 @@ type Session struct {
 	UserAgent   *string    `json:"user_agent,omitempty" db:"user_agent"`
 	IP          *string    `json:"ip,omitempty" db:"ip"`
 
+	// Device and network metadata recorded from the most recent request
+	// creating or refreshing the session.
+	DeviceType      *string `json:"device_type,omitempty" db:"device_type"`
+	OS              *string `json:"os,omitempty" db:"os"`
+	Browser         *string `json:"browser,omitempty" db:"browser"`
+	ForwardedFor    *string `json:"forwarded_for,omitempty" db:"forwarded_for"`
+	Country         *string `json:"country,omitempty" db:"country"`
+	ASN             *int64  `json:"asn,omitempty" db:"asn"`
+	ASNOrganization *string `json:"asn_organization,omitempty" db:"asn_organization"`
+
+	// AuthenticationMethod is how the user signed in when the session was
+	// created, and Provider the identity provider used, if any.
+	AuthenticationMethod *string `json:"authentication_method,omitempty" db:"authentication_method"`
+	Provider             *string `json:"provider,omitempty" db:"provider"`
+
 	Tag *string `json:"tag" db:"tag"`
+
+	OAuthClientID *uuid.UUID `json:"oauth_client_id,omitempty" db:"oauth_client_id"`
+
+	// Scopes are the space separated scopes granted to the OAuth server
+	// client, emitted as the scope claim of access tokens.
+	Scopes *string `json:"scopes,omitempty" db:"scopes"`
+
+	// DPoPJKT is the JWK thumbprint of the key the session's refresh tokens
+	// are bound to (RFC 9449). Refreshing requires a DPoP proof signed by it.
+	DPoPJKT *string `json:"-" db:"dpop_jkt"`
+
+	// Resource is the RFC 8707 resource the session was granted for. Access
+	// tokens can only be refreshed for this resource.
+	Resource *string `json:"-" db:"resource"`
 }
 
 func (Session) TableName() string {
@@ -121,7 +151,35 @@ func (s *Session) UpdateOnlyRefreshInfo(tx *storage.Connection) error {
 	// so we need to convert the value to UTC before updating it.
 	// In the future, we should add a migration to update the type to contain the timezone.
 	*s.RefreshedAt = s.RefreshedAt.UTC()
-	return tx.UpdateOnly(s, "refreshed_at", "user_agent", "ip")
+	return tx.UpdateOnly(s, "refreshed_at", "user_agent", "ip", "device_type", "os", "browser", "forwarded_for", "country", "asn", "asn_organization")
+}
+
+// SetClientInfo records the device and network metadata of the grant params
+// on the session. Empty values clear the previously recorded ones.
+func (s *Session) SetClientInfo(params *GrantParams) {
+	optional := func(value string) *string {
+		if value == "" {
+			return nil
+		}
+		return &value
+	}
+
+	info := utilities.ParseUserAgent(params.UserAgent)
+
+	s.UserAgent = optional(params.UserAgent)
+	s.DeviceType = optional(info.DeviceType)
+	s.OS = optional(info.OS)
+	s.Browser = optional(info.Browser)
+	s.IP = optional(params.IP)
+	s.ForwardedFor = optional(params.ForwardedFor)
+	s.Country = optional(params.Country)
+	s.ASNOrganization = optional(params.ASNOrganization)
+
+	s.ASN = nil
+	if params.ASN != 0 {
+		asn := params.ASN
+		s.ASN = &asn
+	}
 }
 
 type SessionValidityReason = int
@@ -276,6 +334,22 @@ func FindAllSessionsForUser(tx *storage.Connection, userId uuid.UUID, forUpdate
 	return sessions, nil
 }
 
+// LockUserSessions locks the user's row, waiting for concurrent transactions
+// holding the lock, and returns all of the user's sessions. Transactions
+// creating sessions for the user are serialized while the lock is held.
+func LockUserSessions(tx *storage.Connection, userId uuid.UUID) ([]*Session, error) {
+	user := &User{}
+	if err := tx.RawQuery(fmt.Sprintf("SELECT id FROM %q WHERE id = ? LIMIT 1 FOR UPDATE;", user.TableName()), userId).First(user); err != nil {
+		if errors.Cause(err) == sql.ErrNoRows {
+			return nil, UserNotFoundError{}
+		}
+
+		return nil, err
+	}
+
+	return FindAllSessionsForUser(tx, userId, false)
+}
+
 func updateFactorAssociatedSessions(tx *storage.Connection, userID, factorID uuid.UUID, aal string) error {
 	return tx.RawQuery("UPDATE "+(&pop.Model{Value: Session{}}).TableName()+" set aal = ?, factor_id = ? WHERE user_id = ? AND factor_id = ?", aal, nil, userID, factorID).Exec()
 }
@@ -294,6 +368,11 @@ func LogoutSession(tx *storage.Connection, sessionId uuid.UUID) error {
 	return tx.RawQuery("DELETE FROM "+(&pop.Model{Value: Session{}}).TableName()+" WHERE id = ?", sessionId).Exec()
 }
 
+// LogoutOAuthClientSessions deletes all sessions a user has issued to an OAuth server client
+func LogoutOAuthClientSessions(tx *storage.Connection, userID uuid.UUID, clientID uuid.UUID) error {
+	return tx.RawQuery("DELETE FROM "+(&pop.Model{Value: Session{}}).TableName()+" WHERE user_id = ? AND oauth_client_id = ?", userID, clientID).Exec()
+}
+
 // LogoutAllExceptMe deletes all sessions for a user except the current one
 func LogoutAllExceptMe(tx *storage.Connection, sessionId uuid.UUID, userID uuid.UUID) error {
 	return tx.RawQuery("DELETE FROM "+(&pop.Model{Value: Session{}}).TableName()+" WHERE id != ? AND user_id = ?", sessionId, userID).Exec()
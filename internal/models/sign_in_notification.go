@@ -0,0 +1,75 @@
+package models
+
+import (
+	"database/sql"
+	"fmt"
+	"time"
+
+	"github.com/gofrs/uuid"
+	"github.com/pkg/errors"
+	"github.com/supabase/auth/internal/crypto"
+	"github.com/supabase/auth/internal/storage"
+)
+
+// SignInNotification holds the token of the "this wasn't me" link sent to a
+// user when they signed in from a new device or location. Following the link
+// revokes the session that was created by the sign-in.
+type SignInNotification struct {
+	ID        uuid.UUID  `json:"-" db:"id"`
+	UserID    uuid.UUID  `json:"-" db:"user_id"`
+	SessionID *uuid.UUID `json:"-" db:"session_id"`
+	TokenHash string     `json:"-" db:"token_hash"`
+
+	CreatedAt time.Time `json:"created_at" db:"created_at"`
+	ExpiresAt time.Time `json:"expires_at" db:"expires_at"`
+}
+
+// TableName returns the table name for the SignInNotification model
+func (SignInNotification) TableName() string {
+	return "sign_in_notifications"
+}
+
+// hashSignInNotificationToken hashes the token of a sign-in notification.
+// The tokens have enough entropy that a fast hash is sufficient.
+func hashSignInNotificationToken(token string) string {
+	return crypto.GenerateTokenHash("sign_in_notification", token)
+}
+
+// NewSignInNotification creates a sign-in notification for the session and
+// returns it together with the token to include in the link.
+func NewSignInNotification(session *Session, expiresIn time.Duration) (*SignInNotification, string) {
+	token := crypto.SecureAlphanumeric(32)
+	now := time.Now()
+
+	return &SignInNotification{
+		ID:        uuid.Must(uuid.NewV4()),
+		UserID:    session.UserID,
+		SessionID: &session.ID,
+		TokenHash: hashSignInNotificationToken(token),
+		CreatedAt: now,
+		ExpiresAt: now.Add(expiresIn),
+	}, token
+}
+
+// IsExpired returns whether the link can no longer be used.
+func (n *SignInNotification) IsExpired() bool {
+	return time.Now().After(n.ExpiresAt)
+}
+
+// ConsumeSignInNotification finds the sign-in notification for the token and
+// deletes it, so that each link can only be used once.
+func ConsumeSignInNotification(tx *storage.Connection, token string) (*SignInNotification, error) {
+	notification := &SignInNotification{}
+	if err := tx.RawQuery(fmt.Sprintf("SELECT * FROM %q WHERE token_hash = ? LIMIT 1 FOR UPDATE", notification.TableName()), hashSignInNotificationToken(token)).First(notification); err != nil {
+		if errors.Cause(err) == sql.ErrNoRows {
+			return nil, SignInNotificationNotFoundError{}
+		}
+		return nil, errors.Wrap(err, "error finding sign-in notification")
+	}
+
+	if err := tx.Destroy(notification); err != nil {
+		return nil, errors.Wrap(err, "error deleting sign-in notification")
+	}
+
+	return notification, nil
+}
@@ -0,0 +1,122 @@
+package models
+
+import (
+	"database/sql"
+	"fmt"
+	"time"
+
+	"github.com/gofrs/uuid"
+	"github.com/pkg/errors"
+	"github.com/supabase/auth/internal/crypto"
+	"github.com/supabase/auth/internal/storage"
+)
+
+// TrustedDevice is a device a user chose to remember after verifying a
+// factor. Presenting its token raises a later AAL1 session to AAL2 without a
+// new MFA challenge, for as long as the device hasn't expired, wasn't revoked
+// and the factor is still enrolled.
+type TrustedDevice struct {
+	ID         uuid.UUID  `json:"id" db:"id"`
+	UserID     uuid.UUID  `json:"-" db:"user_id"`
+	FactorID   uuid.UUID  `json:"factor_id" db:"factor_id"`
+	TokenHash  string     `json:"-" db:"token_hash"`
+	UserAgent  *string    `json:"user_agent,omitempty" db:"user_agent"`
+	IP         *string    `json:"ip,omitempty" db:"ip"`
+	CreatedAt  time.Time  `json:"created_at" db:"created_at"`
+	LastUsedAt *time.Time `json:"last_used_at,omitempty" db:"last_used_at"`
+	ExpiresAt  time.Time  `json:"expires_at" db:"expires_at"`
+}
+
+// TableName returns the table name for the TrustedDevice model
+func (TrustedDevice) TableName() string {
+	return "mfa_trusted_devices"
+}
+
+// hashTrustedDeviceToken hashes the token of a trusted device. The tokens
+// have enough entropy that a fast hash is sufficient.
+func hashTrustedDeviceToken(token string) string {
+	return crypto.GenerateTokenHash("trusted_device", token)
+}
+
+// NewTrustedDevice trusts the device the user verified the factor on and
+// returns it together with the token to hand to the device.
+func NewTrustedDevice(userID, factorID uuid.UUID, userAgent, ip string, expiresIn time.Duration) (*TrustedDevice, string) {
+	token := crypto.SecureAlphanumeric(32)
+	now := time.Now()
+
+	device := &TrustedDevice{
+		ID:        uuid.Must(uuid.NewV4()),
+		UserID:    userID,
+		FactorID:  factorID,
+		TokenHash: hashTrustedDeviceToken(token),
+		CreatedAt: now,
+		ExpiresAt: now.Add(expiresIn),
+	}
+	if userAgent != "" {
+		device.UserAgent = &userAgent
+	}
+	if ip != "" {
+		device.IP = &ip
+	}
+
+	return device, token
+}
+
+// IsExpired returns whether the device is no longer trusted.
+func (d *TrustedDevice) IsExpired() bool {
+	return time.Now().After(d.ExpiresAt)
+}
+
+// RecordUse marks the device as used to skip an MFA challenge.
+func (d *TrustedDevice) RecordUse(tx *storage.Connection) error {
+	now := time.Now()
+	d.LastUsedAt = &now
+	return tx.UpdateOnly(d, "last_used_at")
+}
+
+// FindTrustedDeviceByToken finds the user's trusted device with the token.
+// Devices of other users are reported as not found.
+func FindTrustedDeviceByToken(tx *storage.Connection, userID uuid.UUID, token string) (*TrustedDevice, error) {
+	device := &TrustedDevice{}
+	if err := tx.RawQuery(fmt.Sprintf("SELECT * FROM %q WHERE token_hash = ? AND user_id = ? LIMIT 1 FOR UPDATE", device.TableName()), hashTrustedDeviceToken(token), userID).First(device); err != nil {
+		if errors.Cause(err) == sql.ErrNoRows {
+			return nil, TrustedDeviceNotFoundError{}
+		}
+		return nil, errors.Wrap(err, "error finding trusted device")
+	}
+
+	return device, nil
+}
+
+// FindTrustedDevicesByUserID returns the user's unexpired trusted devices,
+// most recently trusted first.
+func FindTrustedDevicesByUserID(tx *storage.Connection, userID uuid.UUID) ([]*TrustedDevice, error) {
+	devices := []*TrustedDevice{}
+	if err := tx.Q().Where("user_id = ? AND expires_at > now()", userID).Order("created_at desc").All(&devices); err != nil {
+		if errors.Cause(err) == sql.ErrNoRows {
+			return devices, nil
+		}
+		return nil, errors.Wrap(err, "error finding trusted devices")
+	}
+
+	return devices, nil
+}
+
+// FindTrustedDeviceByUserIDAndID returns the user's trusted device with the
+// ID.
+func FindTrustedDeviceByUserIDAndID(tx *storage.Connection, userID, id uuid.UUID) (*TrustedDevice, error) {
+	device := &TrustedDevice{}
+	if err := tx.Q().Where("user_id = ? AND id = ?", userID, id).First(device); err != nil {
+		if errors.Cause(err) == sql.ErrNoRows {
+			return nil, TrustedDeviceNotFoundError{}
+		}
+		return nil, errors.Wrap(err, "error finding trusted device")
+	}
+
+	return device, nil
+}
+
+// RevokeTrustedDevices deletes all of the user's trusted devices.
+func RevokeTrustedDevices(tx *storage.Connection, userID uuid.UUID) error {
+	return tx.RawQuery(fmt.Sprintf("DELETE FROM %q WHERE user_id = ?", TrustedDevice{}.TableName()), userID).Exec()
+}
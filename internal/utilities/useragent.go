@@ -0,0 +1,95 @@
+package utilities
+
+import (
+	"strings"
+)
+
+// Device types reported by ParseUserAgent
+const (
+	DeviceTypeDesktop = "desktop"
+	DeviceTypeMobile  = "mobile"
+	DeviceTypeTablet  = "tablet"
+	DeviceTypeBot     = "bot"
+)
+
+// UserAgentInfo is the device, operating system and browser a User-Agent
+// header identifies. Fields are empty when they couldn't be determined.
+type UserAgentInfo struct {
+	DeviceType string
+	OS         string
+	Browser    string
+}
+
+// ParseUserAgent extracts coarse device information from a User-Agent
+// header. It only recognizes the most common platforms and browsers, which
+// is enough to tell a user's sessions apart.
+func ParseUserAgent(userAgent string) UserAgentInfo {
+	var info UserAgentInfo
+
+	ua := strings.ToLower(strings.TrimSpace(userAgent))
+	if ua == "" {
+		return info
+	}
+
+	info.OS = parseUserAgentOS(ua)
+	info.Browser = parseUserAgentBrowser(ua)
+
+	switch {
+	case containsAny(ua, "bot", "crawler", "spider", "curl/", "wget/", "python-requests", "go-http-client"):
+		info.DeviceType = DeviceTypeBot
+	case containsAny(ua, "ipad", "tablet") || (info.OS == "Android" && !strings.Contains(ua, "mobile")):
+		info.DeviceType = DeviceTypeTablet
+	case containsAny(ua, "mobile", "iphone", "ipod") || info.OS == "Android":
+		info.DeviceType = DeviceTypeMobile
+	case info.OS != "":
+		info.DeviceType = DeviceTypeDesktop
+	}
+
+	return info
+}
+
+func parseUserAgentOS(ua string) string {
+	switch {
+	case strings.Contains(ua, "windows"):
+		return "Windows"
+	case containsAny(ua, "iphone", "ipad", "ipod"):
+		return "iOS"
+	case strings.Contains(ua, "mac os x"), strings.Contains(ua, "macintosh"):
+		return "macOS"
+	case strings.Contains(ua, "android"):
+		return "Android"
+	case strings.Contains(ua, "cros"):
+		return "ChromeOS"
+	case strings.Contains(ua, "linux"):
+		return "Linux"
+	}
+	return ""
+}
+
+func parseUserAgentBrowser(ua string) string {
+	// the order matters, as most browsers also claim to be Chrome or Safari
+	switch {
+	case containsAny(ua, "edg/", "edga/", "edgios/"):
+		return "Edge"
+	case containsAny(ua, "opr/", "opera"):
+		return "Opera"
+	case strings.Contains(ua, "samsungbrowser/"):
+		return "Samsung Internet"
+	case containsAny(ua, "firefox/", "fxios/"):
+		return "Firefox"
+	case containsAny(ua, "chrome/", "crios/"):
+		return "Chrome"
+	case strings.Contains(ua, "version/") && strings.Contains(ua, "safari/"):
+		return "Safari"
+	}
+	return ""
+}
+
+func containsAny(s string, substrings ...string) bool {
+	for _, substring := range substrings {
+		if strings.Contains(s, substring) {
+			return true
+		}
+	}
+	return false
+}
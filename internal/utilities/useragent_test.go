@@ -0,0 +1,59 @@
+package utilities
+
+import (
+	tst "testing"
+
+	"github.com/stretchr/testify/require"
+)
+
+func TestParseUserAgent(t *tst.T) {
+	examples := []struct {
+		userAgent string
+		expected  UserAgentInfo
+	}{
+		{
+			userAgent: "",
+			expected:  UserAgentInfo{},
+		},
+		{
+			userAgent: "Mozilla/5.0 (Windows NT 10.0; Win64; x64) AppleWebKit/537.36 (KHTML, like Gecko) Chrome/120.0.0.0 Safari/537.36",
+			expected:  UserAgentInfo{DeviceType: DeviceTypeDesktop, OS: "Windows", Browser: "Chrome"},
+		},
+		{
+			userAgent: "Mozilla/5.0 (Windows NT 10.0; Win64; x64) AppleWebKit/537.36 (KHTML, like Gecko) Chrome/120.0.0.0 Safari/537.36 Edg/120.0.2210.91",
+			expected:  UserAgentInfo{DeviceType: DeviceTypeDesktop, OS: "Windows", Browser: "Edge"},
+		},
+		{
+			userAgent: "Mozilla/5.0 (Macintosh; Intel Mac OS X 10_15_7) AppleWebKit/605.1.15 (KHTML, like Gecko) Version/17.2 Safari/605.1.15",
+			expected:  UserAgentInfo{DeviceType: DeviceTypeDesktop, OS: "macOS", Browser: "Safari"},
+		},
+		{
+			userAgent: "Mozilla/5.0 (X11; Linux x86_64; rv:121.0) Gecko/20100101 Firefox/121.0",
+			expected:  UserAgentInfo{DeviceType: DeviceTypeDesktop, OS: "Linux", Browser: "Firefox"},
+		},
+		{
+			userAgent: "Mozilla/5.0 (iPhone; CPU iPhone OS 17_2 like Mac OS X) AppleWebKit/605.1.15 (KHTML, like Gecko) CriOS/120.0.6099.119 Mobile/15E148 Safari/604.1",
+			expected:  UserAgentInfo{DeviceType: DeviceTypeMobile, OS: "iOS", Browser: "Chrome"},
+		},
+		{
+			userAgent: "Mozilla/5.0 (iPad; CPU OS 17_2 like Mac OS X) AppleWebKit/605.1.15 (KHTML, like Gecko) Version/17.2 Mobile/15E148 Safari/604.1",
+			expected:  UserAgentInfo{DeviceType: DeviceTypeTablet, OS: "iOS", Browser: "Safari"},
+		},
+		{
+			userAgent: "Mozilla/5.0 (Linux; Android 14; SM-S918B) AppleWebKit/537.36 (KHTML, like Gecko) SamsungBrowser/23.0 Chrome/115.0.0.0 Mobile Safari/537.36",
+			expected:  UserAgentInfo{DeviceType: DeviceTypeMobile, OS: "Android", Browser: "Samsung Internet"},
+		},
+		{
+			userAgent: "Mozilla/5.0 (Linux; Android 13; SM-X700) AppleWebKit/537.36 (KHTML, like Gecko) Chrome/120.0.0.0 Safari/537.36",
+			expected:  UserAgentInfo{DeviceType: DeviceTypeTablet, OS: "Android", Browser: "Chrome"},
+		},
+		{
+			userAgent: "curl/8.4.0",
+			expected:  UserAgentInfo{DeviceType: DeviceTypeBot},
+		},
+	}
+
+	for _, example := range examples {
+		require.Equal(t, example.expected, ParseUserAgent(example.userAgent), example.userAgent)
+	}
+}
@@ -0,0 +1,40 @@
+-- Create enum for OAuth authorization status
+do $$ begin
+    create type {{ index .Options "Namespace" }}.oauth_authorization_status as enum('pending', 'approved', 'denied', 'expired');
+exception
+    when duplicate_object then null;
+end $$;
+
+-- Create oauth_authorizations table for the authorization code flow
+create table if not exists {{ index .Options "Namespace" }}.oauth_authorizations (
+    id uuid not null,
+    authorization_id text not null,
+    client_id uuid not null references {{ index .Options "Namespace" }}.oauth_clients(id) on delete cascade,
+    user_id uuid null references {{ index .Options "Namespace" }}.users(id) on delete cascade,
+    redirect_uri text not null,
+    scope text not null,
+    state text null,
+    code_challenge text not null,
+    code_challenge_method code_challenge_method not null,
+    response_type text not null default 'code',
+    status {{ index .Options "Namespace" }}.oauth_authorization_status not null default 'pending',
+    authorization_code text null,
+    created_at timestamptz not null default now(),
+    expires_at timestamptz not null,
+    approved_at timestamptz null,
+    constraint oauth_authorizations_pkey primary key (id),
+    constraint oauth_authorizations_authorization_id_key unique (authorization_id),
+    constraint oauth_authorizations_authorization_code_key unique (authorization_code),
+    constraint oauth_authorizations_redirect_uri_length check (char_length(redirect_uri) <= 2048),
+    constraint oauth_authorizations_state_length check (char_length(state) <= 4096)
+);
+
+create index if not exists oauth_authorizations_expires_at_idx
+    on {{ index .Options "Namespace" }}.oauth_authorizations (expires_at);
+
+-- Track which OAuth client a session was issued to
+alter table {{ index .Options "Namespace" }}.sessions
+    add column if not exists oauth_client_id uuid null references {{ index .Options "Namespace" }}.oauth_clients(id) on delete cascade;
+
+create index if not exists sessions_oauth_client_id_idx
+    on {{ index .Options "Namespace" }}.sessions (oauth_client_id);
@@ -0,0 +1,19 @@
+-- Create oauth_consents table to remember which clients a user has authorized
+create table if not exists {{ index .Options "Namespace" }}.oauth_consents (
+    id uuid not null,
+    user_id uuid not null references {{ index .Options "Namespace" }}.users(id) on delete cascade,
+    client_id uuid not null references {{ index .Options "Namespace" }}.oauth_clients(id) on delete cascade,
+    scopes text not null,
+    granted_at timestamptz not null default now(),
+    revoked_at timestamptz null,
+    constraint oauth_consents_pkey primary key (id),
+    constraint oauth_consents_scopes_length check (char_length(scopes) <= 2048)
+);
+
+-- Only one active consent per user and client
+create unique index if not exists oauth_consents_active_user_client_idx
+    on {{ index .Options "Namespace" }}.oauth_consents (user_id, client_id)
+    where revoked_at is null;
+
+create index if not exists oauth_consents_user_id_idx
+    on {{ index .Options "Namespace" }}.oauth_consents (user_id);
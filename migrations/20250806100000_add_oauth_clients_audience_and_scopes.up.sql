@@ -0,0 +1,5 @@
+-- Audience and scopes of the tokens issued to an OAuth client through the
+-- client_credentials grant
+alter table {{ index .Options "Namespace" }}.oauth_clients
+    add column if not exists audience text null,
+    add column if not exists scopes text null;
@@ -0,0 +1,3 @@
+-- OpenID Connect nonce, included in the id_token issued for the authorization
+alter table {{ index .Options "Namespace" }}.oauth_authorizations
+    add column if not exists nonce text null;
@@ -0,0 +1,5 @@
+-- When a client secret is rotated, the previous secret remains valid until
+-- previous_client_secret_expires_at so that integrations can be updated
+alter table {{ index .Options "Namespace" }}.oauth_clients
+    add column if not exists previous_client_secret_hash text null,
+    add column if not exists previous_client_secret_expires_at timestamptz null;
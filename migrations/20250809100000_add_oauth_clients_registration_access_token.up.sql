@@ -0,0 +1,4 @@
+-- RFC 7592 registration access tokens let dynamically registered clients
+-- manage their own registration
+alter table {{ index .Options "Namespace" }}.oauth_clients
+    add column if not exists registration_access_token_hash text null;
@@ -0,0 +1,21 @@
+-- Create oauth_device_authorizations table for the device authorization grant (RFC 8628)
+create table if not exists {{ index .Options "Namespace" }}.oauth_device_authorizations (
+    id uuid not null,
+    device_code text not null,
+    user_code text not null,
+    client_id uuid not null references {{ index .Options "Namespace" }}.oauth_clients(id) on delete cascade,
+    user_id uuid null references {{ index .Options "Namespace" }}.users(id) on delete cascade,
+    scope text not null,
+    status {{ index .Options "Namespace" }}.oauth_authorization_status not null default 'pending',
+    polling_interval integer not null,
+    last_polled_at timestamptz null,
+    created_at timestamptz not null default now(),
+    expires_at timestamptz not null,
+    approved_at timestamptz null,
+    constraint oauth_device_authorizations_pkey primary key (id),
+    constraint oauth_device_authorizations_device_code_key unique (device_code),
+    constraint oauth_device_authorizations_user_code_key unique (user_code)
+);
+
+create index if not exists oauth_device_authorizations_expires_at_idx
+    on {{ index .Options "Namespace" }}.oauth_device_authorizations (expires_at);
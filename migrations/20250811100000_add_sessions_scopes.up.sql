@@ -0,0 +1,3 @@
+-- scopes granted to the OAuth server client the session was issued to
+alter table {{ index .Options "Namespace" }}.sessions
+    add column if not exists scopes text null;
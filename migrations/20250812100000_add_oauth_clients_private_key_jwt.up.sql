@@ -0,0 +1,20 @@
+-- RFC 7523 private_key_jwt client authentication
+alter table {{ index .Options "Namespace" }}.oauth_clients
+    add column if not exists token_endpoint_auth_method text null,
+    add column if not exists jwks text null,
+    add column if not exists jwks_uri text null;
+
+-- jti of client assertions that were already used, kept until the assertion
+-- expires to prevent replays
+create table if not exists {{ index .Options "Namespace" }}.oauth_client_assertions (
+    id uuid not null,
+    client_id uuid not null references {{ index .Options "Namespace" }}.oauth_clients(id) on delete cascade,
+    jti text not null,
+    created_at timestamptz not null default now(),
+    expires_at timestamptz not null,
+    constraint oauth_client_assertions_pkey primary key (id),
+    constraint oauth_client_assertions_client_id_jti_key unique (client_id, jti)
+);
+
+create index if not exists oauth_client_assertions_expires_at_idx
+    on {{ index .Options "Namespace" }}.oauth_client_assertions (expires_at);
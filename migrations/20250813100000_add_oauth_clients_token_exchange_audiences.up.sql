@@ -0,0 +1,3 @@
+-- audiences an OAuth server client may request through RFC 8693 token exchange
+alter table {{ index .Options "Namespace" }}.oauth_clients
+    add column if not exists token_exchange_audiences text null;
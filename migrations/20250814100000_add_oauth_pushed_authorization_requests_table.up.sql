@@ -0,0 +1,19 @@
+-- Clients can be required to use pushed authorization requests (RFC 9126)
+alter table {{ index .Options "Namespace" }}.oauth_clients
+    add column if not exists require_pushed_authorization_requests boolean not null default false;
+
+-- Create oauth_pushed_authorization_requests table for authorization
+-- parameters pushed by clients ahead of the authorization request
+create table if not exists {{ index .Options "Namespace" }}.oauth_pushed_authorization_requests (
+    id uuid not null,
+    request_uri text not null,
+    client_id uuid not null references {{ index .Options "Namespace" }}.oauth_clients(id) on delete cascade,
+    parameters text not null,
+    created_at timestamptz not null default now(),
+    expires_at timestamptz not null,
+    constraint oauth_pushed_authorization_requests_pkey primary key (id),
+    constraint oauth_pushed_authorization_requests_request_uri_key unique (request_uri)
+);
+
+create index if not exists oauth_pushed_authorization_requests_expires_at_idx
+    on {{ index .Options "Namespace" }}.oauth_pushed_authorization_requests (expires_at);
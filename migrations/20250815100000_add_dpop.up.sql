@@ -0,0 +1,18 @@
+-- RFC 9449 DPoP: sessions bound to the thumbprint of the client's key
+alter table {{ index .Options "Namespace" }}.sessions
+    add column if not exists dpop_jkt text null;
+
+-- jti of DPoP proofs that were already used, kept until the proof is too old
+-- to be accepted to prevent replays
+create table if not exists {{ index .Options "Namespace" }}.dpop_proofs (
+    id uuid not null,
+    jkt text not null,
+    jti text not null,
+    created_at timestamptz not null default now(),
+    expires_at timestamptz not null,
+    constraint dpop_proofs_pkey primary key (id),
+    constraint dpop_proofs_jkt_jti_key unique (jkt, jti)
+);
+
+create index if not exists dpop_proofs_expires_at_idx
+    on {{ index .Options "Namespace" }}.dpop_proofs (expires_at);
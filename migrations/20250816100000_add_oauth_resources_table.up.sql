@@ -0,0 +1,15 @@
+-- Create oauth_resources table, the registry of the resources (RFC 8707)
+-- audience restricted access tokens can be issued for
+create table if not exists {{ index .Options "Namespace" }}.oauth_resources (
+    id uuid not null,
+    resource text not null,
+    name text null,
+    access_token_expiry integer null,
+    allowed_client_ids text null,
+    created_at timestamptz not null default now(),
+    updated_at timestamptz not null default now(),
+    constraint oauth_resources_pkey primary key (id),
+    constraint oauth_resources_resource_key unique (resource),
+    constraint oauth_resources_access_token_expiry_check check (access_token_expiry is null or access_token_expiry > 0),
+    constraint oauth_resources_resource_length check (char_length(resource) <= 2048)
+);
@@ -0,0 +1,12 @@
+-- device and network metadata recorded when sessions are created or
+-- refreshed, and how they were created
+alter table {{ index .Options "Namespace" }}.sessions
+    add column if not exists device_type text null,
+    add column if not exists os text null,
+    add column if not exists browser text null,
+    add column if not exists forwarded_for text null,
+    add column if not exists country text null,
+    add column if not exists asn bigint null,
+    add column if not exists asn_organization text null,
+    add column if not exists authentication_method text null,
+    add column if not exists provider text null;
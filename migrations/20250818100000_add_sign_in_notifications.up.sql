@@ -0,0 +1,27 @@
+-- devices and countries users have signed in from, used to notify users of
+-- sign-ins from a device or location that was never seen before
+create table if not exists {{ index .Options "Namespace" }}.known_devices (
+    id uuid not null,
+    user_id uuid not null references {{ index .Options "Namespace" }}.users(id) on delete cascade,
+    fingerprint text not null,
+    country text not null default '',
+    created_at timestamptz not null default now(),
+    last_seen_at timestamptz not null default now(),
+    constraint known_devices_pkey primary key (id),
+    constraint known_devices_user_id_fingerprint_country_key unique (user_id, fingerprint, country)
+);
+
+-- tokens of the "this wasn't me" links of sign-in notifications
+create table if not exists {{ index .Options "Namespace" }}.sign_in_notifications (
+    id uuid not null,
+    user_id uuid not null references {{ index .Options "Namespace" }}.users(id) on delete cascade,
+    session_id uuid null references {{ index .Options "Namespace" }}.sessions(id) on delete set null,
+    token_hash text not null,
+    created_at timestamptz not null default now(),
+    expires_at timestamptz not null,
+    constraint sign_in_notifications_pkey primary key (id),
+    constraint sign_in_notifications_token_hash_key unique (token_hash)
+);
+
+create index if not exists sign_in_notifications_expires_at_idx
+    on {{ index .Options "Namespace" }}.sign_in_notifications (expires_at);
@@ -0,0 +1,17 @@
+do $$ begin
+    alter type {{ index .Options "Namespace" }}.factor_type add value 'recovery_code';
+exception
+    when duplicate_object then null;
+end $$;
+
+-- single-use recovery codes of recovery_code factors, only stored hashed
+create table if not exists {{ index .Options "Namespace" }}.mfa_recovery_codes (
+    id uuid not null,
+    factor_id uuid not null,
+    code_hash text not null,
+    created_at timestamptz not null default now(),
+    used_at timestamptz null,
+    constraint mfa_recovery_codes_pkey primary key (id),
+    constraint mfa_recovery_codes_factor_id_code_hash_key unique (factor_id, code_hash),
+    constraint mfa_recovery_codes_factor_id_fkey foreign key (factor_id) references {{ index .Options "Namespace" }}.mfa_factors(id) on delete cascade
+);
@@ -0,0 +1,34 @@
+-- discoverable WebAuthn credentials users can sign in with instead of a
+-- password
+create table if not exists {{ index .Options "Namespace" }}.passkeys (
+    id uuid not null,
+    user_id uuid not null,
+    credential_id text not null,
+    credential jsonb not null,
+    aaguid uuid null,
+    friendly_name text null,
+    created_at timestamptz not null default now(),
+    updated_at timestamptz not null default now(),
+    last_used_at timestamptz null,
+    constraint passkeys_pkey primary key (id),
+    constraint passkeys_credential_id_key unique (credential_id),
+    constraint passkeys_user_id_fkey foreign key (user_id) references {{ index .Options "Namespace" }}.users(id) on delete cascade
+);
+
+create index if not exists passkeys_user_id_idx
+    on {{ index .Options "Namespace" }}.passkeys (user_id);
+
+-- pending passkey ceremonies, user_id is the handle of the user being signed
+-- up or registering a passkey and null for sign-ins
+create table if not exists {{ index .Options "Namespace" }}.passkey_challenges (
+    id uuid not null,
+    user_id uuid null,
+    challenge_type text not null,
+    session_data jsonb not null,
+    created_at timestamptz not null default now(),
+    expires_at timestamptz not null,
+    constraint passkey_challenges_pkey primary key (id)
+);
+
+create index if not exists passkey_challenges_expires_at_idx
+    on {{ index .Options "Namespace" }}.passkey_challenges (expires_at);
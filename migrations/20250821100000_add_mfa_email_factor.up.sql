@@ -0,0 +1,11 @@
+do $$ begin
+    alter type {{ index .Options "Namespace" }}.factor_type add value 'email';
+exception
+    when duplicate_object then null;
+end $$;
+
+-- address email factors send their codes to, which can differ from the
+-- user's own email
+alter table {{ index .Options "Namespace" }}.mfa_factors add column if not exists email text null;
+
+create unique index if not exists unique_email_factor_per_user on {{ index .Options "Namespace" }}.mfa_factors (user_id, email);
@@ -0,0 +1,22 @@
+-- devices that skip MFA challenges after a successful verification, only
+-- storing a hash of the token handed to the device. Deleting the factor the
+-- device was trusted with revokes it.
+create table if not exists {{ index .Options "Namespace" }}.mfa_trusted_devices (
+    id uuid not null,
+    user_id uuid not null references {{ index .Options "Namespace" }}.users(id) on delete cascade,
+    factor_id uuid not null references {{ index .Options "Namespace" }}.mfa_factors(id) on delete cascade,
+    token_hash text not null,
+    user_agent text null,
+    ip inet null,
+    created_at timestamptz not null default now(),
+    last_used_at timestamptz null,
+    expires_at timestamptz not null,
+    constraint mfa_trusted_devices_pkey primary key (id),
+    constraint mfa_trusted_devices_token_hash_key unique (token_hash)
+);
+
+create index if not exists mfa_trusted_devices_user_id_idx
+    on {{ index .Options "Namespace" }}.mfa_trusted_devices (user_id);
+
+create index if not exists mfa_trusted_devices_expires_at_idx
+    on {{ index .Options "Namespace" }}.mfa_trusted_devices (expires_at);
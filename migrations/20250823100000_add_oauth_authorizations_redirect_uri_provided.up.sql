@@ -0,0 +1,4 @@
+-- whether the client sent redirect_uri with the authorization request, in
+-- which case it must also be sent when redeeming the authorization code
+alter table {{ index .Options "Namespace" }}.oauth_authorizations
+    add column if not exists redirect_uri_provided boolean not null default false;
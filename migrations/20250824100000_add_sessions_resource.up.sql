@@ -0,0 +1,4 @@
+-- RFC 8707: the resource the session was granted for, which is the only
+-- resource access tokens can be refreshed for
+alter table {{ index .Options "Namespace" }}.sessions
+    add column if not exists resource text null;
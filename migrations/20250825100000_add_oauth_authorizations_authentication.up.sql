@@ -0,0 +1,11 @@
+-- how the user authenticated in the session consent was given from, which is
+-- reported in the acr, amr and auth_time claims of ID tokens
+alter table {{ index .Options "Namespace" }}.oauth_authorizations
+    add column if not exists aal text null,
+    add column if not exists amr text null,
+    add column if not exists auth_time timestamptz null;
+
+alter table {{ index .Options "Namespace" }}.oauth_device_authorizations
+    add column if not exists aal text null,
+    add column if not exists amr text null,
+    add column if not exists auth_time timestamptz null;